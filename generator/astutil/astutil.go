@@ -163,10 +163,15 @@ func DupCallExpr(t *ast.CallExpr) *ast.CallExpr {
 	if t == nil {
 		return nil
 	}
-	return &ast.CallExpr{
+	r := &ast.CallExpr{
 		Fun:  DupExpr(t.Fun),
 		Args: DupExprList(t.Args),
 	}
+	if t.Ellipsis.IsValid() {
+		// The position is irrelevant, but it marks the variadic call f(x...).
+		r.Ellipsis = 1
+	}
+	return r
 }
 
 func DupIdent(id *ast.Ident) *ast.Ident {
@@ -262,6 +267,11 @@ func DupExpr(l ast.Expr) ast.Expr {
 			Op: t.Op,
 			X:  DupExpr(t.X),
 		}
+	case *ast.TypeAssertExpr:
+		return &ast.TypeAssertExpr{
+			X:    DupExpr(t.X),
+			Type: DupExpr(t.Type),
+		}
 	case *ast.ArrayType:
 		return &ast.ArrayType{
 			Len: DupExpr(t.Len),
@@ -312,6 +322,8 @@ func DupStmt(l ast.Stmt) ast.Stmt {
 		return nil
 	}
 	switch t := l.(type) {
+	case *ast.BlockStmt:
+		return DupBlockStmt(t)
 	case *ast.AssignStmt:
 		return &ast.AssignStmt{
 			Lhs:    DupExprList(t.Lhs),
@@ -319,6 +331,11 @@ func DupStmt(l ast.Stmt) ast.Stmt {
 			Tok:    t.Tok,
 			Rhs:    DupExprList(t.Rhs),
 		}
+	case *ast.BranchStmt:
+		return &ast.BranchStmt{
+			Tok:   t.Tok,
+			Label: DupIdent(t.Label),
+		}
 	case *ast.DeclStmt:
 		return &ast.DeclStmt{
 			Decl: DupDecl(t.Decl),
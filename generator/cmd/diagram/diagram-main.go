@@ -0,0 +1,83 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary diagram-main draws the railroad diagrams of the rules of a PEG
+// grammar and the graph of the dependencies between the rules.
+//
+// Usage: diagram-main --grammar=file.peg [--svg_dir=dir] [--dot=file.dot]
+//
+// It writes one SVG image per rule and index.html with all diagrams to
+// --svg_dir, and the dependency graph in Graphviz DOT format to --dot.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/salikh/peg/parser2"
+	"github.com/salikh/peg/parser2/diagram"
+)
+
+var (
+	grammarFlag = flag.String("grammar", "", "The path to the grammar file.")
+	svgDirFlag  = flag.String("svg_dir", "", "The directory to write the SVG railroad diagrams and index.html to.")
+	dotFlag     = flag.String("dot", "", "The path to write the rule dependency graph in DOT format to.")
+)
+
+func main() {
+	flag.Parse()
+	if *grammarFlag == "" {
+		log.Exitf("--grammar must not be empty.")
+	}
+	if *svgDirFlag == "" && *dotFlag == "" {
+		log.Exitf("At least one of --svg_dir and --dot must be specified.")
+	}
+	g, err := parser2.ParseGrammarFS(os.DirFS(filepath.Dir(*grammarFlag)), filepath.Base(*grammarFlag))
+	if err != nil {
+		log.Exitf("Error parsing the grammar file %q: %s", *grammarFlag, err)
+	}
+	if *svgDirFlag != "" {
+		if err := os.MkdirAll(*svgDirFlag, 0755); err != nil {
+			log.Exitf("Cannot create the directory %q: %s", *svgDirFlag, err)
+		}
+		href := func(rule string) string {
+			if _, ok := g.Rules[rule]; !ok {
+				return ""
+			}
+			return rule + ".svg"
+		}
+		for _, name := range g.RuleNames {
+			svg, err := diagram.SVG(g, name, href)
+			if err != nil {
+				log.Exitf("Error drawing the rule %s: %s", name, err)
+			}
+			write(filepath.Join(*svgDirFlag, name+".svg"), svg)
+		}
+		title := strings.TrimSuffix(filepath.Base(*grammarFlag), filepath.Ext(*grammarFlag))
+		write(filepath.Join(*svgDirFlag, "index.html"), diagram.HTML(g, title))
+	}
+	if *dotFlag != "" {
+		write(*dotFlag, diagram.DOT(g))
+	}
+}
+
+func write(filename, content string) {
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		log.Exitf("Cannot write %q: %s", filename, err)
+	}
+}
@@ -17,17 +17,19 @@ package main
 import (
 	"flag"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	log "github.com/golang/glog"
 	"github.com/salikh/peg/generator"
-	"github.com/salikh/peg/parser"
 )
 
 var (
-	grammarFlag = flag.String("grammar", "", "The path to the grammar file.")
+	grammarFlag = flag.String("grammar", "", "The path to the grammar file. The imported files are read relative to its directory.")
 	userSource  = flag.String("user_source", "", "The path to the go source file with data types. Optional.")
 	outputFlag  = flag.String("output", "", "The path to write the parser Go source.")
 	packageName = flag.String("package", "gen", "The name of the package to generate.")
+	byteMode    = flag.Bool("byte_mode", false, "If true, the dot and the char classes of the generated parser match single bytes.")
 )
 
 func main() {
@@ -38,15 +40,7 @@ func main() {
 	if *outputFlag == "" {
 		log.Exitf("--output must not be empty.")
 	}
-	grammar, err := ioutil.ReadFile(*grammarFlag)
-	if err != nil {
-		log.Exitf("Cannot read the grammar from %q: %s", *grammarFlag, err)
-	}
-	_, err = parser.New(string(grammar))
-	if err != nil {
-		log.Exitf("Error parsing the grammar file %q: %s", *grammarFlag, err)
-	}
-	g, err := generator.New(string(grammar))
+	g, err := generator.NewFromFS(os.DirFS(filepath.Dir(*grammarFlag)), filepath.Base(*grammarFlag))
 	if err != nil {
 		log.Exitf("Error parsing the PEG: %s", err)
 	}
@@ -55,6 +49,7 @@ func main() {
 		// ZZZ: For the time being, do not run the generator when having userSource.
 		return
 	}
+	g.ByteMode = *byteMode
 	output, err := g.Generate(*packageName)
 	if err != nil {
 		log.Exitf("Error generating the parser: %s", err)
@@ -23,7 +23,6 @@ import (
 
 	log "github.com/golang/glog"
 	"github.com/salikh/peg/generator"
-	"github.com/salikh/peg/parser"
 )
 
 var (
@@ -41,10 +40,6 @@ func main() {
 	if err != nil {
 		log.Exitf("Cannot read the grammar from %q: %s", *grammarFlag, err)
 	}
-	_, err = parser.New(string(grammar))
-	if err != nil {
-		log.Exitf("Error parsing the grammar file %q: %s", *grammarFlag, err)
-	}
 	g, err := generator.New(string(grammar))
 	if err != nil {
 		log.Exitf("Error parsing the PEG: %s", err)
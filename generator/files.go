@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Grammar files are merged in the same way as in parser2.NewFromFS:
+// imports are relative to the importing file, the rules of the main file
+// come first, and a rule defined in several files must be marked with
+// 'override' in exactly one of them.
+
+// NewFromFS creates a new parser generator from the grammar file name
+// in fsys, following the import directives. If there are imports, the
+// embedded grammar source of the generated parser is the concatenation
+// of all files, each preceded by a comment with the file name.
+func NewFromFS(fsys fs.FS, name string) (*generator, error) {
+	name = path.Clean(name)
+	l := &loader{fsys: fsys, files: make(map[string]*Grammar)}
+	err := l.load(name)
+	if err != nil {
+		return nil, err
+	}
+	g := &Grammar{
+		Rules:   make(map[string]*Rule),
+		Imports: l.files[name].Imports,
+	}
+	var sources []string
+	defs := make(map[string][]*Rule)
+	for _, file := range l.order {
+		fg := l.files[file]
+		sources = append(sources, "# "+file+"\n"+fg.Source)
+		if fg.Skip != "" && g.Skip != "" && fg.Skip != g.Skip {
+			return nil, fmt.Errorf("%s: %%skip %s conflicts with %%skip %s", file, fg.Skip, g.Skip)
+		}
+		if fg.Skip != "" {
+			g.Skip = fg.Skip
+		}
+		for _, ruleName := range fg.RuleNames {
+			if _, ok := defs[ruleName]; !ok {
+				g.RuleNames = append(g.RuleNames, ruleName)
+			}
+			defs[ruleName] = append(defs[ruleName], fg.Rules[ruleName])
+		}
+	}
+	g.Source = strings.Join(sources, "\n")
+	if len(l.order) == 1 {
+		g.Source = l.files[name].Source
+	}
+	for _, ruleName := range g.RuleNames {
+		rule, err := mergeRule(defs[ruleName])
+		if err != nil {
+			return nil, err
+		}
+		g.Rules[ruleName] = rule
+	}
+	err = g.expandParams()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrammar(g); err != nil {
+		return nil, err
+	}
+	return &generator{source: g.Source, Grammar: g}, nil
+}
+
+// loader reads the grammar files and their imports.
+type loader struct {
+	fsys  fs.FS
+	files map[string]*Grammar
+	// order is the list of loaded files in the order of the depth-first
+	// traversal of imports.
+	order []string
+}
+
+func (l *loader) load(name string) error {
+	if _, ok := l.files[name]; ok {
+		return nil
+	}
+	b, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return err
+	}
+	r, err := Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("%s: cannot parse grammar source: %s", name, err)
+	}
+	if err := checkLiterals(r.Tree, string(b)); err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	g, err := convertFile(r.Tree)
+	if err != nil {
+		return fmt.Errorf("%s: error constructing semantic tree: %s", name, err)
+	}
+	g.Source = string(b)
+	for _, rule := range g.Rules {
+		rule.File = name
+	}
+	l.files[name] = g
+	l.order = append(l.order, name)
+	for _, imp := range g.Imports {
+		err := l.load(path.Join(path.Dir(name), imp))
+		if err != nil {
+			return fmt.Errorf("%s: import %q: %s", name, imp, err)
+		}
+	}
+	return nil
+}
+
+// mergeRule selects the definition of a rule among the definitions
+// from different files.
+func mergeRule(defs []*Rule) (*Rule, error) {
+	var overrides []*Rule
+	for _, rule := range defs {
+		if rule.Override {
+			overrides = append(overrides, rule)
+		}
+	}
+	switch {
+	case len(overrides) > 1:
+		return nil, ruleError(overrides[1], fmt.Errorf(
+			"rule %s is already overridden in %s", overrides[1].Ident, overrides[0].File))
+	case len(overrides) == 1 && len(defs) == 1:
+		return nil, ruleError(defs[0], fmt.Errorf(
+			"rule %s does not override any rule", defs[0].Ident))
+	case len(overrides) == 1:
+		return overrides[0], nil
+	case len(defs) > 1:
+		return nil, ruleError(defs[1], fmt.Errorf(
+			"rule %s is already defined in %s, use 'override' to replace it",
+			defs[1].Ident, defs[0].File))
+	}
+	return defs[0], nil
+}
+
+// ruleError prefixes err with the name of the file where the rule
+// is defined.
+func ruleError(rule *Rule, err error) error {
+	if rule.File == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %s", rule.File, err)
+}
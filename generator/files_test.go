@@ -0,0 +1,95 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for name, source := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(source)}
+	}
+	return fsys
+}
+
+var commonGrammar = `Ident <- < [a-z]+ >
+Num <- < [0-9]+ >
+List(X) <- X ( "," X )*
+`
+
+func TestNewFromFS(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"main.peg":       "import \"lib/common.peg\"\nA <- List(Ident) Num\noverride Num <- < [0-9] >\n",
+		"lib/common.peg": commonGrammar,
+	})
+	g, err := NewFromFS(fsys, "main.peg")
+	if err != nil {
+		t.Fatalf("NewFromFS returns error %s, want success", err)
+	}
+	want := []string{"A", "Num", "Ident", "List_Ident"}
+	if !reflect.DeepEqual(g.RuleNames, want) {
+		t.Errorf("NewFromFS returns rules %q, want %q", g.RuleNames, want)
+	}
+	if g.Rules["Num"].File != "main.peg" || g.Rules["Ident"].File != "lib/common.peg" {
+		t.Errorf("NewFromFS returns rules from files %q and %q, want main.peg and lib/common.peg",
+			g.Rules["Num"].File, g.Rules["Ident"].File)
+	}
+	if _, err := g.Generate("gen"); err != nil {
+		t.Errorf("Generate returns error %s, want success", err)
+	}
+}
+
+func TestNewFromFSError(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- Ident\nIdent <- [A-Z]+\n",
+			"common.peg": commonGrammar,
+		}, "common.peg: rule Ident is already defined in main.peg, " +
+			"use 'override' to replace it"},
+		{map[string]string{
+			"main.peg": "A <- B\noverride B <- \"b\"\n",
+		}, "main.peg: rule B does not override any rule"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- List(Ident, Num)\n",
+			"common.peg": commonGrammar,
+		}, "main.peg: rule List expects 1 arguments, got 2"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\n%skip _\nA <- Ident\n_ <- \" \"*\n",
+			"common.peg": "%skip Space\n" + commonGrammar + "Space <- \" \"*\n",
+		}, "common.peg: %skip Space conflicts with %skip _"},
+	}
+	for _, tt := range tests {
+		_, err := NewFromFS(mapFS(tt.files), "main.peg")
+		if err == nil {
+			t.Errorf("NewFromFS(%q) returns success, want error %q", tt.files, tt.want)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("NewFromFS(%q) returns error %q, want %q", tt.files, err, tt.want)
+		}
+	}
+	source := "import \"common.peg\"\nA <- Ident\n"
+	if _, err := New(source); err == nil {
+		t.Errorf("New(%q) returns success, want error", source)
+	}
+}
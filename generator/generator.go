@@ -23,6 +23,7 @@ import (
 	"go/token"
 	"io/ioutil"
 	"strconv"
+	"strings"
 
 	log "github.com/golang/glog"
 	"github.com/salikh/peg/compat/runfiles"
@@ -42,6 +43,11 @@ type generator struct {
 	*Grammar
 	// packageName keeps the package name of the Go source file with user-provided types.
 	packageName string
+	// ByteMode specifies whether the generated parser matches the dot and
+	// the char classes against single bytes rather than UTF-8 runes, see
+	// parser2.ParserOptions.ByteMode. The parser then also has the function
+	// ParseBytes taking the input as a byte slice.
+	ByteMode bool
 }
 
 func (g *generator) ParseTree() *parser.Node {
@@ -61,10 +67,16 @@ func New(source string) (*generator, error) {
 		log.Infof("PEG AST:\n%v\n", r.Tree)
 	}
 	g.pegTree = r.Tree
+	if err := checkLiterals(g.pegTree, source); err != nil {
+		return nil, err
+	}
 	g.Grammar, err = ConvertGrammar2(g.pegTree)
 	if err != nil {
 		return nil, fmt.Errorf("error constructing semantic tree: %s", err)
 	}
+	if err := checkGrammar(g.Grammar); err != nil {
+		return nil, err
+	}
 	return g, nil
 }
 
@@ -72,7 +84,14 @@ func New(source string) (*generator, error) {
 // parser package. The generated package name is gen.
 func (g *generator) Generate(packagename string) (string, error) {
 	// The grammar source was parsed in New.
+	if _, err := predicateMethods(g.Grammar); err != nil {
+		return "", err
+	}
+	if _, ok := g.Rules[g.Skip]; g.Skip != "" && !ok {
+		return "", fmt.Errorf("undefined skip rule: %s", g.Skip)
+	}
 	// Now generate AST
+	byteMode = g.ByteMode
 	f := generateAST(g.Grammar, packagename)
 	// Add the grammar source as a top-level comment.
 	f.Doc = &ast.CommentGroup{
@@ -215,6 +234,10 @@ var ruleHandlers = make(map[string]string)
 // handler indices.
 var handlerIndices = make(map[string]int)
 
+// semanticMethods maps the names of the semantic predicates to the methods
+// of the Predicates interface.
+var semanticMethods = make(map[string]string)
+
 func lateSubstitutionsDoIt(node ast.Node) {
 	for _, l := range lateSubstitutions {
 		handlerName, ok := ruleHandlers[l.rule]
@@ -226,16 +249,29 @@ func lateSubstitutionsDoIt(node ast.Node) {
 	}
 }
 
-func makeParseFn(name string) *ast.FuncDecl {
+// makeParseFn makes the Parse function of the generated parser with the top
+// rule name. If recovers is set, the syntax errors recovered by the labeled
+// failures are collected into Result.Errors. If semantic is set, Parse takes
+// the implementation of the Predicates interface as the second argument.
+func makeParseFn(name string, recovers, semantic bool) *ast.FuncDecl {
 	parseFunc := astutil.DupFuncDecl(parseTemplate)
 	lateSubstituteIdent(parseFunc, "testHandler", name)
-	return gogen.Func("Parse", gogen.FuncType(
-		gogen.Fields(gogen.AField("source", gogen.Ident("string"))),
+	collect := ""
+	if recovers {
+		collect = "r.Errors = collectErrors(r.Tree)"
+	}
+	args := gogen.Fields(gogen.AField("source", gogen.Ident("string")))
+	init := ""
+	if semantic {
+		args = append(args, gogen.AField("predicates", gogen.Ident("Predicates")))
+		init = ", semantic: predicates"
+	}
+	return gogen.Func("Parse", gogen.FuncType(args,
 		gogen.Fields(gogen.Field(nil, gogen.Star(gogen.Ident("Result"))),
 			gogen.Field(nil, gogen.Ident("error")))),
 		// The top rule always has handler index 0.
 		gogen.Stmts(fmt.Sprintf(`
-  r := &Result{Source: source, Memo: make(map[int]map[int]*Node), NodeStack: make([]*Node, 0, 10)}
+  r := &Result{Source: source, Memo: make(map[int]map[int]*Node), NodeStack: make([]*Node, 0, 10)%s}
   w, err := apply(r, 0, %s, 0)
   if err != nil {
 		return r, err
@@ -243,12 +279,31 @@ func makeParseFn(name string) *ast.FuncDecl {
 	if w != len(source) {
 		return r, fmt.Errorf("some characters remain unconsumed: %%q", source[w:])
 	}
+	%s
   return r, nil
-`, name+"Handler"))...)
+`, init, name+"Handler", collect))...)
+
+}
 
+// makeParseBytesFn makes the function ParseBytes of the parsers generated
+// in byte mode, which takes the input as a byte slice.
+func makeParseBytesFn(semantic bool) *ast.FuncDecl {
+	args := gogen.Fields(gogen.AField("source", gogen.SliceType(gogen.Ident("byte"))))
+	call := "Parse(string(source))"
+	if semantic {
+		args = append(args, gogen.AField("predicates", gogen.Ident("Predicates")))
+		call = "Parse(string(source), predicates)"
+	}
+	return gogen.Func("ParseBytes", gogen.FuncType(args,
+		gogen.Fields(gogen.Field(nil, gogen.Star(gogen.Ident("Result"))),
+			gogen.Field(nil, gogen.Ident("error")))),
+		gogen.Stmts("return "+call)...)
 }
 
 func MakeDotHandler(handlerName string) []ast.Decl {
+	if byteMode {
+		return []ast.Decl{gogen.ByteDotHandler(handlerName)}
+	}
 	return []ast.Decl{gogen.DotHandler(handlerName)}
 }
 
@@ -262,7 +317,14 @@ func MakeCharClassHandler(cc *charclass.CharClass, handlerName string) []ast.Dec
 	if cc.Special == "[:any:]" {
 		return MakeDotHandler(handlerName)
 	}
-	return []ast.Decl{gogen.CharClassHandler(handlerName, cc)}
+	handler := gogen.CharClassHandler
+	if byteMode {
+		handler = gogen.ByteCharClassHandler
+	}
+	if cc.Expr != "" {
+		return []ast.Decl{gogen.CharClassTable(handlerName, cc), handler(handlerName, cc)}
+	}
+	return []ast.Decl{handler(handlerName, cc)}
 }
 
 func MakePlusHandler(handlerName, subHandler string) []ast.Decl {
@@ -310,20 +372,51 @@ func MakeTermsHandler(terms []*Term, handlerName string) []ast.Decl {
 	return r
 }
 
-// unQuote converts the captured literal string with framing quoted ''/""
+// unQuote converts the captured literal string with framing quotes ''/""/``
 // into the actual string.
 func unQuote(s string) (string, error) {
-	if s[0] == '"' {
-		return strconv.Unquote(s)
-	} else {
-		return s[1 : len(s)-1], nil
+	return parser.UnquoteLiteral(s)
+}
+
+// checkLiterals checks the literals of the syntax tree of the grammar
+// source, and returns the first error located in the source.
+func checkLiterals(n *parser.Node, source string) error {
+	if n.Label == "Literal" {
+		if _, err := unQuote(n.Text); err != nil {
+			if lerr, ok := err.(*parser.LiteralError); ok {
+				// The literal is captured at the end of the node.
+				return lerr.Locate(source, n.Pos+n.Len-len(n.Text))
+			}
+			return err
+		}
+	}
+	for _, c := range n.Children {
+		if err := checkLiterals(c, source); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func MakeTermHandler(term *Term, handlerName string) []ast.Decl {
 	switch {
+	case term.Recover != "":
+		subHandler := handlerName + "_recover"
+		t := *term
+		t.Recover = ""
+		r := MakeTermHandler(&t, subHandler)
+		return append(r, gogen.RecoverHandler(handlerName, subHandler,
+			term.Recover+"Handler", handlerIndices[term.Recover]))
+	case term.Prec != nil:
+		return makePrecedenceHandler(term, handlerName)
+	case term.Ident != "" && term.Label != "":
+		subHandler := handlerName + "_labeled"
+		r := makeRuleHandler(term.Ident, subHandler)
+		return append(r, gogen.LabeledHandler(handlerName, subHandler, term.Label))
 	case term.Ident != "":
 		return makeRuleHandler(term.Ident, handlerName)
+	case term.Literal != "" && term.IgnoreCase:
+		return []ast.Decl{gogen.LiteralFoldHandler(handlerName, term.Literal)}
 	case term.Literal != "":
 		return MakeLiteralHandler(term.Literal, handlerName)
 	case term.CharClass != nil:
@@ -334,6 +427,9 @@ func MakeTermHandler(term *Term, handlerName string) []ast.Decl {
 	case term.Capture != nil:
 		subHandler := handlerName + "_capture"
 		r := MakeRHSHandler(subHandler, subHandler, term.Capture)
+		if term.Label != "" {
+			return append(r, gogen.LabeledCaptureHandler(handlerName, subHandler, term.Label))
+		}
 		return append(r, gogen.CaptureHandler(handlerName, subHandler))
 	case term.Special != nil:
 		switch term.Special.Rune {
@@ -349,9 +445,17 @@ func MakeTermHandler(term *Term, handlerName string) []ast.Decl {
 			subHandler := handlerName + "_star"
 			r := MakeTermHandler(term.Special.Term, subHandler)
 			return append(r, MakeStarHandler(handlerName, subHandler)...)
+		case '{':
+			subHandler := handlerName + "_repeat"
+			r := MakeTermHandler(term.Special.Term, subHandler)
+			return append(r, gogen.RepeatHandler(handlerName, subHandler, term.Special.Min, term.Special.Max))
 		default:
 			log.Exitf("Handler for special:%s is NYI", term.Special)
 		}
+	case term.SemPred != "":
+		return []ast.Decl{gogen.SemPredHandler(handlerName, semanticMethods[term.SemPred], term.SemPred, false)}
+	case term.SemNegPred != "":
+		return []ast.Decl{gogen.SemPredHandler(handlerName, semanticMethods[term.SemNegPred], term.SemNegPred, true)}
 	case term.Pred != nil:
 		subHandler := handlerName + "_pos"
 		r := MakeTermHandler(term.Pred, subHandler)
@@ -363,6 +467,10 @@ func MakeTermHandler(term *Term, handlerName string) []ast.Decl {
 	case term.Parens != nil:
 		subHandler := handlerName + "_paren"
 		return MakeRHSHandler(handlerName, subHandler, term.Parens)
+	case term.Cut:
+		return []ast.Decl{gogen.CutHandler(handlerName)}
+	case term.BackRef != "":
+		return []ast.Decl{gogen.BackRefHandler(handlerName, term.BackRef)}
 	default:
 		log.Exitf("Handler for term %s is NYI", term)
 	}
@@ -495,13 +603,41 @@ func generateAST(g *Grammar, packagename string) *ast.File {
 		// Store handler and hi correspondence.
 		handlerIndices[ruleName] = hi
 	}
-	for _, rule := range g.Rules {
+	predicates, _ := predicateMethods(g)
+	var methods []string
+	for _, name := range predicates {
+		methods = append(methods, semanticMethods[name])
+	}
+	for _, ruleName := range g.RuleNames {
+		rule := g.Rules[ruleName]
+		if g.Skip != "" && !g.isLexical(rule) {
+			// The rewritten copy is only used for the code generation.
+			r := *rule
+			r.RHS = insertSkip(rule.RHS, g.Skip)
+			rule = &r
+		}
 		decls := makeRule(rule)
 		nf.Decls = append(nf.Decls, decls...)
 	}
 	labelsDecl := gogen.Var("labels", nil, gogen.Composite(gogen.SliceType(gogen.Ident("string")), labels))
-	parseFn := makeParseFn(top)
-	nf.Decls = append(nf.Decls, labelsDecl, parseFn)
+	var markers []ast.Expr
+	for _, ruleName := range g.RuleNames {
+		if marker := g.Rules[ruleName].Marker; marker != "" {
+			markers = append(markers, gogen.KeyValue(gogen.String(strconv.Quote(ruleName)),
+				gogen.String(strconv.Quote(marker))))
+		}
+	}
+	markersDecl := gogen.Var("markers", nil, gogen.Composite(
+		gogen.MapType(gogen.Ident("string"), gogen.Ident("string")), markers))
+	parseFn := makeParseFn(top, hasRecover(g), len(predicates) > 0)
+	nf.Decls = append(nf.Decls, labelsDecl, markersDecl, parseFn)
+	if byteMode {
+		nf.Decls = append(nf.Decls, makeParseBytesFn(len(predicates) > 0))
+	}
+	if len(predicates) > 0 {
+		nf.Decls = append(nf.Decls, gogen.PredicatesInterface(methods))
+		addResultField(nf, gogen.Field([]*ast.Ident{gogen.Ident("semantic")}, gogen.Ident("Predicates")))
+	}
 	lateSubstitutionsDoIt(nf)
 	// FIXME: use g.utf8Used
 	utf8visitor := &selectorVisitor{Name: "utf8"}
@@ -522,6 +658,142 @@ func generateAST(g *Grammar, packagename string) *ast.File {
 	return nf
 }
 
+// walkTerms calls f for every term of the grammar, including the nested
+// terms.
+func walkTerms(g *Grammar, f func(term *Term)) {
+	for _, name := range g.RuleNames {
+		forEachTerm(g.Rules[name].RHS, f)
+	}
+}
+
+// forEachTerm calls f for every term of rhs, including the nested terms.
+func forEachTerm(rhs *RHS, f func(term *Term)) {
+	for _, terms := range rhs.Terms {
+		for _, term := range terms {
+			forEachSubterm(term, f)
+		}
+	}
+}
+
+func forEachSubterm(term *Term, f func(term *Term)) {
+	f(term)
+	switch {
+	case term.Parens != nil:
+		forEachTerm(term.Parens, f)
+	case term.NegPred != nil:
+		forEachSubterm(term.NegPred, f)
+	case term.Pred != nil:
+		forEachSubterm(term.Pred, f)
+	case term.Special != nil:
+		forEachSubterm(term.Special.Term, f)
+	case term.Capture != nil:
+		forEachTerm(term.Capture, f)
+	}
+}
+
+// checkBackRefs checks that every back-reference $name of the rule refers
+// to a labeled capture <name: ...> of the same rule.
+func checkBackRefs(rule *Rule) error {
+	labels := make(map[string]bool)
+	forEachTerm(rule.RHS, func(term *Term) {
+		if term.Capture != nil && term.Label != "" {
+			labels[term.Label] = true
+		}
+	})
+	var err error
+	forEachTerm(rule.RHS, func(term *Term) {
+		if term.BackRef != "" && !labels[term.BackRef] && err == nil {
+			err = fmt.Errorf("back-reference $%s does not match a capture <%s: ...> in rule %s",
+				term.BackRef, term.BackRef, rule.Ident)
+		}
+	})
+	return err
+}
+
+// checkGrammar checks the rules of the grammar after the expansion of the
+// parameterized rules: the back-references, the recovery rules of the
+// labeled failures, and the terms that the parser generator does not
+// support.
+func checkGrammar(g *Grammar) error {
+	for _, name := range g.RuleNames {
+		rule := g.Rules[name]
+		if err := checkBackRefs(rule); err != nil {
+			return ruleError(rule, err)
+		}
+		var err error
+		forEachTerm(rule.RHS, func(term *Term) {
+			if err != nil {
+				return
+			}
+			if _, ok := g.Rules[term.Recover]; term.Recover != "" && !ok {
+				err = fmt.Errorf("unknown recovery rule: %s", term.Recover)
+			} else if term.Indent != "" {
+				err = fmt.Errorf("%s is not supported by the parser generator, use parser2", term.Indent)
+			}
+		})
+		if err != nil {
+			return ruleError(rule, err)
+		}
+	}
+	return nil
+}
+
+// hasRecover reports whether the grammar has labeled failures term^label.
+func hasRecover(g *Grammar) bool {
+	found := false
+	walkTerms(g, func(term *Term) {
+		found = found || term.Recover != ""
+	})
+	return found
+}
+
+// predicateMethods returns the names of the semantic predicates of the
+// grammar in the order of their first use, and stores the names of the
+// corresponding methods of the Predicates interface into semanticMethods.
+// The method name is the predicate name with the first letter capitalized,
+// so it is an error if two predicates differ only in the first letter case.
+func predicateMethods(g *Grammar) ([]string, error) {
+	var names []string
+	methods := make(map[string]string)
+	var err error
+	walkTerms(g, func(term *Term) {
+		name := term.SemPred
+		if name == "" {
+			name = term.SemNegPred
+		}
+		if name == "" {
+			return
+		}
+		method := strings.ToUpper(name[:1]) + name[1:]
+		if other, ok := methods[method]; ok {
+			if other != name && err == nil {
+				err = fmt.Errorf("semantic predicates %s and %s map to the same method %s", other, name, method)
+			}
+			return
+		}
+		methods[method] = name
+		semanticMethods[name] = method
+		names = append(names, name)
+	})
+	return names, err
+}
+
+// addResultField adds the field to the Result struct of the generated parser.
+func addResultField(f *ast.File, field *ast.Field) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == "Result" {
+				st := ts.Type.(*ast.StructType)
+				st.Fields.List = append(st.Fields.List, field)
+			}
+		}
+	}
+}
+
 var (
 	template                      *ast.File
 	charClassHandlerTemplate      *ast.FuncDecl
@@ -544,6 +816,8 @@ var (
 	pegG                          parser.Grammar
 	utf8Used                      = false
 	unicodeUsed                   = false
+	// byteMode is set from generator.ByteMode for the code generation.
+	byteMode = false
 )
 
 type funcFinder struct {
@@ -662,6 +936,7 @@ func cutTemplates(f *ast.File) {
 	parseTemplate = cutFunction(f, "Parse")
 
 	labelsTemplate = cutVar(f, "labels")
+	cutVar(f, "markers")
 	charClassHandlerTemplate = cutFunction(f, "CharClassHandler")
 	// TODO(salikh): This is not used for templating, only for testing.
 	cutFunction(f, "CharClassAlnumHandler")
@@ -674,6 +949,16 @@ func cutTemplates(f *ast.File) {
 	cutGroupHandlerTemplate(groupHandlerTemplate)
 	literalConstTemplate = cutConst(f, "literal")
 	literalHandlerTemplate = cutFunction(f, "LiteralHandler")
+	// TODO(salikh): This is not used for templating, only for testing.
+	cutFunction(f, "LiteralFoldHandler")
+	cutConst(f, "annotationKey")
+	cutFunction(f, "LabeledHandler")
+	cutFunction(f, "LabeledCaptureHandler")
+	cutFunction(f, "BackRefHandler")
+	cutFunction(f, "CutHandler")
+	cutFunction(f, "RecoverHandler")
+	cutVar(f, "precExpr")
+	cutFunction(f, "PrecedenceHandler")
 	plusHandlerTemplate = cutFunction(f, "PlusHandler")
 	predicateNegativeFlagTemplate = cutConst(f, "predicateNegative")
 	predicateHandlerTemplate = cutFunction(f, "PredicateHandler")
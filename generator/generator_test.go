@@ -0,0 +1,115 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratePredicates(t *testing.T) {
+	g, err := New(`A <- B &{isKeyword} / B !{isKeyword} &{ isTypeName }
+B <- < [a-z]+ >`)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	src, err := g.Generate("gen")
+	if err != nil {
+		t.Fatalf("Generate returns error %s, want success", err)
+	}
+	for _, want := range []string{
+		"func Parse(source string, predicates Predicates) (*Result, error)",
+		"type Predicates interface {\n\tIsKeyword(r *Result, pos int, node *parser.Node) bool\n" +
+			"\tIsTypeName(r *Result, pos int, node *parser.Node) bool\n}",
+		"r.semantic.IsKeyword(",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Generate returns source without %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGeneratePredicatesError(t *testing.T) {
+	g, err := New(`A <- &{isKeyword} &{IsKeyword} "a"`)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	want := "semantic predicates isKeyword and IsKeyword map to the same method IsKeyword"
+	if _, err := g.Generate("gen"); err == nil || err.Error() != want {
+		t.Errorf("Generate returns error %v, want %q", err, want)
+	}
+}
+
+func TestIndentNotSupported(t *testing.T) {
+	want := "INDENT is not supported by the parser generator, use parser2"
+	if _, err := New("A <- INDENT 'a' DEDENT"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestBackRefUndefined(t *testing.T) {
+	want := "back-reference $tag does not match a capture <tag: ...> in rule B"
+	if _, err := New("A <- <tag: 'a'> B\nB <- $tag"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestGenerateByteMode(t *testing.T) {
+	g, err := New(`A <- [\x80-\xff] .`)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	g.ByteMode = true
+	src, err := g.Generate("gen")
+	if err != nil {
+		t.Fatalf("Generate returns error %s, want success", err)
+	}
+	for _, want := range []string{
+		"func ParseBytes(source []byte) (*Result, error) {\n\treturn Parse(string(source))\n}",
+		"c, w := rune(r.Source[pos]), 1",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Generate returns source without %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "utf8.DecodeRuneInString") {
+		t.Errorf("Generate returns source decoding UTF-8 in byte mode:\n%s", src)
+	}
+}
+
+func TestGenerateUndefinedSkip(t *testing.T) {
+	g, err := New("%skip _\nSum <- \"1\" \"+\" \"1\"")
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	want := "undefined skip rule: _"
+	if _, err := g.Generate("gen"); err == nil || err.Error() != want {
+		t.Errorf("Generate returns error %v, want %q", err, want)
+	}
+}
+
+func TestLiteralErrorLocation(t *testing.T) {
+	want := `2:7: invalid escape sequence \q in literal "x\qy"`
+	if _, err := New("A <- 'a' B\nB <- \"x\\qy\""); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestUnknownRecoveryRule(t *testing.T) {
+	want := "unknown recovery rule: missing"
+	if _, err := New("A <- 'a'^missing"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
@@ -237,6 +237,14 @@ func Struct(fields ...*ast.Field) ast.Expr {
 	}
 }
 
+func Interface(methods ...*ast.Field) ast.Expr {
+	return &ast.InterfaceType{
+		Methods: &ast.FieldList{
+			List: methods,
+		},
+	}
+}
+
 func Var(name string, ty, val ast.Expr) *ast.GenDecl {
 	var values []ast.Expr
 	if val != nil {
@@ -387,6 +395,31 @@ func LiteralHandler(name, literal string) *ast.FuncDecl {
 		Return(Call(Ident("len"), Ident("literal")), Ident("nil")))
 }
 
+// LiteralFoldHandler generates Go AST for the handler of a case-insensitive
+// literal. The runes of the input are compared to the runes of the literal
+// using Unicode simple case folding, so the matched input may have
+// a different length in bytes than the literal.
+func LiteralFoldHandler(name, literal string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`const literal = %s
+n := 0
+for _, lc := range literal {
+	c, w := utf8.DecodeRuneInString(r.Source[pos+n:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting %%q, got %%q", literal, r.Source[pos:])
+	}
+	for f := c; f != lc; {
+		f = unicode.SimpleFold(f)
+		if f == c {
+			return 0, fmt.Errorf("expecting %%q, got %%q", literal, r.Source[pos:pos+n+w])
+		}
+	}
+	n += w
+}
+return n, nil`, strconv.Quote(literal)))...)
+}
+
 func makeCharClassMap(name string, m map[rune]bool) *ast.DeclStmt {
 	var vals []ast.Expr
 	for c := range m {
@@ -437,53 +470,118 @@ func makeRangeTable(name string, t *unicode.RangeTable) *ast.DeclStmt {
 	if t.R32 != nil {
 		var vals []ast.Expr
 		for _, rg := range t.R32 {
-			vals = append(vals, makeRange("unicode.Range16", int64(rg.Lo), int64(rg.Hi), int64(rg.Stride)))
+			vals = append(vals, makeRange("unicode.Range32", int64(rg.Lo), int64(rg.Hi), int64(rg.Stride)))
 		}
-		keyvals = append(keyvals, KeyValue(Ident("R32"), Composite(Star(SelIdent("unicode.Range32")), vals)))
+		keyvals = append(keyvals, KeyValue(Ident("R32"), Composite(SliceType(SelIdent("unicode.Range32")), vals)))
+	}
+	if t.LatinOffset != 0 {
+		keyvals = append(keyvals, KeyValue(Ident("LatinOffset"), Int(strconv.Itoa(t.LatinOffset))))
 	}
 	return DeclStmt(Var(name, nil, StructLiteral("&unicode.RangeTable", keyvals...)))
 }
 
+// CharClassTable generates the package-level range table of the handler
+// name of a composite character class. Composite classes are compiled
+// into large tables, so they are built once rather than on every call.
+func CharClassTable(name string, cc *charclass.CharClass) ast.Decl {
+	return makeRangeTable(name+"_table", cc.RangeTable).Decl
+}
+
 // CharClassHandler generates Go AST for the character class handler.
 // Note: it panics on invalid arg string, because checking validity is a
 // responsibility of the PEG parser.
 func CharClassHandler(name string, cc *charclass.CharClass) *ast.FuncDecl {
+	return charClassHandler(name, cc, false)
+}
+
+// ByteCharClassHandler is like CharClassHandler, but the handler matches
+// a single byte b of the input, which belongs to the class if the rune
+// with the code b does.
+func ByteCharClassHandler(name string, cc *charclass.CharClass) *ast.FuncDecl {
+	return charClassHandler(name, cc, true)
+}
+
+func charClassHandler(name string, cc *charclass.CharClass, byteMode bool) *ast.FuncDecl {
 	var stmt []ast.Stmt
-	var cond ast.Expr
+	// match returns the condition that the rune c belongs to the class.
+	var match func(c ast.Expr) ast.Expr
 	switch {
 	case cc.Special == "[:alnum:]":
-		cond = Binary(Call(Sel(Ident("unicode"), "IsLetter"), Ident("c")), token.LOR,
-			Call(Sel(Ident("unicode"), "IsNumber"), Ident("c")))
+		match = func(c ast.Expr) ast.Expr {
+			return Binary(Call(Sel(Ident("unicode"), "IsLetter"), c), token.LOR,
+				Call(Sel(Ident("unicode"), "IsNumber"), c))
+		}
 	case cc.Special != "":
-		cond = Call(Sel(Ident("unicode"), cc.Special), Ident("c"))
+		match = func(c ast.Expr) ast.Expr {
+			return Call(Sel(Ident("unicode"), cc.Special), c)
+		}
 	default:
 		if cc.Map != nil {
 			stmt = append(stmt, makeCharClassMap("charClassMap", cc.Map))
-			cond = Index(Ident("charClassMap"), Ident("c"))
 		}
-		if cc.RangeTable != nil {
-			stmt = append(stmt, makeRangeTable("rangeTable", cc.RangeTable))
-			newcond := Call(Sel(Ident("unicode"), "Is"), Ident("rangeTable"), Ident("c"))
-			if cond != nil {
-				cond = Binary(cond, token.LOR, newcond)
-			} else {
-				cond = newcond
+		table := "rangeTable"
+		switch {
+		case cc.Property != "":
+			table = "unicode." + cc.Property
+		case cc.Expr != "":
+			table = name + "_table"
+		case cc.RangeTable != nil:
+			stmt = append(stmt, makeRangeTable(table, cc.RangeTable))
+		}
+		match = func(c ast.Expr) ast.Expr {
+			var cond ast.Expr
+			if cc.Map != nil {
+				cond = Index(Ident("charClassMap"), c)
 			}
+			if cc.RangeTable != nil {
+				newcond := Call(Sel(Ident("unicode"), "Is"), SelIdent(table), c)
+				if cond != nil {
+					cond = Binary(cond, token.LOR, newcond)
+				} else {
+					cond = newcond
+				}
+			}
+			return cond
 		}
 	}
+	if byteMode {
+		stmt = append(stmt, Stmts(`
+			if pos == len(r.Source) {
+				return 0, fmt.Errorf("expecting char, got EOF")
+			}
+			c, w := rune(r.Source[pos]), 1
+		`)...)
+	} else {
+		stmt = append(stmt,
+			AssignMulti(E(Ident("c"), Ident("w")), E(Call(Sel(Ident("utf8"), "DecodeRuneInString"),
+				Slice(Sel(Ident("r"), "Source"), Ident("pos"), nil)))),
+			If(nil, Binary(Ident("w"), token.EQL, Int("0")), Return(Int("0"), Call(Sel(Ident("fmt"), "Errorf"),
+				String(`"expecting char, got EOF"`)))),
+			If(nil, Binary(Ident("c"), token.EQL, Sel(Ident("utf8"), "RuneError")),
+				Return(Ident("w"), Call(Sel(Ident("fmt"), "Errorf"), String(`"invalid utf8: %q"`),
+					Slice(Sel(Ident("r"), "Source"), Ident("pos"), Binary(Ident("pos"), token.ADD, Ident("w")))))))
+	}
+	cond := match(Ident("c"))
+	if cc.IgnoreCase {
+		// Try the runes equivalent to c under simple case folding.
+		stmt = append(stmt,
+			Assign(Ident("match"), cond),
+			For(Assign(Ident("f"), Call(Sel(Ident("unicode"), "SimpleFold"), Ident("c"))),
+				Binary(Unary(token.NOT, Ident("match")), token.LAND, Binary(Ident("f"), token.NEQ, Ident("c"))),
+				Assign(Ident("f"), Call(Sel(Ident("unicode"), "SimpleFold"), Ident("f")), token.ASSIGN),
+				Assign(Ident("match"), match(Ident("f")), token.ASSIGN)))
+		cond = Ident("match")
+	}
 	if !cc.Negated {
 		cond = Unary(token.NOT, cond)
 	}
+	class := "[" + cc.String() + "]"
+	if cc.IgnoreCase {
+		class += "i"
+	}
 	stmt = append(stmt,
-		AssignMulti(E(Ident("c"), Ident("w")), E(Call(Sel(Ident("utf8"), "DecodeRuneInString"),
-			Slice(Sel(Ident("r"), "Source"), Ident("pos"), nil)))),
-		If(nil, Binary(Ident("w"), token.EQL, Int("0")), Return(Int("0"), Call(Sel(Ident("fmt"), "Errorf"),
-			String(`"expecting char, got EOF"`)))),
-		If(nil, Binary(Ident("c"), token.EQL, Sel(Ident("utf8"), "RuneError")),
-			Return(Ident("w"), Call(Sel(Ident("fmt"), "Errorf"), String(`"invalid utf8: %q"`),
-				Slice(Sel(Ident("r"), "Source"), Ident("pos"), Binary(Ident("pos"), token.ADD, Ident("w")))))),
 		If(nil, cond,
-			Return(Int("0"), Call(Sel(Ident("fmt"), "Errorf"), String(fmt.Sprintf(`"character %%q does not match class [%s]"`, cc)), Ident("c")))),
+			Return(Int("0"), Call(Sel(Ident("fmt"), "Errorf"), String(strconv.Quote("character %q does not match class "+class)), Ident("c")))),
 		Return(Ident("w"), Ident("nil")),
 	)
 	return Func(name, FuncType(
@@ -493,19 +591,21 @@ func CharClassHandler(name string, cc *charclass.CharClass) *ast.FuncDecl {
 }
 
 func StarHandler(name, subhandler string) *ast.FuncDecl {
+	stmts := Stmts(fmt.Sprintf(`
+			ww := 0
+			save := r.saveState()
+			k := r.push(pos)
+			for w, err := %s(r, pos); err == nil && w > 0; w, err = %s(r, pos+ww) {
+				ww += w
+				save = r.saveState()
+				r.points[k] = pos + ww
+			}
+			r.points = r.points[:k]
+			r.restoreState(save)
+			return ww, nil
+		`, subhandler, subhandler))
 	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
-		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
-		Assign(Ident("ww"), Int("0")),
-		For(AssignMulti(E(Ident("w"), Ident("err")), E(Call(Ident(subhandler),
-			Ident("r"), Ident("pos")))),
-			Binary(Binary(Ident("err"), token.EQL, Ident("nil")),
-				token.LAND, Binary(Ident("w"), token.GTR, Int("0"))),
-			AssignMulti(E(Ident("w"), Ident("err")),
-				E(Call(Ident(subhandler), Ident("r"), Binary(Ident("pos"), token.ADD, Ident("ww")))), token.ASSIGN),
-			Assign(Ident("ww"), Ident("w"), token.ADD_ASSIGN),
-		),
-		Return(Ident("ww"), Ident("nil")),
-	)
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))), stmts...)
 }
 
 func GroupHandler(name string, subhandlers []string) *ast.FuncDecl {
@@ -536,9 +636,13 @@ func PredicateHandler(name, subhandler string, negative bool) *ast.FuncDecl {
 	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
 		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
 		DeclStmt(Const("negative", nil, Ident(negStr))),
+		Stmt("r.predicates++"),
+		Stmt("scope := r.enter(pos, false)"),
 		AssignMulti(E(Ident("_"), Ident("err")), E(
 			Call(Ident(subhandler),
 				Ident("r"), Ident("pos")))),
+		Stmt("r.leave(scope)"),
+		Stmt("r.predicates--"),
 		If(nil, Binary(Ident("negative"), token.EQL, Binary(Ident("err"), token.NEQ, Ident("nil"))),
 			Return(Int("0"), Ident("nil"))),
 		If(nil, Binary(Ident("err"), token.EQL, Ident("nil")),
@@ -548,17 +652,26 @@ func PredicateHandler(name, subhandler string, negative bool) *ast.FuncDecl {
 }
 
 func ChoiceHandler(name, subhandler string, subhandlers ...string) *ast.FuncDecl {
-	stmts := []ast.Stmt{
-		AssignMulti(E(Ident("w"), Ident("err")), E(
-			Call(Ident(subhandler), Ident("r"), Ident("pos")))),
+	var stmts []ast.Stmt
+	if len(subhandlers) > 0 {
+		stmts = append(stmts, Stmt("scope := r.enter(pos, true)"),
+			Assign(Ident("save"), Call(Sel(Ident("r"), "saveState"))))
 	}
+	stmts = append(stmts, AssignMulti(E(Ident("w"), Ident("err")), E(
+		Call(Ident(subhandler), Ident("r"), Ident("pos")))))
 	for _, subhandler := range subhandlers {
+		// The next alternative is not tried if the failed one passed a cut.
 		stmts = append(stmts,
-			If(nil, Binary(Ident("err"), token.NEQ, Ident("nil")),
+			If(nil, Binary(Binary(Ident("err"), token.NEQ, Ident("nil")), token.LAND,
+				Unary(token.NOT, Sel(Ident("r"), "cut"))),
+				ExprStmt(Call(Sel(Ident("r"), "restoreState"), Ident("save"))),
 				AssignMulti(E(Ident("w"), Ident("err")), E(
 					Call(Ident(subhandler), Ident("r"), Ident("pos"))), token.ASSIGN),
 			))
 	}
+	if len(subhandlers) > 0 {
+		stmts = append(stmts, Stmt("r.leave(scope)"))
+	}
 	stmts = append(stmts, Return(Ident("w"), Ident("err")))
 	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
 		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))), stmts...)
@@ -578,6 +691,166 @@ func CaptureHandler(name, subhandler string) *ast.FuncDecl {
 		`, subhandler))...)
 }
 
+// LabeledCaptureHandler makes the handler of a labeled capture <label: ...>,
+// which stores the captured text in the annotation label of the top node.
+func LabeledCaptureHandler(name, subhandler, label string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			w, err := %s(r, pos)
+			if err != nil {
+				return w, err
+			}
+			r.annotate(%q, r.Source[pos:pos+w])
+			return w, nil
+		`, subhandler, label))...)
+}
+
+// BackRefHandler makes the handler of a back-reference $label, which
+// matches the text stored by the labeled capture <label: ...> in the top
+// node.
+func BackRefHandler(name, label string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			text, ok := r.TopNode().Annotations[%q]
+			if !ok {
+				return 0, fmt.Errorf(%q)
+			}
+			if len(r.Source)-pos < len(text) || r.Source[pos:pos+len(text)] != text {
+				return 0, fmt.Errorf("expecting %s = %%q, got %%q", text, r.Source[pos:])
+			}
+			return len(text), nil
+		`, label, "back-reference $"+label+" before capture", "$"+label))...)
+}
+
+// LabeledHandler makes the handler of a labeled rule reference label:Rule,
+// which stores the node of the rule in the tree annotation label of the
+// top node.
+func LabeledHandler(name, subhandler, label string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			save := r.saveState()
+			w, err := %s(r, pos)
+			if err != nil {
+				return w, err
+			}
+			r.annotateTree(save, %q)
+			return w, nil
+		`, subhandler, label))...)
+}
+
+// CutHandler makes the handler of the cut operator ~, which commits
+// the parser to the current alternative of the innermost choice.
+func CutHandler(name string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(`
+			r.commit(pos)
+			return 0, nil
+		`)...)
+}
+
+// RecoverHandler makes the handler of a labeled failure term^label. If the
+// subhandler of the term fails, the recovery rule with the handler index
+// ruleIndex is applied by ruleHandler at the same position.
+func RecoverHandler(name, subhandler, ruleHandler string, ruleIndex int) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			save := r.saveState()
+			k := r.push(pos)
+			w, err := %s(r, pos)
+			r.points = r.points[:k]
+			if err == nil {
+				return w, nil
+			}
+			r.restoreState(save)
+			return r.recover(pos, w, err, %s, %d)
+		`, subhandler, ruleHandler, ruleIndex))...)
+}
+
+// PrecOp is an operator of a precedence table, see PrecedenceTable.
+type PrecOp struct {
+	Literal string
+	// Label is the label of the operator nodes, or empty if the nodes are
+	// labeled by the rule name.
+	Label string
+	// Level is the precedence of the operator, starting from 1.
+	Level int
+	Right bool
+}
+
+// PrecedenceTable makes the variable with the precedence table of the
+// prefix, postfix and infix operators for PrecedenceHandler.
+func PrecedenceTable(name string, prefix, postfix, infix []PrecOp) ast.Decl {
+	var fields []string
+	for _, kind := range []struct {
+		name string
+		ops  []PrecOp
+	}{{"prefix", prefix}, {"postfix", postfix}, {"infix", infix}} {
+		if len(kind.ops) == 0 {
+			continue
+		}
+		var ops []string
+		for _, op := range kind.ops {
+			ops = append(ops, fmt.Sprintf("{%q, %q, %d, %t}", op.Literal, op.Label, op.Level, op.Right))
+		}
+		fields = append(fields, fmt.Sprintf("%s: []precOp{%s}", kind.name, strings.Join(ops, ", ")))
+	}
+	return Var(name, nil, Expr(fmt.Sprintf("precTable{%s}", strings.Join(fields, ", "))))
+}
+
+// PrecedenceHandler makes the handler of a rule defined by the precedence
+// table in the variable table. The operands are parsed by the handler
+// operand, and the handler skip is applied between the operators and
+// operands unless it is empty.
+func PrecedenceHandler(name, table, operand, skip string) *ast.FuncDecl {
+	if skip == "" {
+		skip = "nil"
+	}
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			return r.precedence(&%s, %s, %s, pos)
+		`, table, operand, skip))...)
+}
+
+// PredicatesInterface makes the interface Predicates with the methods that
+// implement the semantic predicates of a grammar.
+func PredicatesInterface(methods []string) ast.Decl {
+	var fields []*ast.Field
+	for _, method := range methods {
+		fields = append(fields, AField(method, PredicateFuncType()))
+	}
+	return Type("Predicates", Interface(fields...))
+}
+
+// PredicateFuncType makes the signature of the semantic predicate methods.
+func PredicateFuncType() *ast.FuncType {
+	return FuncType(Fields(AField("r", Star(Ident("Result"))), AField("pos", Ident("int")),
+		AField("node", Star(Sel(Ident("parser"), "Node")))), Fields(Field(nil, Ident("bool"))))
+}
+
+// SemPredHandler makes the handler of a semantic predicate, which calls
+// the method of the Predicates interface that was passed to Parse. The
+// negative predicate !{name} succeeds if the method returns false.
+func SemPredHandler(name, method, predicate string, negative bool) *ast.FuncDecl {
+	cond, expected := "!", "&{"+predicate+"}"
+	if negative {
+		cond, expected = "", "!{"+predicate+"}"
+	}
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(fmt.Sprintf(`
+			if %sr.semantic.%s(r, pos, (*parser.Node)(r.TopNode())) {
+				return 0, fmt.Errorf(%s)
+			}
+			return 0, nil
+		`, cond, method, strconv.Quote("semantic predicate "+expected+" failed")))...)
+}
+
 func DotHandler(name string) *ast.FuncDecl {
 	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
 		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
@@ -593,14 +866,31 @@ func DotHandler(name string) *ast.FuncDecl {
 				`)...)
 }
 
+// ByteDotHandler generates Go AST for the handler of the dot that matches
+// any byte.
+func ByteDotHandler(name string) *ast.FuncDecl {
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))),
+		Stmts(`
+					if pos == len(r.Source) {
+						return 0, fmt.Errorf("expected character, got EOF")
+					}
+					return 1, nil
+				`)...)
+}
+
 func QuestionHandler(name, subhandler string) *ast.FuncDecl {
 	stmts := []ast.Stmt{
+		Assign(Ident("save"), Call(Sel(Ident("r"), "saveState"))),
+		Stmt("k := r.push(pos)"),
 		AssignMulti(E(Ident("w"), Ident("err")), E(
 			Call(Ident(subhandler), Ident("r"), Ident("pos")))),
 	}
 	stmts = append(stmts,
 		Stmts(`
+			r.points = r.points[:k]
 			if err != nil {
+				r.restoreState(save)
 				return 0, nil
 			}
 			return w, nil
@@ -616,15 +906,60 @@ func PlusHandler(name, subhandler string) *ast.FuncDecl {
 				return 0, err
 			}
 			ww := w
+			save := r.saveState()
+			k := r.push(pos + ww)
 			for w, err = %s(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = %s(r, pos+ww) {
 				ww += w
+				save = r.saveState()
+				r.points[k] = pos + ww
 			}
+			r.points = r.points[:k]
+			r.restoreState(save)
 			return ww, nil
 		`, subhandler, subhandler, subhandler))
 	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
 		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))), stmts...)
 }
 
+// RepeatHandler makes the handler of the bounded repetition {min,max}.
+// If max is negative, the number of repetitions is not bounded.
+func RepeatHandler(name, subhandler string, min, max int) *ast.FuncDecl {
+	cond := fmt.Sprintf("n < %d", max)
+	if max < 0 {
+		cond = ""
+	}
+	fail := ""
+	if min > 0 {
+		fail = fmt.Sprintf(`
+				if err != nil && n < %d {
+					r.points = r.points[:k]
+					return ww + w, err
+				}`, min)
+	}
+	stmts := Stmts(fmt.Sprintf(`
+			ww := 0
+			save := r.saveState()
+			k := r.push(pos)
+			for n := 0; %s; n++ {
+				w, err := %s(r, pos+ww)%s
+				if err != nil {
+					break
+				}
+				ww += w
+				save = r.saveState()
+				r.points[k] = pos + ww
+				if w == 0 {
+					break
+				}
+			}
+			r.points = r.points[:k]
+			r.restoreState(save)
+			return ww, nil
+		`, cond, subhandler, fail))
+	return Func(name, FuncType(Fields(AField("r", Star(Ident("Result"))),
+		AField("pos", Ident("int"))), Fields(Field(nil, Ident("int")), Field(nil, Ident("error")))), stmts...)
+}
+
 func ParseStmt(stmt string) (ast.Stmt, error) {
 	source := `package my
 
@@ -19,11 +19,16 @@ import (
 	"go/parser"
 	"go/token"
 	"testing"
+	"unicode"
 
 	"github.com/salikh/peg/parser/charclass"
 )
 
 func TestPackage(t *testing.T) {
+	setClass, err := charclass.Parse(`[a-d\U00010400-\U0001044f]--[a]`)
+	if err != nil {
+		t.Fatalf("charclass.Parse returns error %s", err)
+	}
 	tests := []struct {
 		want string
 		f    *ast.File
@@ -172,6 +177,116 @@ func CharClassAlnumHandler(r *Result, pos int) (int, error) {
 		{
 			`package mypackage
 
+func CharClassFoldHandler(r *Result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'k': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	match := charClassMap[c]
+	for f := unicode.SimpleFold(c); !match && f != c; f = unicode.SimpleFold(f) {
+		match = charClassMap[f]
+	}
+	if !match {
+		return 0, fmt.Errorf("character %q does not match class [k]i", c)
+	}
+	return w, nil
+}
+`,
+			Package("mypackage", []string{}, CharClassHandler("CharClassFoldHandler",
+				&charclass.CharClass{Map: map[rune]bool{'k': true}, IgnoreCase: true})),
+		},
+		{
+			`package mypackage
+
+func ByteCharClassHandler(r *Result, pos int) (int, error) {
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x80, Hi: 0xff, Stride: 1}}}
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	c, w := rune(r.Source[pos]), 1
+	if !unicode.Is(rangeTable, c) {
+		return 0, fmt.Errorf("character %q does not match class [\\u0080-ÿ]", c)
+	}
+	return w, nil
+}
+`,
+			Package("mypackage", []string{}, ByteCharClassHandler("ByteCharClassHandler",
+				&charclass.CharClass{RangeTable: &unicode.RangeTable{R16: []unicode.Range16{{Lo: 0x80, Hi: 0xff, Stride: 1}}}})),
+		},
+		{
+			`package mypackage
+
+var CharClassSetHandler_table = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x62, Hi: 0x64, Stride: 1}}, R32: []unicode.Range32{unicode.Range32{Lo: 0x10400, Hi: 0x1044f, Stride: 1}}, LatinOffset: 1}
+
+func CharClassSetHandler(r *Result, pos int) (int, error) {
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !unicode.Is(CharClassSetHandler_table, c) {
+		return 0, fmt.Errorf("character %q does not match class [[a-d\\U00010400-\\U0001044f]--[a]]", c)
+	}
+	return w, nil
+}
+`,
+			Package("mypackage", []string{},
+				CharClassTable("CharClassSetHandler", setClass),
+				CharClassHandler("CharClassSetHandler", setClass)),
+		},
+		{
+			`package mypackage
+
+func CharClassGreekHandler(r *Result, pos int) (int, error) {
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if unicode.Is(unicode.Greek, c) {
+		return 0, fmt.Errorf("character %q does not match class [^\\p{Greek}]", c)
+	}
+	return w, nil
+}
+`,
+			Package("mypackage", []string{}, CharClassHandler("CharClassGreekHandler",
+				&charclass.CharClass{Property: "Greek", RangeTable: unicode.Greek, Negated: true})),
+		},
+		{
+			`package mypackage
+
+func LiteralFoldHandler(r *Result, pos int) (int, error) {
+	const literal = "abc"
+	n := 0
+	for _, lc := range literal {
+		c, w := utf8.DecodeRuneInString(r.Source[pos+n:])
+		if w == 0 {
+			return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+		}
+		for f := c; f != lc; {
+			f = unicode.SimpleFold(f)
+			if f == c {
+				return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:pos+n+w])
+			}
+		}
+		n += w
+	}
+	return n, nil
+}
+`,
+			Package("mypackage", []string{}, LiteralFoldHandler("LiteralFoldHandler", "abc")),
+		},
+		{
+			`package mypackage
+
 func CharClassAlnumHandler(r *Result, pos int) (int, error) {
 	c, w := utf8.DecodeRuneInString(r.Source[pos:])
 	if w == 0 {
@@ -206,9 +321,15 @@ func CharClassAlnumHandler(r *Result, pos int) (int, error) {
 
 func StarHandler1(r *Result, pos int) (int, error) {
 	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
 	for w, err := XHandler(r, pos); err == nil && w > 0; w, err = XHandler(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
 `,
@@ -290,7 +411,11 @@ func GroupHandler(r *Result, pos int) (int, error) {
 
 func PredicateHandler(r *Result, pos int) (int, error) {
 	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
 	_, err := ZHandler(r, pos)
+	r.leave(scope)
+	r.predicates--
 	if negative == (err != nil) {
 		return 0, nil
 	}
@@ -334,13 +459,18 @@ func PredicateHandler(r *Result, pos int) (int, error) {
 			`package mypackage
 
 func ChoiceHandler0(r *Result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
 	w, err := Handler1(r, pos)
-	if err != nil {
+	if err != nil && !r.cut {
+		r.restoreState(save)
 		w, err = Handler2(r, pos)
 	}
-	if err != nil {
+	if err != nil && !r.cut {
+		r.restoreState(save)
 		w, err = Handler3(r, pos)
 	}
+	r.leave(scope)
 	return w, err
 }
 `,
@@ -387,6 +517,18 @@ func DotHandler1(r *Result, pos int) (int, error) {
 		{
 			`package mypackage
 
+func ByteDotHandler(r *Result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	return 1, nil
+}
+`,
+			Package("mypackage", []string{}, ByteDotHandler("ByteDotHandler")),
+		},
+		{
+			`package mypackage
+
 func DotHandler(r *Result, pos int) (int, error) {
 	if pos == len(r.Source) {
 		return 0, fmt.Errorf("expected character, got EOF")
@@ -416,8 +558,12 @@ func DotHandler(r *Result, pos int) (int, error) {
 			`package mypackage
 
 func QuestionHandler0(r *Result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
 	w, err := Handler1(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
+		r.restoreState(save)
 		return 0, nil
 	}
 	return w, nil
@@ -425,6 +571,170 @@ func QuestionHandler0(r *Result, pos int) (int, error) {
 `,
 			Package("mypackage", []string{}, QuestionHandler("QuestionHandler0", "Handler1")),
 		},
+		{
+			`package mypackage
+
+func LabeledHandler0(r *Result, pos int) (int, error) {
+	save := r.saveState()
+	w, err := Handler1(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.annotateTree(save, "name")
+	return w, nil
+}
+`,
+			Package("mypackage", []string{}, LabeledHandler("LabeledHandler0", "Handler1", "name")),
+		},
+		{
+			`package mypackage
+
+func LabeledCaptureHandler0(r *Result, pos int) (int, error) {
+	w, err := Handler1(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.annotate("key", r.Source[pos:pos+w])
+	return w, nil
+}
+`,
+			Package("mypackage", []string{}, LabeledCaptureHandler("LabeledCaptureHandler0", "Handler1", "key")),
+		},
+		{
+			`package mypackage
+
+func BackRefHandler0(r *Result, pos int) (int, error) {
+	text, ok := r.TopNode().Annotations["tag"]
+	if !ok {
+		return 0, fmt.Errorf("back-reference $tag before capture")
+	}
+	if len(r.Source)-pos < len(text) || r.Source[pos:pos+len(text)] != text {
+		return 0, fmt.Errorf("expecting $tag = %q, got %q", text, r.Source[pos:])
+	}
+	return len(text), nil
+}
+`,
+			Package("mypackage", []string{}, BackRefHandler("BackRefHandler0", "tag")),
+		},
+		{
+			`package mypackage
+
+func RepeatHandler0(r *Result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for n := 0; n < 3; n++ {
+		w, err := Handler1(r, pos+ww)
+		if err != nil && n < 2 {
+			r.points = r.points[:k]
+			return ww + w, err
+		}
+		if err != nil {
+			break
+		}
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+		if w == 0 {
+			break
+		}
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+`,
+			Package("mypackage", []string{}, RepeatHandler("RepeatHandler0", "Handler1", 2, 3)),
+		},
+		{
+			`package mypackage
+
+func RepeatHandler0(r *Result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for n := 0; ; n++ {
+		w, err := Handler1(r, pos+ww)
+		if err != nil {
+			break
+		}
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+		if w == 0 {
+			break
+		}
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+`,
+			Package("mypackage", []string{}, RepeatHandler("RepeatHandler0", "Handler1", 0, -1)),
+		},
+		{
+			`package mypackage
+
+func CutHandler0(r *Result, pos int) (int, error) {
+	r.commit(pos)
+	return 0, nil
+}
+`,
+			Package("mypackage", []string{}, CutHandler("CutHandler0")),
+		},
+		{
+			`package mypackage
+
+func RecoverHandler0(r *Result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Handler1(r, pos)
+	r.points = r.points[:k]
+	if err == nil {
+		return w, nil
+	}
+	r.restoreState(save)
+	return r.recover(pos, w, err, SemiHandler, 5)
+}
+`,
+			Package("mypackage", []string{}, RecoverHandler("RecoverHandler0", "Handler1", "SemiHandler", 5)),
+		},
+		{
+			`package mypackage
+
+func SemPredHandler0(r *Result, pos int) (int, error) {
+	if r.semantic.IsTypeName(r, pos, (*parser.Node)(r.TopNode())) {
+		return 0, fmt.Errorf("semantic predicate !{isTypeName} failed")
+	}
+	return 0, nil
+}
+`,
+			Package("mypackage", []string{}, SemPredHandler("SemPredHandler0", "IsTypeName", "isTypeName", true)),
+		},
+		{
+			`package mypackage
+
+type Predicates interface {
+	IsTypeName(r *Result, pos int, node *parser.Node) bool
+	IsKeyword(r *Result, pos int, node *parser.Node) bool
+}
+`,
+			Package("mypackage", []string{}, PredicatesInterface([]string{"IsTypeName", "IsKeyword"})),
+		},
+		{
+			`package mypackage
+
+var Handler1_table = precTable{prefix: []precOp{{"-", "Neg", 3, false}}, infix: []precOp{{"+", "", 1, false}, {"^", "Pow", 2, true}}}
+
+func PrecedenceHandler0(r *Result, pos int) (int, error) {
+	return r.precedence(&Handler1_table, Handler1_operand, nil, pos)
+}
+`,
+			Package("mypackage", []string{},
+				PrecedenceTable("Handler1_table", []PrecOp{{"-", "Neg", 3, false}}, nil,
+					[]PrecOp{{"+", "", 1, false}, {"^", "Pow", 2, true}}),
+				PrecedenceHandler("PrecedenceHandler0", "Handler1_table", "Handler1_operand", "")),
+		},
 	}
 
 	for _, tt := range tests {
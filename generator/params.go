@@ -0,0 +1,198 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parameterized rules, e.g. CommaList(X) <- X (_ "," X)*, are expanded
+// when the grammar is constructed. Each distinct invocation, e.g.
+// CommaList(Ident), creates an instance rule named by joining the rule name
+// and the argument names with underscores, e.g. CommaList_Ident, with the
+// parameters replaced by the arguments. The instance name is also the label
+// of the syntax tree nodes produced by the instance. Arguments that are
+// invocations themselves are instantiated first, so List(Pair(A, B)) is
+// named List_Pair_A_B. The instances are appended to Grammar.RuleNames in
+// the order of their first invocation, and the parameterized rules
+// themselves are removed from the grammar.
+
+// maxInstances limits the number of instances to detect infinite
+// instantiation, e.g. in A(X) <- A(B(X)).
+const maxInstances = 1000
+
+// expandParams replaces invocations of parameterized rules with
+// references to their instances.
+func (g *Grammar) expandParams() error {
+	templates := make(map[string]*Rule)
+	var names []string
+	for _, name := range g.RuleNames {
+		rule := g.Rules[name]
+		if len(rule.Params) == 0 {
+			names = append(names, name)
+			continue
+		}
+		if len(names) == 0 {
+			return ruleError(rule, fmt.Errorf("top rule %s cannot have parameters", name))
+		}
+		templates[name] = rule
+		delete(g.Rules, name)
+	}
+	g.RuleNames = names
+	signatures := make(map[string]string)
+	// Instances are appended to g.RuleNames in the loop.
+	for i := 0; i < len(g.RuleNames); i++ {
+		rule := g.Rules[g.RuleNames[i]]
+		err := g.instantiateRHS(rule.RHS, templates, signatures)
+		if err != nil {
+			return ruleError(rule, err)
+		}
+	}
+	return nil
+}
+
+// instantiateRHS instantiates all invocations of parameterized rules in rhs.
+func (g *Grammar) instantiateRHS(rhs *RHS, templates map[string]*Rule, signatures map[string]string) error {
+	for _, terms := range rhs.Terms {
+		for _, term := range terms {
+			err := g.instantiateTerm(term, templates, signatures)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// instantiateTerm instantiates all invocations of parameterized rules in
+// term.
+func (g *Grammar) instantiateTerm(term *Term, templates map[string]*Rule, signatures map[string]string) error {
+	switch {
+	case term.Parens != nil:
+		return g.instantiateRHS(term.Parens, templates, signatures)
+	case term.NegPred != nil:
+		return g.instantiateTerm(term.NegPred, templates, signatures)
+	case term.Pred != nil:
+		return g.instantiateTerm(term.Pred, templates, signatures)
+	case term.Special != nil:
+		return g.instantiateTerm(term.Special.Term, templates, signatures)
+	case term.Capture != nil:
+		return g.instantiateRHS(term.Capture, templates, signatures)
+	}
+	return g.instantiate(term, templates, signatures)
+}
+
+// instantiate replaces the invocation of a parameterized rule with
+// the reference to its instance, creating the instance if necessary.
+// The signatures map instance names to the invocations that created them.
+func (g *Grammar) instantiate(term *Term, templates map[string]*Rule, signatures map[string]string) error {
+	if term.Ident == "" {
+		return nil
+	}
+	template, ok := templates[term.Ident]
+	if !ok {
+		if len(term.Args) > 0 {
+			return fmt.Errorf("rule %s does not have parameters", term.Ident)
+		}
+		return nil
+	}
+	if len(term.Args) != len(template.Params) {
+		return fmt.Errorf("rule %s expects %d arguments, got %d",
+			term.Ident, len(template.Params), len(term.Args))
+	}
+	subst := make(map[string]string)
+	var args []string
+	for i, arg := range term.Args {
+		err := g.instantiate(arg, templates, signatures)
+		if err != nil {
+			return err
+		}
+		subst[template.Params[i]] = arg.Ident
+		args = append(args, arg.Ident)
+	}
+	name := term.Ident + "_" + strings.Join(args, "_")
+	signature := term.Ident + "(" + strings.Join(args, ", ") + ")"
+	term.Ident = name
+	term.Args = nil
+	if prev, ok := signatures[name]; ok {
+		if prev != signature {
+			return fmt.Errorf("instances %s and %s have the same name %s",
+				prev, signature, name)
+		}
+		return nil
+	}
+	if _, ok := g.Rules[name]; ok {
+		return fmt.Errorf("instance %s conflicts with rule %s", signature, name)
+	}
+	if len(signatures) >= maxInstances {
+		return fmt.Errorf("too many instances of parameterized rules, "+
+			"possibly infinite instantiation in %s", signature)
+	}
+	signatures[name] = signature
+	g.Rules[name] = &Rule{
+		Ident:  name,
+		File:   template.File,
+		Token:  template.Token,
+		Marker: template.Marker,
+		RHS:    substRHS(template.RHS, subst),
+	}
+	g.RuleNames = append(g.RuleNames, name)
+	return nil
+}
+
+// substRHS returns a copy of rhs with the rule references replaced
+// according to subst.
+func substRHS(rhs *RHS, subst map[string]string) *RHS {
+	r := &RHS{}
+	for _, terms := range rhs.Terms {
+		var c []*Term
+		for _, term := range terms {
+			c = append(c, substTerm(term, subst))
+		}
+		r.Terms = append(r.Terms, c)
+	}
+	return r
+}
+
+func substTerm(term *Term, subst map[string]string) *Term {
+	t := *term
+	switch {
+	case t.Parens != nil:
+		t.Parens = substRHS(t.Parens, subst)
+	case t.NegPred != nil:
+		t.NegPred = substTerm(t.NegPred, subst)
+	case t.Pred != nil:
+		t.Pred = substTerm(t.Pred, subst)
+	case t.Special != nil:
+		t.Special = &Special{
+			Term: substTerm(t.Special.Term, subst),
+			Rune: t.Special.Rune,
+			Min:  t.Special.Min,
+			Max:  t.Special.Max,
+		}
+	case t.Capture != nil:
+		t.Capture = substRHS(t.Capture, subst)
+	case t.Ident != "":
+		if arg, ok := subst[t.Ident]; ok {
+			t.Ident = arg
+		}
+		t.Args = nil
+		for _, arg := range term.Args {
+			t.Args = append(t.Args, substTerm(arg, subst))
+		}
+	}
+	return &t
+}
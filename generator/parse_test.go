@@ -89,6 +89,12 @@ var parseTests = []parseTest{
 		`(Grammar
 	    (Rule text("A") (RHS (Choice
 			 (Term :Special(Special (Term :CharClass("[:any:]")) :Rune("+")))))))`},
+	{`A <- .{2} .{1,3} . {0,}`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("[:any:]")) :Rune("{") :Min("2") :Max("2")))
+			 (Term :Special(Special (Term :CharClass("[:any:]")) :Rune("{") :Min("1") :Max("3")))
+			 (Term :Special(Special (Term :CharClass("[:any:]")) :Rune("{") :Min("0")))))))`},
 	{`A <- !.`,
 		`(Grammar
 	    (Rule text("A") (RHS (Choice
@@ -237,6 +243,71 @@ var parseTests = []parseTest{
 		`(Grammar
 	    (Rule text("A") (RHS (Choice (Term :CharClass("[:any:]")))))
 	    (Rule text("B") (RHS (Choice (Term :CharClass("[:any:]"))))))`},
+	{`A <- L(B)
+	L(X) <- X ("," X)*
+	B <- "b"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice (Term :Ident("L_B")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("L_B") (RHS (Choice
+			 (Term :Ident("B"))
+			 (Term :Special(Special
+			  (Term :Parens(RHS (Choice (Term :Literal(",")) (Term :Ident("B")))))
+			  :Rune("*")))))))`},
+	{`A <- P(B, C) P(C, B) P(B, C)
+	P(X, Y) <- X Y
+	B <- "b"
+	C <- "c"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Ident("P_B_C")) (Term :Ident("P_C_B")) (Term :Ident("P_B_C")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("C") (RHS (Choice (Term :Literal("c")))))
+	    (Rule text("P_B_C") (RHS (Choice (Term :Ident("B")) (Term :Ident("C")))))
+	    (Rule text("P_C_B") (RHS (Choice (Term :Ident("C")) (Term :Ident("B"))))))`},
+	{`A <- L(P(B,
+	  B))
+	L(X) <- X L(X)?
+	P(X, Y) <- X Y
+	B <- "b"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice (Term :Ident("L_P_B_B")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("P_B_B") (RHS (Choice (Term :Ident("B")) (Term :Ident("B")))))
+	    (Rule text("L_P_B_B") (RHS (Choice
+			 (Term :Ident("P_B_B"))
+			 (Term :Special(Special (Term :Ident("L_P_B_B")) :Rune("?")))))))`},
+	{`A <- B (C)
+	B <- "b"
+	C <- "c"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Ident("B"))
+			 (Term :Parens(RHS (Choice (Term :Ident("C"))))))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("C") (RHS (Choice (Term :Literal("c"))))))`},
+	{`%skip _
+	Sum <- Num "+" Num
+	%token Num <- [0-9]+
+	_ <- " "*`,
+		`(Grammar :Skip("_")
+	    (Rule text("Sum") (RHS (Choice
+			 (Term :Ident("Num")) (Term :Literal("+")) (Term :Ident("Num")))))
+	    (Rule text("Num") :Token (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("0-9")) :Rune("+"))))))
+	    (Rule text("_") (RHS (Choice
+			 (Term :Special(Special (Term :Literal(" ")) :Rune("*")))))))`},
+	{`Expr <- %prec Num
+	  %left Add:"+" "-"
+	  %prefix Neg:"-"
+	Num <- [0-9]+`,
+		`(Grammar
+	    (Rule text("Expr") (RHS (Choice
+			 (Term :Ident("Num") :Prec(Precedence
+			   (Level :Kind("left") (Op :Literal("+") :Label("Add")) (Op :Literal("-")))
+			   (Level :Kind("prefix") (Op :Literal("-") :Label("Neg"))))))))
+	    (Rule text("Num") (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("0-9")) :Rune("+")))))))`},
 }
 
 type invalidParseTest struct {
@@ -260,6 +331,28 @@ var invalidParseTests = []invalidParseTest{
 	{source: `A <- .
 
 	/ [x] B <- .`, syntaxErr: `"<-`},
+	{source: `A <- L(B, B)
+	L(X) <- X`, semanticErr: `rule L expects 1 arguments, got 2`},
+	{source: `A <- L
+	L(X) <- X`, semanticErr: `rule L expects 1 arguments, got 0`},
+	{source: `A <- B(C)
+	B <- "b"`, semanticErr: `rule B does not have parameters`},
+	{source: `L(X) <- X
+	A <- L(A)`, semanticErr: `top rule L cannot have parameters`},
+	{source: `A <- L(B)
+	L(X) <- X
+	L_B <- "b"`, semanticErr: `instance L\(B\) conflicts with rule L_B`},
+	{source: `A <- L(P(B, C)) L_P(B, C)
+	L(X) <- X
+	L_P(X, Y) <- X Y
+	P(X, Y) <- X Y`, semanticErr: `instances L\(P_B_C\) and L_P\(B, C\) have the same name L_P_B_C`},
+	{source: `A <- L(B)
+	L(X) <- L(M(X))
+	M(X) <- X`, semanticErr: `too many instances`},
+	{source: `E <- %prec N %left "+" "+"
+	N <- [0-9]`, semanticErr: `operator "\+" is defined more than once`},
+	{source: `E <- %prec N %left ""
+	N <- [0-9]`, semanticErr: `empty operator in precedence table`},
 }
 
 func TestParse2(t *testing.T) {
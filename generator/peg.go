@@ -37,12 +37,34 @@ type Grammar struct {
 	// RuleNames keeps the list of rule name in the original definition order.
 	RuleNames []string
 	Source    string
+	// Imports is the list of files imported by the grammar source.
+	Imports []string
+	// Skip is the name of the rule set by the directive %skip, which is
+	// inserted between the terms of the syntactic rules, see
+	// parser2.Grammar.Skip.
+	Skip string
 }
 
 // Rule represent one PEG rule (Rule <- RHS).
 type Rule struct {
 	// Ident is the name of the rule, defined in LHS.
 	Ident string
+	// File is the name of the file where the rule is defined,
+	// if the grammar was loaded with NewFromFS.
+	File string
+	// Override is true if the rule replaces the rule with the same name
+	// from an imported file.
+	Override bool
+	// Token is true if the rule is marked with %token as a lexical rule,
+	// which is exempt from the %skip directive.
+	Token bool
+	// Marker is "inline", "drop" or "keep" if the rule has a tree shape
+	// marker, see parser2.Rule.Marker.
+	Marker string
+	// Params is the list of parameter names of a parameterized rule.
+	// Parameterized rules are expanded during the grammar construction
+	// and do not appear in Grammar.Rules.
+	Params []string
 	// RHS is the rule's right-hand side.
 	*RHS
 }
@@ -61,18 +83,58 @@ type Term struct {
 	Parens  *RHS
 	NegPred *Term
 	Pred    *Term
+	// SemPred and SemNegPred are set to the name of the semantic predicate
+	// for &{name} and !{name} respectively. The generated parser calls the
+	// predicates through the Predicates interface.
+	SemPred    string
+	SemNegPred string
 	*Special
 	Capture *RHS
 	*charclass.CharClass
 	Literal string
-	Ident   string
+	// IgnoreCase specifies that Literal is matched case-insensitively.
+	// Case-insensitive char classes have CharClass.IgnoreCase set instead.
+	IgnoreCase bool
+	Ident      string
+	// Args are the arguments of a parameterized rule invocation Ident(Args).
+	// Each argument has an Ident and optionally Args. The invocations are
+	// replaced with references to the rule instances during the grammar
+	// construction.
+	Args []*Term
+	// Label is set for labeled rule references name:Ident, which store the
+	// node in TreeAnnotations, and for labeled captures <name: ...>, which
+	// store the text in Annotations instead of Text.
+	Label string
+	// Cut is set for the cut operator ~, which commits the parser to the
+	// current alternative of the innermost choice of the rule.
+	Cut bool
+	// Indent is set for the indentation terms INDENT, DEDENT and SAMEDENT,
+	// which are only supported by parser2.
+	Indent string
+	// BackRef is set for the back-references $name to the name of the
+	// labeled capture <name: ...> of the same rule, whose text it matches.
+	BackRef string
+	// Recover is set for the labeled failures term^label to the label, which
+	// is also the name of the recovery rule applied if the term fails.
+	Recover string
+	// recoverLabel is set on the placeholder terms that hold the postfix
+	// recovery labels during the conversion.
+	recoverLabel string
+	// Prec is set for the rules defined by a precedence table %prec Ident,
+	// where Ident is the operand rule. The term is the only term of the
+	// rule.
+	Prec *Precedence
 }
 
-// Special is a term with a option or repeat special modifer (*?+).
+// Special is a term with a option or repeat special modifer (*?+), or
+// with a bounded repetition {n}, {n,} or {n,m}.
 type Special struct {
 	*Term
-	// Rune is one of '*' '?' '+'.
+	// Rune is one of '*' '?' '+', or '{' for the bounded repetition.
 	Rune rune
+	// Min and Max are the bounds of the bounded repetition. Max is -1 if
+	// the repetition has no upper bound.
+	Min, Max int
 }
 
 func (g *Grammar) String() string {
@@ -80,6 +142,9 @@ func (g *Grammar) String() string {
 		return "(nil)"
 	}
 	r := []string{"(Grammar "}
+	if g.Skip != "" {
+		r = append(r, `:Skip(`, strconv.Quote(g.Skip), `) `)
+	}
 	for _, name := range g.RuleNames {
 		rule := g.Rules[name]
 		r = append(r, rule.String())
@@ -93,6 +158,9 @@ func (rule *Rule) String() string {
 		return "(nil)"
 	}
 	r := []string{`(Rule text("`, rule.Ident, `") `}
+	if rule.Token {
+		r = append(r, ":Token ")
+	}
 	r = append(r, rule.RHS.String())
 	r = append(r, ")")
 	return strings.Join(r, "")
@@ -125,6 +193,12 @@ func (t *Term) String() string {
 	if t.Pred != nil {
 		r = append(r, " :Pred", t.Pred.String())
 	}
+	if t.SemPred != "" {
+		r = append(r, ` :SemPred(`, strconv.Quote(t.SemPred), `)`)
+	}
+	if t.SemNegPred != "" {
+		r = append(r, ` :SemNegPred(`, strconv.Quote(t.SemNegPred), `)`)
+	}
 	if t.Capture != nil {
 		r = append(r, " :Capture", t.Capture.String())
 	}
@@ -134,12 +208,33 @@ func (t *Term) String() string {
 	if t.Literal != "" {
 		r = append(r, ` :Literal(`, strconv.Quote(t.Literal), `)`)
 	}
+	if t.IgnoreCase || t.CharClass != nil && t.CharClass.IgnoreCase {
+		r = append(r, ` :IgnoreCase`)
+	}
 	if t.Ident != "" {
 		r = append(r, ` :Ident(`, strconv.Quote(t.Ident), `)`)
 	}
+	if t.Prec != nil {
+		r = append(r, ` :Prec`, t.Prec.String())
+	}
+	if t.Label != "" {
+		r = append(r, ` :Label(`, strconv.Quote(t.Label), `)`)
+	}
+	if t.Cut {
+		r = append(r, ` :Cut`)
+	}
+	if t.Indent != "" {
+		r = append(r, ` :Indent(`, strconv.Quote(t.Indent), `)`)
+	}
+	if t.BackRef != "" {
+		r = append(r, ` :BackRef(`, strconv.Quote(t.BackRef), `)`)
+	}
 	if t.Special != nil {
 		r = append(r, ` :Special`, t.Special.String())
 	}
+	if t.Recover != "" {
+		r = append(r, ` :Recover(`, strconv.Quote(t.Recover), `)`)
+	}
 	r = append(r, ")")
 	return strings.Join(r, "")
 }
@@ -151,6 +246,12 @@ func (s *Special) String() string {
 		r = append(r, s.Term.String())
 	}
 	r = append(r, ` :Rune("`, q[1:len(q)-1], `")`)
+	if s.Rune == '{' {
+		r = append(r, ` :Min("`, strconv.Itoa(s.Min), `")`)
+		if s.Max >= 0 {
+			r = append(r, ` :Max("`, strconv.Itoa(s.Max), `")`)
+		}
+	}
 	r = append(r, ")")
 	return strings.Join(r, "")
 }
@@ -158,6 +259,28 @@ func (s *Special) String() string {
 // ConvertGrammar2 is a  reimplementation of the semantic tree reconstruction
 // using callbacks.
 func ConvertGrammar2(n *parser.Node) (*Grammar, error) {
+	g, err := convertFile(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Imports) > 0 {
+		return nil, fmt.Errorf("import %q requires a file system, use NewFromFS",
+			g.Imports[0])
+	}
+	for _, name := range g.RuleNames {
+		if g.Rules[name].Override {
+			return nil, fmt.Errorf("rule %s does not override any rule", name)
+		}
+	}
+	if err := g.expandParams(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// convertFile converts the syntax tree of one grammar file into
+// the semantic tree without expanding the parameterized rules.
+func convertFile(n *parser.Node) (*Grammar, error) {
 	val, err := Construct(n, callback, &AccessorOptions{
 		ErrorOnUnusedChild: true,
 	})
@@ -172,6 +295,9 @@ func ConvertGrammar2(n *parser.Node) (*Grammar, error) {
 	return g, nil
 }
 
+// indentTerms lists the names of the indentation terms, which are reserved.
+var indentTerms = map[string]bool{"INDENT": true, "DEDENT": true, "SAMEDENT": true}
+
 // The callback that is used to convert the syntax parse tree into
 // the semantic tree.
 func callback(label string, ca Accessor) (interface{}, error) {
@@ -187,15 +313,70 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			rules[rule.Ident] = rule
 			ruleNames = append(ruleNames, rule.Ident)
 		}
+		var imports []string
+		if i, err := ca.GetTyped("Import", []string{}); err == nil {
+			imports = i.([]string)
+		}
+		skip, _ := ca.GetString("Skip")
 		return &Grammar{
 			Rules:     rules,
 			RuleNames: ruleNames,
+			Imports:   imports,
+			Skip:      skip,
 		}, nil
+	case "Import":
+		return unQuote(ca.String("Literal"))
+	case "Skip":
+		return ca.String("Ident"), nil
 	case "Rule":
+		if name := ca.String("Ident"); indentTerms[name] {
+			return nil, fmt.Errorf("%s is a reserved name and cannot be defined as a rule", name)
+		}
+		var params []string
+		if p, err := ca.GetTyped("Params", []string{}); err == nil {
+			params = p.([]string)
+		}
+		_, err := ca.GetTyped("Override", true)
+		_, tokenErr := ca.GetTyped("Token", true)
+		marker, _ := ca.GetString("Marker")
+		var rhs *RHS
+		if prec, err := ca.GetTyped("Prec", &Term{}); err == nil {
+			rhs = &RHS{Terms: [][]*Term{{prec.(*Term)}}}
+		} else {
+			rhs = ca.Get("RHS", &RHS{}).(*RHS)
+		}
 		return &Rule{
-			Ident: ca.String("Ident"),
-			RHS:   ca.Get("RHS", &RHS{}).(*RHS),
+			Ident:    ca.String("Ident"),
+			Override: err == nil,
+			Token:    tokenErr == nil,
+			Marker:   marker,
+			Params:   params,
+			RHS:      rhs,
 		}, nil
+	case "Override", "Token":
+		return true, nil
+	case "Marker":
+		return ca.Node().Text, nil
+	case "Params":
+		return ca.Get("Ident", []string{}).([]string), nil
+	case "Prec":
+		prec := &Precedence{Levels: ca.Get("PrecLevel", []*PrecLevel{}).([]*PrecLevel)}
+		if err := prec.check(); err != nil {
+			return nil, err
+		}
+		return &Term{Ident: ca.String("Ident"), Prec: prec}, nil
+	case "PrecLevel":
+		return &PrecLevel{
+			Kind: ca.Node().Text,
+			Ops:  ca.Get("PrecOp", []*PrecOp{}).([]*PrecOp),
+		}, nil
+	case "PrecOp":
+		literal, err := unQuote(ca.String("Literal"))
+		if err != nil {
+			return nil, err
+		}
+		label, _ := ca.GetString("Label")
+		return &PrecOp{Label: label, Literal: literal}, nil
 	case "RHS":
 		return &RHS{ca.Get("Terms", [][]*Term{}).([][]*Term)}, nil
 	case "Terms":
@@ -216,6 +397,23 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			terms = append(terms[0:i-1], terms[i:]...)
 			i--
 		}
+		// Attach the postfix recovery labels ^label to the previous terms.
+		for i := 0; i < len(terms); i++ {
+			if terms[i].recoverLabel == "" {
+				continue
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("recovery label ^%s cannot be first in the rule",
+					terms[i].recoverLabel)
+			}
+			if terms[i-1].Recover != "" {
+				return nil, fmt.Errorf("term has more than one recovery label: ^%s^%s",
+					terms[i-1].Recover, terms[i].recoverLabel)
+			}
+			terms[i-1].Recover = terms[i].recoverLabel
+			terms = append(terms[0:i], terms[i+1:]...)
+			i--
+		}
 		return terms, nil
 	case "Term":
 		term := &Term{}
@@ -226,25 +424,42 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			term.NegPred = ca.Get("NegPred", &Term{}).(*Term)
 		case "Pred":
 			term.Pred = ca.Get("Pred", &Term{}).(*Term)
+		case "SemPred":
+			term.SemPred = ca.String("SemPred")
+		case "SemNegPred":
+			term.SemNegPred = ca.String("SemNegPred")
 		case "Capture":
-			term.Capture = ca.Get("Capture", &RHS{}).(*RHS)
+			term = ca.Get("Capture", &Term{}).(*Term)
 		case "CharClass":
 			term.CharClass = ca.Get("CharClass", &charclass.CharClass{}).(*charclass.CharClass)
+			if _, err := ca.GetTyped("IgnoreCase", true); err == nil {
+				term.CharClass.IgnoreCase = true
+			}
 		case "Literal":
-			raw := ca.String("Literal")
-			var unquoted string
-			if raw[0] == '"' {
-				var err error
-				unquoted, err = strconv.Unquote(raw)
-				if err != nil {
-					return nil, fmt.Errorf("error in strconv.Unquote(%q): %s", raw, err)
-				}
-			} else {
-				unquoted = raw[1 : len(raw)-1]
+			unquoted, err := unQuote(ca.String("Literal"))
+			if err != nil {
+				return nil, err
 			}
 			term.Literal = unquoted
+			if _, err := ca.GetTyped("IgnoreCase", true); err == nil {
+				term.IgnoreCase = true
+			}
+		case "Labeled":
+			term = ca.Get("Labeled", &Term{}).(*Term)
+		case "Call":
+			term = ca.Get("Call", &Term{}).(*Term)
 		case "Ident":
 			term.Ident = ca.String("Ident")
+		case "Cut":
+			term.Cut = true
+		case "Indent":
+			term.Indent = ca.String("Indent")
+		case "BackRef":
+			term.BackRef = ca.String("BackRef")
+		case "Repeat":
+			term.Special = ca.Get("Repeat", &Special{}).(*Special)
+		case "Recover":
+			term.recoverLabel = ca.String("Recover")
 		case "Special":
 			special := ca.Get("Special", &Special{}).(*Special)
 			if special.Rune == '.' {
@@ -259,25 +474,81 @@ func callback(label string, ca Accessor) (interface{}, error) {
 	case "Special":
 		c, _ := utf8.DecodeRuneInString(ca.Node().Text)
 		return &Special{Rune: c}, nil
+	case "Repeat":
+		return parseRepeat(ca.Node().Text)
+	case "Recover":
+		return ca.String("Ident"), nil
 	case "Parens":
 		return ca.GetTyped("RHS", &RHS{})
 	case "NegPred":
 		return ca.GetTyped("Term", &Term{})
 	case "Pred":
 		return ca.GetTyped("Term", &Term{})
+	case "SemPred", "SemNegPred":
+		return ca.String("Ident"), nil
 	case "Capture":
-		return ca.GetTyped("RHS", &RHS{})
+		label, _ := ca.GetString("Label")
+		return &Term{
+			Capture: ca.Get("RHS", &RHS{}).(*RHS),
+			Label:   label,
+		}, nil
+	case "Call":
+		return &Term{
+			Ident: ca.String("Ident"),
+			Args:  ca.Get("Arg", []*Term{}).([]*Term),
+		}, nil
+	case "Labeled":
+		term := &Term{Label: ca.String("Label")}
+		if call, err := ca.GetTyped("Call", &Term{}); err == nil {
+			term.Ident = call.(*Term).Ident
+			term.Args = call.(*Term).Args
+		} else {
+			term.Ident = ca.String("Ident")
+		}
+		return term, nil
+	case "Label":
+		return ca.Node().Text, nil
+	case "Arg":
+		if call, err := ca.GetTyped("Call", &Term{}); err == nil {
+			return call, nil
+		}
+		return &Term{Ident: ca.String("Ident")}, nil
 	case "Literal":
 		return ca.Node().Text, nil
-	case "Ident":
+	case "Ident", "Indent", "BackRef":
 		return ca.Node().Text, nil
 	case "CharClass":
 
 		return charclass.Parse(ca.Node().Text)
-	case "EndOfLine":
+	case "IgnoreCase":
+		return true, nil
+	case "EndOfLine", "Cut", "ClassItem":
 		return nil, nil
 	case "_":
 		return nil, nil
 	}
 	return nil, fmt.Errorf("Unexpected label: %s", label)
 }
+
+// parseRepeat parses the bounds n, n, or n,m of a bounded repetition.
+func parseRepeat(text string) (*Special, error) {
+	minText, maxText, comma := strings.Cut(text, ",")
+	min, err := strconv.Atoi(minText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repetition {%s}: %s", text, err)
+	}
+	max := min
+	switch {
+	case comma && maxText == "":
+		max = -1
+	case comma:
+		max, err = strconv.Atoi(maxText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repetition {%s}: %s", text, err)
+		}
+		if max < min {
+			return nil, fmt.Errorf("invalid repetition {%s}: maximum is less than minimum", text)
+		}
+	}
+	return &Special{Rune: '{', Min: min, Max: max}, nil
+}
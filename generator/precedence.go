@@ -0,0 +1,128 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/salikh/peg/generator/gogen"
+)
+
+// The rules defined by a precedence table %prec Operand are parsed with
+// precedence climbing in the same way as in parser2, see
+// parser2/precedence.go. The generated handler passes the table to the
+// helper method precedence of the template.
+
+// Precedence is a precedence table of operators.
+type Precedence struct {
+	// Levels are the levels of operators from the lowest to the highest
+	// precedence.
+	Levels []*PrecLevel
+	// skip is the name of the skip rule applied between the operators
+	// and operands, set by insertSkip.
+	skip string
+}
+
+// PrecLevel is one level of a precedence table.
+type PrecLevel struct {
+	// Kind is one of "left", "right", "prefix" or "postfix".
+	Kind string
+	Ops  []*PrecOp
+}
+
+// PrecOp is an operator of a precedence table.
+type PrecOp struct {
+	// Label is the label of the operator nodes, or empty if the nodes
+	// are labeled by the rule name.
+	Label   string
+	Literal string
+}
+
+func (p *Precedence) String() string {
+	r := []string{"(Precedence"}
+	for _, level := range p.Levels {
+		r = append(r, ` (Level :Kind(`, strconv.Quote(level.Kind), `)`)
+		for _, op := range level.Ops {
+			r = append(r, ` (Op :Literal(`, strconv.Quote(op.Literal), `)`)
+			if op.Label != "" {
+				r = append(r, ` :Label(`, strconv.Quote(op.Label), `)`)
+			}
+			r = append(r, ")")
+		}
+		r = append(r, ")")
+	}
+	r = append(r, ")")
+	return strings.Join(r, "")
+}
+
+// check returns an error if the operators are empty or ambiguous.
+func (p *Precedence) check() error {
+	prefix := make(map[string]bool)
+	other := make(map[string]bool)
+	for _, level := range p.Levels {
+		for _, op := range level.Ops {
+			if op.Literal == "" {
+				return fmt.Errorf("empty operator in precedence table")
+			}
+			seen := other
+			if level.Kind == "prefix" {
+				seen = prefix
+			}
+			if seen[op.Literal] {
+				return fmt.Errorf("operator %q is defined more than once", op.Literal)
+			}
+			seen[op.Literal] = true
+		}
+	}
+	return nil
+}
+
+// makePrecedenceHandler makes the handler of the precedence table term
+// together with the handlers of the operand and skip rules and the table
+// variable.
+func makePrecedenceHandler(term *Term, handlerName string) []ast.Decl {
+	operand := handlerName + "_operand"
+	r := makeRuleHandler(term.Ident, operand)
+	skip := ""
+	if term.Prec.skip != "" {
+		skip = handlerName + "_skip"
+		r = append(r, makeRuleHandler(term.Prec.skip, skip)...)
+	}
+	var prefix, postfix, infix []gogen.PrecOp
+	for i, level := range term.Prec.Levels {
+		for _, op := range level.Ops {
+			o := gogen.PrecOp{Literal: op.Literal, Label: op.Label, Level: i + 1, Right: level.Kind == "right"}
+			switch level.Kind {
+			case "prefix":
+				prefix = append(prefix, o)
+			case "postfix":
+				postfix = append(postfix, o)
+			default:
+				infix = append(infix, o)
+			}
+		}
+	}
+	// The longest operator is matched first.
+	for _, ops := range [][]gogen.PrecOp{prefix, postfix, infix} {
+		sort.SliceStable(ops, func(i, j int) bool { return len(ops[i].Literal) > len(ops[j].Literal) })
+	}
+	table := handlerName + "_table"
+	return append(r, gogen.PrecedenceTable(table, prefix, postfix, infix),
+		gogen.PrecedenceHandler(handlerName, table, operand, skip))
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import "unicode"
+
+// The directive %skip inserts the skip rule between the terms of the
+// syntactic rules. The rules are rewritten in the same way as in
+// parser2, see parser2/skip.go.
+
+// isLexical reports whether the rule is exempt from the %skip directive.
+func (g *Grammar) isLexical(rule *Rule) bool {
+	return rule.Token || rule.Ident == g.Skip || isUpperName(rule.Ident)
+}
+
+// isUpperName reports whether name has letters and all of them are
+// uppercase.
+func isUpperName(name string) bool {
+	letters := false
+	for _, c := range name {
+		if unicode.IsLower(c) {
+			return false
+		}
+		letters = letters || unicode.IsLetter(c)
+	}
+	return letters
+}
+
+// insertSkip returns a copy of rhs with the references to the skip rule
+// inserted between the terms of sequences and between the iterations of
+// repetitions.
+func insertSkip(rhs *RHS, skip string) *RHS {
+	r := &RHS{}
+	for _, terms := range rhs.Terms {
+		var c []*Term
+		for i, term := range terms {
+			if i > 0 {
+				c = append(c, &Term{Ident: skip})
+			}
+			c = append(c, skipTerm(term, skip))
+		}
+		r.Terms = append(r.Terms, c)
+	}
+	return r
+}
+
+func skipTerm(term *Term, skip string) *Term {
+	t := *term
+	switch {
+	case t.Prec != nil:
+		prec := *t.Prec
+		prec.skip = skip
+		t.Prec = &prec
+	case t.Parens != nil:
+		t.Parens = insertSkip(t.Parens, skip)
+	case t.NegPred != nil:
+		t.NegPred = skipTerm(t.NegPred, skip)
+	case t.Pred != nil:
+		t.Pred = skipTerm(t.Pred, skip)
+	case t.Capture != nil:
+		t.Capture = insertSkip(t.Capture, skip)
+	case t.Special != nil:
+		return skipRepeat(&t, skip)
+	}
+	return &t
+}
+
+// skipRepeat rewrites the repetitions X* as (X (_ X)*)?, X+ as (X (_ X)*),
+// and X{n,m} as (X (_ X){n-1,m-1}), made optional if n is 0.
+func skipRepeat(t *Term, skip string) *Term {
+	special := t.Special
+	term := skipTerm(special.Term, skip)
+	min, max := special.Min, special.Max
+	switch special.Rune {
+	case '?':
+		min, max = 0, 1
+	case '*':
+		min, max = 0, -1
+	case '+':
+		min, max = 1, -1
+	}
+	if max == 0 || max == 1 {
+		// There are no iterations to separate.
+		t.Special = &Special{Term: term, Rune: special.Rune, Min: special.Min, Max: special.Max}
+		return t
+	}
+	rest := &Special{
+		Term: &Term{Parens: &RHS{Terms: [][]*Term{{{Ident: skip}, term}}}},
+		Rune: '*',
+		Min:  0,
+		Max:  -1,
+	}
+	if max > 0 {
+		rest.Rune, rest.Max = '{', max-1
+		if min > 0 {
+			rest.Min = min - 1
+		}
+	} else if min > 1 {
+		rest.Rune, rest.Min = '{', min-1
+	}
+	seq := &RHS{Terms: [][]*Term{{term, {Special: rest}}}}
+	if min == 0 {
+		t.Special = &Special{Term: &Term{Parens: seq}, Rune: '?'}
+		return t
+	}
+	t.Special = nil
+	t.Parens = seq
+	return t
+}
@@ -30,6 +30,7 @@ package template
 
 import (
 	"fmt"
+	"sort"
 	"unicode"
 	"unicode/utf8"
 
@@ -48,6 +49,38 @@ type Result struct {
 	// Final AST.
 	Tree *parser.Node
 	NodeStack
+	// cut is set when a cut is passed in the current alternative of the
+	// innermost choice, and predicates is the number of predicates being
+	// evaluated.
+	cut        bool
+	predicates int
+	// points is the stack of the positions where the parser can resume
+	// after a failure, with -1 for the choices committed by a cut, and
+	// choice is the number of points up to the point of the innermost
+	// choice, or 0 if the innermost scope of the cuts is a rule or
+	// a predicate.
+	points []int
+	choice int
+	// committed is the position before which the memo entries have been
+	// discarded.
+	committed int
+	// Errors is the list of syntax errors recovered by the labeled failures
+	// term^label, in the order of input positions.
+	Errors []*SyntaxError
+}
+
+// SyntaxError is a syntax error recovered by a labeled failure term^label.
+type SyntaxError struct {
+	// Label is the label of the failed term.
+	Label string
+	// Pos is the byte position where the failed term started.
+	Pos int
+	// Err is the error returned by the failed term.
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d: %s: %s", e.Pos, e.Label, e.Err)
 }
 
 func (s *NodeStack) Push(n *Node) {
@@ -69,9 +102,23 @@ func (r *Result) TopNode() *Node {
 	return r.NodeStack[last]
 }
 
+// markers maps the names of the rules with tree shape markers to the
+// markers. It is replaced by the actual markers from a grammar by the parser
+// generator.
+var markers = map[string]string{"Space": "drop"}
+
 func (r *Result) Attach(n *Node) {
-	if n.Text == "" && n.Start == 0 && len(n.Children) == 0 && len(n.Annotations) == 0 &&
-		len(r.NodeStack) > 0 {
+	marker := markers[n.Label]
+	if marker == "drop" && len(r.NodeStack) > 0 {
+		r.keepErrors((*parser.Node)(n))
+		return
+	}
+	if marker == "inline" && len(r.NodeStack) > 0 {
+		r.inline(n)
+		return
+	}
+	if marker != "keep" && n.Text == "" && n.Start == 0 && len(n.Children) == 0 && len(n.Annotations) == 0 &&
+		len(n.TreeAnnotations) == 0 && len(r.NodeStack) > 0 {
 		// Heuristic: do not attach the nodes without any useful annotations,
 		// text or children. Note, that captured text may be empty, but n.Start is
 		// non-zero in that case.
@@ -94,6 +141,77 @@ func (r *Result) Attach(n *Node) {
 	//log.Infof("node = %s, attached = %s", r.NodeStack[last], n)
 }
 
+// state is the part of the top node that is modified by the handlers and
+// needs to be restored when a handler fails.
+type state struct {
+	children        []*parser.Node
+	annotations     map[string]string
+	treeAnnotations map[string]*parser.Node
+	cut             bool
+}
+
+func (r *Result) saveState() state {
+	n := r.TopNode()
+	return state{n.Children, n.Annotations, n.TreeAnnotations, r.cut}
+}
+
+func (r *Result) restoreState(s state) {
+	n := r.TopNode()
+	n.Children = s.children
+	n.Annotations = s.annotations
+	n.TreeAnnotations = s.treeAnnotations
+	r.cut = s.cut
+}
+
+// annotate sets a string annotation of the top node. The annotation maps
+// are copied on write, so that saved states are not affected.
+func (r *Result) annotate(key, value string) {
+	n := r.TopNode()
+	m := make(map[string]string, len(n.Annotations)+1)
+	for k, v := range n.Annotations {
+		m[k] = v
+	}
+	m[key] = value
+	n.Annotations = m
+}
+
+// annotateTree moves the last child of the top node, if it was attached
+// after the state s was saved, into the tree annotation key.
+func (r *Result) annotateTree(s state, key string) {
+	n := r.TopNode()
+	last := len(n.Children) - 1
+	if last < len(s.children) {
+		return
+	}
+	r.setTreeAnnotation(key, n.Children[last])
+	n.Children = n.Children[:last]
+}
+
+// setTreeAnnotation sets a tree annotation of the top node, copying the
+// annotation map on write.
+func (r *Result) setTreeAnnotation(key string, value *parser.Node) {
+	n := r.TopNode()
+	m := make(map[string]*parser.Node, len(n.TreeAnnotations)+1)
+	for k, v := range n.TreeAnnotations {
+		m[k] = v
+	}
+	m[key] = value
+	n.TreeAnnotations = m
+}
+
+// inline attaches the children of n to the top node, and merges the
+// annotations of n into the annotations of the top node.
+func (r *Result) inline(n *Node) {
+	top := r.TopNode()
+	top.Children = append(top.Children, n.Children...)
+	for k, v := range n.Annotations {
+		r.annotate(k, v)
+	}
+	for k, v := range n.TreeAnnotations {
+		r.setTreeAnnotation(k, v)
+	}
+}
+
 //------------------------------------------------------------------------------
 // The following handlers are not copied to the generated parsers verbatim.
 // Instead, they are used as templates, with the variables replaced with the
@@ -119,6 +237,27 @@ func LiteralHandler(r *Result, pos int) (int, error) {
 	return len(literal), nil
 }
 
+// LiteralFoldHandler is a template code for case-insensitive literal
+// handlers in the generated parser.
+func LiteralFoldHandler(r *Result, pos int) (int, error) {
+	// LiteralFoldHandler
+	n := 0
+	for _, lc := range literal {
+		c, w := utf8.DecodeRuneInString(r.Source[pos+n:])
+		if w == 0 {
+			return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+		}
+		for f := c; f != lc; {
+			f = unicode.SimpleFold(f)
+			if f == c {
+				return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:pos+n+w])
+			}
+		}
+		n += w
+	}
+	return n, nil
+}
+
 // charClassMap defines the char class definition for the CharClassHandler. It is replaced
 // by the actual definition from a grammar by the parser generator.
 var charClassMap = map[rune]bool{' ': true, '\n': true, '\t': true, '\r': true}
@@ -159,9 +298,15 @@ func CharClassAlnumHandler(r *Result, pos int) (int, error) {
 func StarHandler(r *Result, pos int) (int, error) {
 	// StarHandler
 	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
 	for w, err := CharClassHandler(r, pos); err == nil && w > 0; w, err = CharClassHandler(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
 
@@ -218,7 +363,11 @@ const predicateNegative = false
 
 func PredicateHandler(r *Result, pos int) (int, error) {
 	// PredicateHandler
+	r.predicates++
+	scope := r.enter(pos, false)
 	_, err := GroupHandler(r, pos)
+	r.leave(scope)
+	r.predicates--
 	if predicateNegative == (err != nil) {
 		return 0, nil
 	}
@@ -248,10 +397,14 @@ func CaptureEndHandler(r *Result, pos int) (int, error) {
 
 func ChoiceHandler(r *Result, pos int) (int, error) {
 	// ChoiceHandler
+	scope := r.enter(pos, true)
+	save := r.saveState()
 	w, err := LiteralHandler(r, pos)
-	if err != nil {
+	if err != nil && !r.cut {
+		r.restoreState(save)
 		w, err = StarHandler(r, pos)
 	}
+	r.leave(scope)
 	return w, err
 }
 
@@ -273,21 +426,346 @@ func PlusHandler(r *Result, pos int) (int, error) {
 	if err != nil {
 		return ww, err
 	}
+	save := r.saveState()
+	k := r.push(pos + ww)
 	for w, err := CharClassHandler(r, pos+ww); err == nil && w > 0; w, err = CharClassHandler(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
 
 func QuestionHandler(r *Result, pos int) (int, error) {
 	// QuestionHandler
+	save := r.saveState()
+	k := r.push(pos)
 	w, err := CharClassHandler(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
+		r.restoreState(save)
 		return 0, nil
 	}
 	return w, nil
 }
 
+// annotationKey is the label of a labeled rule reference or a labeled capture.
+const annotationKey = "key"
+
+// LabeledHandler is a template code for labeled rule references key:Rule.
+func LabeledHandler(r *Result, pos int) (int, error) {
+	// LabeledHandler
+	save := r.saveState()
+	w, err := RuleHandler(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.annotateTree(save, annotationKey)
+	return w, nil
+}
+
+// LabeledCaptureHandler is a template code for labeled captures <key: ...>.
+func LabeledCaptureHandler(r *Result, pos int) (int, error) {
+	// LabeledCaptureHandler
+	w, err := GroupHandler(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.annotate(annotationKey, r.Source[pos:pos+w])
+	return w, nil
+}
+
+// BackRefHandler is a template code for back-references $key, which match
+// the text of the labeled capture <key: ...>.
+func BackRefHandler(r *Result, pos int) (int, error) {
+	// BackRefHandler
+	text, ok := r.TopNode().Annotations[annotationKey]
+	if !ok {
+		return 0, fmt.Errorf("back-reference $%s before capture", annotationKey)
+	}
+	if len(r.Source)-pos < len(text) || r.Source[pos:pos+len(text)] != text {
+		return 0, fmt.Errorf("expecting $%s = %q, got %q", annotationKey, text, r.Source[pos:])
+	}
+	return len(text), nil
+}
+
+// CutHandler is a template code for the cut operator ~.
+func CutHandler(r *Result, pos int) (int, error) {
+	// CutHandler
+	r.commit(pos)
+	return 0, nil
+}
+
+// commit is called when the parser passes a cut at pos. The cut commits
+// the innermost choice, so the parser can only backtrack to the points of
+// the enclosing choices and repetitions, and the memo entries before the
+// first of them are discarded, unless the cut is inside of a predicate.
+func (r *Result) commit(pos int) {
+	r.cut = true
+	if r.choice > 0 {
+		r.points[r.choice-1] = -1
+	}
+	if r.predicates > 0 {
+		return
+	}
+	bound := pos
+	for _, p := range r.points {
+		if p >= 0 {
+			bound = p
+			break
+		}
+	}
+	for ; r.committed < bound; r.committed++ {
+		delete(r.Memo, r.committed)
+	}
+}
+
+// cutScope is the state of the cuts saved when a rule, a predicate or
+// a choice starts, and restored when it finishes.
+type cutScope struct {
+	cut    bool
+	choice int
+	points int
+}
+
+// enter starts a new scope of the cuts at pos. If choice is set, the scope
+// is a choice, and pos is a point where the parser resumes with the next
+// alternative until a cut commits the choice.
+func (r *Result) enter(pos int, choice bool) cutScope {
+	s := cutScope{r.cut, r.choice, len(r.points)}
+	r.cut = false
+	r.choice = 0
+	if choice {
+		r.points = append(r.points, pos)
+		r.choice = len(r.points)
+	}
+	return s
+}
+
+// leave restores the state of the cuts saved by enter.
+func (r *Result) leave(s cutScope) {
+	r.cut, r.choice, r.points = s.cut, s.choice, r.points[:s.points]
+}
+
+// push adds pos to the points where the parser resumes after a failure and
+// returns its index. The caller removes the point when it finishes.
+func (r *Result) push(pos int) int {
+	r.points = append(r.points, pos)
+	return len(r.points) - 1
+}
+
+// RecoverHandler is a template code for the labeled failures term^label.
+func RecoverHandler(r *Result, pos int) (int, error) {
+	// RecoverHandler
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := LiteralHandler(r, pos)
+	r.points = r.points[:k]
+	if err == nil {
+		return w, nil
+	}
+	r.restoreState(save)
+	return r.recover(pos, w, err, StarHandler, 3)
+}
+
+// recover is called when a labeled failure term fails at pos with w, err.
+// It applies the recovery rule h with the handler index hi at pos, and if
+// the rule matches, attaches an Error node with the skipped input to the top
+// node. The Error node holds the syntax error in Err and the label in the
+// "label" annotation. If the recovery rule fails, the original failure is
+// returned.
+func (r *Result) recover(pos, w int, err error, h handler, hi int) (int, error) {
+	n := &Node{Label: "Error", Pos: pos, Annotations: map[string]string{"label": labels[hi]}}
+	r.NodeStack.Push(n)
+	rw, rerr := apply(r, pos, h, hi)
+	r.NodeStack.Pop()
+	if rerr != nil {
+		return w, err
+	}
+	n.Len = rw
+	n.Text = r.Source[pos : pos+rw]
+	n.Err = &SyntaxError{Label: labels[hi], Pos: pos, Err: err}
+	top := r.TopNode()
+	top.Children = append(top.Children, (*parser.Node)(n))
+	return rw, nil
+}
+
+// keepErrors attaches the Error nodes from the subtree of the dropped node n
+// to the top node, so that the recovered syntax errors are not lost.
+func (r *Result) keepErrors(n *parser.Node) {
+	for _, c := range n.Children {
+		if _, ok := c.Err.(*SyntaxError); ok && c.Label == "Error" {
+			top := r.TopNode()
+			top.Children = append(top.Children, c)
+		} else {
+			r.keepErrors(c)
+		}
+	}
+}
+
+// collectErrors returns the syntax errors held by the Error nodes of the
+// tree n in the order of input positions.
+func collectErrors(n *parser.Node) []*SyntaxError {
+	var errs []*SyntaxError
+	var walk func(n *parser.Node)
+	walk = func(n *parser.Node) {
+		if serr, ok := n.Err.(*SyntaxError); ok && n.Label == "Error" {
+			errs = append(errs, serr)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+		for _, c := range n.TreeAnnotations {
+			walk(c)
+		}
+	}
+	walk(n)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pos < errs[j].Pos })
+	return errs
+}
+
+// precOp is an operator of a precedence table.
+type precOp struct {
+	literal string
+	label   string
+	// level is the precedence of the operator, starting from 1.
+	level int
+	right bool
+}
+
+// precTable is a precedence table of operators. The operators of each kind
+// are sorted by the literal length, so that the longest operator is matched
+// first.
+type precTable struct {
+	prefix, postfix, infix []precOp
+}
+
+// precExpr is the precedence table used by PrecedenceHandler. It is replaced
+// by the actual table from a grammar by the parser generator.
+var precExpr = precTable{
+	prefix: []precOp{{"-", "Neg", 3, false}},
+	infix:  []precOp{{"+", "Add", 1, false}, {"*", "Mul", 2, false}},
+}
+
+// PrecedenceHandler is a template code for the rules defined by
+// a precedence table %prec Operand. The skip handler is nil if the grammar
+// has no %skip directive or the rule is lexical.
+func PrecedenceHandler(r *Result, pos int) (int, error) {
+	// PrecedenceHandler
+	return r.precedence(&precExpr, LiteralHandler, nil, pos)
+}
+
+// precedence parses the operators of the table t with precedence climbing
+// and attaches the operator nodes to the top node. Every operator node is
+// labeled by the operator name, or by the rule name, and has the operator
+// text and the operands as children.
+func (r *Result) precedence(t *precTable, operand, skip handler, pos int) (int, error) {
+	k := r.push(pos)
+	nodes, w, err := r.parsePrec(t, operand, skip, pos, 0)
+	r.points = r.points[:k]
+	if err != nil {
+		return w, err
+	}
+	top := r.TopNode()
+	top.Children = append(top.Children[:len(top.Children):len(top.Children)], nodes...)
+	return w, nil
+}
+
+// parsePrec parses an expression at pos with the operators of precedence
+// min and higher, and returns the nodes of the expression.
+func (r *Result) parsePrec(t *precTable, operand, skip handler, pos, min int) ([]*parser.Node, int, error) {
+	var left []*parser.Node
+	w := -1
+	if op, ok := r.matchOp(t.prefix, pos, 0); ok {
+		s := r.skipOp(skip, pos+len(op.literal))
+		nodes, ww, err := r.parsePrec(t, operand, skip, pos+len(op.literal)+s, op.level)
+		if err == nil {
+			w = len(op.literal) + s + ww
+			left = []*parser.Node{r.opNode(op, pos, w, nodes)}
+		}
+	}
+	if w < 0 {
+		var err error
+		left, w, err = r.collectNodes(operand, pos)
+		if err != nil {
+			return nil, w, err
+		}
+	}
+	for {
+		s := r.skipOp(skip, pos+w)
+		at := pos + w + s
+		if op, ok := r.matchOp(t.postfix, at, min); ok {
+			w += s + len(op.literal)
+			left = []*parser.Node{r.opNode(op, pos, w, left)}
+			continue
+		}
+		op, ok := r.matchOp(t.infix, at, min)
+		if !ok {
+			break
+		}
+		next := op.level + 1
+		if op.right {
+			next = op.level
+		}
+		s2 := r.skipOp(skip, at+len(op.literal))
+		right, ww, err := r.parsePrec(t, operand, skip, at+len(op.literal)+s2, next)
+		if err != nil {
+			// The operator is not a part of the expression.
+			break
+		}
+		w += s + len(op.literal) + s2 + ww
+		left = []*parser.Node{r.opNode(op, pos, w, append(left[:len(left):len(left)], right...))}
+	}
+	return left, w, nil
+}
+
+// collectNodes applies the handler and returns the nodes it attached to the
+// top node, removing them from the top node.
+func (r *Result) collectNodes(h handler, pos int) ([]*parser.Node, int, error) {
+	top := r.TopNode()
+	n := len(top.Children)
+	w, err := h(r, pos)
+	nodes := append([]*parser.Node(nil), top.Children[n:]...)
+	top.Children = top.Children[:n]
+	return nodes, w, err
+}
+
+// skipOp applies the skip handler, if any, and returns the length of the
+// skipped input.
+func (r *Result) skipOp(skip handler, pos int) int {
+	if skip == nil {
+		return 0
+	}
+	_, w, err := r.collectNodes(skip, pos)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// matchOp returns the first operator of ops with precedence min or higher
+// that matches the input at pos.
+func (r *Result) matchOp(ops []precOp, pos, min int) (precOp, bool) {
+	for _, op := range ops {
+		if op.level >= min && len(r.Source)-pos >= len(op.literal) && r.Source[pos:pos+len(op.literal)] == op.literal {
+			return op, true
+		}
+	}
+	return precOp{}, false
+}
+
+// opNode makes the node of the operator applied to the operands, spanning
+// the input from pos of length w.
+func (r *Result) opNode(op precOp, pos, w int, operands []*parser.Node) *parser.Node {
+	label := op.label
+	if label == "" {
+		label = r.TopNode().Label
+	}
+	return &parser.Node{Label: label, Text: op.literal, Pos: pos, Len: w, Children: operands}
+}
+
 type handler func(r *Result, pos int) (int, error)
 
 func apply(r *Result, pos int, h handler, hi int) (int, error) {
@@ -312,7 +790,9 @@ func apply(r *Result, pos int, h handler, hi int) (int, error) {
 	}
 	n = &Node{Label: labels[hi]}
 	r.NodeStack.Push(n)
+	scope := r.enter(pos, false)
 	w, err := h(r, pos)
+	r.leave(scope)
 	if err != nil {
 		//log.Infof("%d> fail w%d", r.Level, w+w1)
 		n := r.NodeStack.Pop()
@@ -349,5 +829,6 @@ func Parse(source string) (*Result, error) {
 	if w != len(source) {
 		return r, fmt.Errorf("some characters remain unconsumed: %q", source[w:])
 	}
+	r.Errors = collectErrors(r.Tree)
 	return r, nil
 }
@@ -16,6 +16,7 @@ package template
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -77,6 +78,19 @@ func TestLiteralHandler(t *testing.T) {
 	testOneHandler(t, LiteralHandler, "LiteralHandler", literalTests)
 }
 
+func TestLiteralFoldHandler(t *testing.T) {
+	var tests = []test{
+		{"abc", 0, 3, ""},
+		{"ABC", 0, 3, ""},
+		{"aBcd", 0, 3, ""},
+		{"xAbC", 1, 3, ""},
+		{"", 0, 0, "expecting.*got"},
+		{"ab", 0, 0, "expecting.*got"},
+		{"abd", 0, 0, "expecting.*got"},
+	}
+	testOneHandler(t, LiteralFoldHandler, "LiteralFoldHandler", tests)
+}
+
 func TestCharClassHandler(t *testing.T) {
 	var tests = []test{
 		{"", 0, 0, "expecting.*got EOF"},
@@ -292,6 +306,144 @@ func TestCaptureHandlers(t *testing.T) {
 	}
 }
 
+func TestLabeledCaptureHandler(t *testing.T) {
+	r := &Result{
+		Source: " abc ",
+		Memo:   make(map[int]map[int]*Node),
+	}
+	node := &Node{Label: "top"}
+	r.NodeStack.Push(node)
+	w, err := LabeledCaptureHandler(r, 0)
+	if err != nil {
+		t.Fatalf("LabeledCaptureHandler(%q,0) returns error %s, want success", r.Source, err)
+	}
+	if w != 5 {
+		t.Errorf("LabeledCaptureHandler(%q,0) returns w=%d, want 5", r.Source, w)
+	}
+	if got := node.Annotations[annotationKey]; got != " abc " {
+		t.Errorf("LabeledCaptureHandler(%q,0) annotates %q, want %q", r.Source, got, " abc ")
+	}
+}
+
+func TestBackRefHandler(t *testing.T) {
+	tests := []struct {
+		input string
+		w     int
+		err   bool
+	}{
+		{"abc", 3, false},
+		{"abcd", 3, false},
+		{"ab", 0, true},
+		{"abd", 0, true},
+	}
+	for _, tt := range tests {
+		r := &Result{
+			Source: tt.input,
+			Memo:   make(map[int]map[int]*Node),
+		}
+		r.NodeStack.Push(&Node{Label: "top", Annotations: map[string]string{annotationKey: "abc"}})
+		w, err := BackRefHandler(r, 0)
+		if (err != nil) != tt.err {
+			t.Errorf("BackRefHandler(%q,0) returns error %v, want error %v", tt.input, err, tt.err)
+		}
+		if err == nil && w != tt.w {
+			t.Errorf("BackRefHandler(%q,0) returns w=%d, want %d", tt.input, w, tt.w)
+		}
+	}
+	r := &Result{
+		Source: "abc",
+		Memo:   make(map[int]map[int]*Node),
+	}
+	r.NodeStack.Push(&Node{Label: "top"})
+	if _, err := BackRefHandler(r, 0); err == nil {
+		t.Errorf("BackRefHandler(%q,0) without a capture returns success, want error", r.Source)
+	}
+}
+
+func TestCutHandler(t *testing.T) {
+	r := &Result{
+		Source: "abc",
+		Memo:   make(map[int]map[int]*Node),
+	}
+	for pos := 0; pos <= 3; pos++ {
+		r.Memo[pos] = make(map[int]*Node)
+	}
+	r.predicates = 1
+	CutHandler(r, 1)
+	if len(r.Memo) != 4 {
+		t.Errorf("CutHandler(r, 1) inside of a predicate keeps %d memo entries, want 4", len(r.Memo))
+	}
+	r.predicates = 0
+	r.cut = false
+	// The cut commits the choice at 1, but the parser can still resume
+	// the repetition at 2.
+	scope := r.enter(1, true)
+	k := r.push(2)
+	w, err := CutHandler(r, 3)
+	if w != 0 || err != nil {
+		t.Errorf("CutHandler(r, 3) returns (%d, %v), want (0, nil)", w, err)
+	}
+	if len(r.Memo) != 2 || r.Memo[2] == nil || r.Memo[3] == nil {
+		t.Errorf("CutHandler(r, 3) keeps memo entries %v, want positions 2 and 3", r.Memo)
+	}
+	if !r.cut || r.points[0] != -1 {
+		t.Errorf("CutHandler(r, 3) leaves cut %v and points %v, want true and [-1 2]", r.cut, r.points)
+	}
+	r.points = r.points[:k]
+	r.leave(scope)
+	if r.cut || len(r.points) != 0 {
+		t.Errorf("leave after CutHandler leaves cut %v and points %v, want false and []", r.cut, r.points)
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	r := &Result{
+		Source: "  x",
+		Memo:   make(map[int]map[int]*Node),
+	}
+	node := &Node{Label: "top"}
+	r.NodeStack.Push(node)
+	w, err := RecoverHandler(r, 0)
+	if err != nil {
+		t.Fatalf("RecoverHandler(%q,0) returns error %s, want success", r.Source, err)
+	}
+	if w != 2 {
+		t.Errorf("RecoverHandler(%q,0) returns w=%d, want 2", r.Source, w)
+	}
+	if len(node.Children) != 1 || node.Children[0].Label != "Error" || node.Children[0].Text != "  " {
+		t.Fatalf("RecoverHandler(%q,0) attaches %v, want an Error node with the skipped spaces", r.Source, node.Children)
+	}
+	errs := collectErrors(node)
+	if len(errs) != 1 || errs[0].Label != "Space" || errs[0].Pos != 0 {
+		t.Errorf("RecoverHandler(%q,0) records errors %v, want one error labeled Space at 0", r.Source, errs)
+	}
+}
+
+func TestPrecedenceHandler(t *testing.T) {
+	r := &Result{
+		Source: "abc+abc*-abc+",
+		Memo:   make(map[int]map[int]*Node),
+	}
+	node := &Node{Label: "top"}
+	r.NodeStack.Push(node)
+	w, err := PrecedenceHandler(r, 0)
+	if err != nil {
+		t.Fatalf("PrecedenceHandler(%q,0) returns error %s, want success", r.Source, err)
+	}
+	if w != 12 {
+		t.Errorf("PrecedenceHandler(%q,0) returns w=%d, want 12", r.Source, w)
+	}
+	// LiteralHandler does not attach nodes, so every operator node only has
+	// the nested operator node as a child.
+	var labels []string
+	for n := node; len(n.Children) == 1; n = n.Children[0] {
+		labels = append(labels, n.Children[0].Label+n.Children[0].Text)
+	}
+	if got, want := strings.Join(labels, " "), "Add+ Mul* Neg-"; got != want {
+		t.Errorf("PrecedenceHandler(%q,0) attaches %q, want %q", r.Source, got, want)
+	}
+}
+
 func TestParse(t *testing.T) {
 	testHandler = GroupHandler
 	tests := []struct {
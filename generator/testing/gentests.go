@@ -33,7 +33,7 @@ import (
 	log "github.com/golang/glog"
 	"github.com/salikh/peg/compat/runfiles"
 	"github.com/salikh/peg/generator"
-	peg "github.com/salikh/peg/parser"
+	"github.com/salikh/peg/parser2"
 	"github.com/salikh/peg/tests"
 )
 
@@ -42,12 +42,12 @@ var (
 )
 
 // extractFirstIdent is a quick and hacky solution to extract a human-readable name
-// from a grammar.
+// from a grammar. The lines of directives such as %skip are skipped.
 func extractFirstIdent(s string) string {
-	parts := strings.SplitN(s, " ", 20)
-	for _, part := range parts {
-		if part != "" {
-			return part
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "%") {
+			return fields[0]
 		}
 	}
 	return "grammar"
@@ -81,6 +81,99 @@ func (v *genFinder) Visit(node ast.Node) ast.Visitor {
 	return v
 }
 
+// treeSuites lists the tree test suites run with the generated parsers.
+// The parsers of the suites with byteMode are generated in the byte mode.
+var treeSuites = []struct {
+	name     string
+	tests    []tests.TreeTest
+	byteMode bool
+}{
+	{"Labels", tests.Labels, false},
+	{"Markers", tests.Markers, false},
+	{"Cut", tests.Cut, false},
+	{"Repeat", tests.Repeat, false},
+	{"Recover", tests.Recover, false},
+	{"BackRef", tests.BackRef, false},
+	{"Skip", tests.Skip, false},
+	{"Precedence", tests.Precedence, false},
+	{"ByteMode", tests.ByteMode, true},
+}
+
+// testTemplate is a parsed template of the test source.
+type testTemplate struct {
+	fset *token.FileSet
+	file *ast.File
+	// testNum is the value of the testNum constant, which is overwritten
+	// in place for each test.
+	testNum *ast.BasicLit
+}
+
+func parseTemplate(name string) *testTemplate {
+	fset := token.NewFileSet()
+	filename := runfiles.Path("github.com/salikh/peg/generator/testing/" + name)
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		log.Exitf("Could not parse %s: %s", filename, err)
+	}
+	// Find the testNum constant.
+	v := &genFinder{name: "testNum", Token: token.CONST}
+	ast.Walk(v, file)
+	if v.GenDecl == nil {
+		log.Exitf("Could not find testNum in %s", filename)
+	}
+	testNum := v.GenDecl.Specs[0].(*ast.ValueSpec).Values[0].(*ast.BasicLit)
+	return &testTemplate{fset: fset, file: file, testNum: testNum}
+}
+
+// generate writes the generated parser of the grammar and the test source
+// to a new directory named by the first identifier of the grammar with
+// the prefix. It returns false if the parser generator does not support
+// the grammar.
+func generate(dirs map[string]int, prefix, grammar string, byteMode bool, tmpl *testTemplate, i int) bool {
+	name := prefix + extractFirstIdent(grammar)
+	g, err := generator.New(grammar)
+	if err != nil {
+		log.Infof("Failed to parse PEG [%s]: %s", grammar, err)
+		return false
+	}
+	g.ByteMode = byteMode
+	goSource, err := g.Generate("gen")
+	if err != nil {
+		log.Infof("Failed to generate go source for [%s]: %s", grammar, err)
+		return false
+	}
+	count := dirs[name] + 1
+	dirs[name] = count
+	if count > 1 {
+		name = name + strconv.FormatInt(int64(count), 10)
+	}
+	log.Infof("%d: %s", i, name)
+	dir := filepath.Join(*outputDir, name)
+	err = os.Mkdir(dir, 0775)
+	if err != nil {
+		log.Exitf("Failed to mkdir %s: %s", dir, err)
+	}
+	filename := filepath.Join(dir, "gen.go")
+	err = ioutil.WriteFile(filename, []byte(goSource), 0664)
+	if err != nil {
+		log.Exitf("Failed to write %s: %s", filename, err)
+	}
+	// Overwrite the testNum value in place.
+	tmpl.testNum.Value = strconv.FormatInt(int64(i), 10)
+	config := printer.Config{Mode: printer.UseSpaces, Tabwidth: 2}
+	var buf bytes.Buffer
+	err = config.Fprint(&buf, tmpl.fset, tmpl.file)
+	if err != nil {
+		log.Exitf("Failed to print the test source for %s: %s", dir, err)
+	}
+	filename = filepath.Join(dir, "gen_test.go")
+	err = ioutil.WriteFile(filename, buf.Bytes(), 0664)
+	if err != nil {
+		log.Exitf("Failed to write %s: %s", filename, err)
+	}
+	return true
+}
+
 func main() {
 	flag.Parse()
 	log.Info("Generating parser tests...")
@@ -92,63 +185,31 @@ func main() {
 	if err != nil {
 		log.Exitf("Error trying to mkdir %s/: %s", *outputDir, err)
 	}
-	// Parse a template of the test.
-	fset := token.NewFileSet()
-	gentestTemplate := runfiles.Path("github.com/salikh/peg/generator/testing/test_template.golang")
-	testTree, err := parser.ParseFile(fset, gentestTemplate, nil, parser.ParseComments)
-	if err != nil {
-		log.Exitf("Could not parse %s: %s", gentestTemplate, err)
-	}
-	// Find the testNum constant.
-	v := &genFinder{name: "testNum", Token: token.CONST}
-	ast.Walk(v, testTree)
-	if v.GenDecl == nil {
-		log.Exitf("Could not find testNum in %s", gentestTemplate)
-	}
-	testNumValue := v.GenDecl.Specs[0].(*ast.ValueSpec).Values[0].(*ast.BasicLit)
 	// A counter of the directories with the same name.
 	dirs := make(map[string]int)
-	config := printer.Config{Mode: printer.UseSpaces, Tabwidth: 2}
+	tmpl := parseTemplate("test_template.golang")
 	for i, test := range tests.Positive {
-		_, err := peg.New(test.Grammar)
+		_, err := parser2.New(test.Grammar, nil)
 		if err != nil {
 			log.Exitf("Failed to parse the grammar [%s]: %s", test.Grammar, err)
 		}
-		name := extractFirstIdent(test.Grammar)
-		g, err := generator.New(test.Grammar)
-		if err != nil {
-			log.Infof("Failed to parse PEG [%s]: %s", test.Grammar, err)
-			continue
-		}
-		goSource, err := g.Generate("gen")
-		if err != nil {
-			log.Infof("Failed to generate go source for [%s]: %s", test.Grammar, err)
-			continue
-		}
-		count := dirs[name] + 1
-		dirs[name] = count
-		if count > 1 {
-			name = name + strconv.FormatInt(int64(count), 10)
-		}
-		log.Infof("%d: %s", i, name)
-		dir := filepath.Join(*outputDir, name)
-		err = os.Mkdir(dir, 0775)
-		if err != nil {
-			log.Exitf("Failed to mkdir %s: %s", dir, err)
-		}
-		filename := filepath.Join(dir, "gen.go")
-		err = ioutil.WriteFile(filename, []byte(goSource), 0664)
-		if err != nil {
-			log.Exitf("Failed to write %s: %s", filename, err)
-		}
-		// Overwrite the testNum value in place.
-		testNumValue.Value = strconv.FormatInt(int64(i), 10)
-		var buf bytes.Buffer
-		err = config.Fprint(&buf, fset, testTree)
-		filename = filepath.Join(dir, "gen_test.go")
-		err = ioutil.WriteFile(filename, buf.Bytes(), 0664)
-		if err != nil {
-			log.Exitf("Failed to write %s: %s", filename, err)
+		generate(dirs, "", test.Grammar, false, tmpl, i)
+	}
+	tmpl = parseTemplate("tree_test_template.golang")
+	// Find the test variable, tests.Labels[testNum], to rewrite the
+	// name of the suite.
+	v := &genFinder{name: "test", Token: token.VAR}
+	ast.Walk(v, tmpl.file)
+	if v.GenDecl == nil {
+		log.Exitf("Could not find test in tree_test_template.golang")
+	}
+	suite := v.GenDecl.Specs[0].(*ast.ValueSpec).Values[0].(*ast.IndexExpr).X.(*ast.SelectorExpr).Sel
+	for _, s := range treeSuites {
+		suite.Name = s.name
+		for i, test := range s.tests {
+			if !generate(dirs, s.name+"_", test.Grammar, s.byteMode, tmpl, i) {
+				log.Exitf("The parser generator does not support the %s test [%s]", s.name, test.Grammar)
+			}
 		}
 	}
 }
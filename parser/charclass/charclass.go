@@ -38,6 +38,18 @@ type CharClass struct {
 	// is translated into unicode package name conventions (e.g. "[:alpha:]" -> "IsLetter").
 	// "[:alnum:]" is a special special case and is represented by the value "[:alnum:]" without translation.
 	Special string
+	// IgnoreCase indicates that the char class matches the runes that are
+	// equivalent under Unicode simple case folding to the runes of the class.
+	IgnoreCase bool
+	// Expr is the source of a composite char class that has nested or
+	// special classes inside, e.g. "[:alpha:]_0-9" or "[a-z]--[aeiou]",
+	// without the leading '^'. Composite char classes are compiled into
+	// RangeTable.
+	Expr string
+	// Property is the name of the Unicode general category, script or
+	// property of a char class \p{Name}, e.g. "Lu" or "Greek". RangeTable
+	// is then the table of the unicode package with the same name.
+	Property string
 }
 
 var specialClasses = map[string]string{
@@ -54,13 +66,19 @@ var specialClasses = map[string]string{
 	"[:any:]":   "[:any:]",
 }
 
-// Parse parses a charclass string.
-// TODO(salikh): Support mixed special classes (both specials and regular
-// chars).
+// Parse parses a charclass string. The special classes can be mixed with
+// the regular chars, and the nested classes can be combined with the set
+// operations, see parseComposite.
 func Parse(arg string) (*CharClass, error) {
 	if len(arg) == 0 {
 		return nil, errors.New("empty char class")
 	}
+	if cc, ok := parseProperty(arg); ok {
+		return cc, nil
+	}
+	if _, ok := specialClasses[strings.TrimPrefix(arg, "^")]; !ok && isComposite(arg) {
+		return parseComposite(arg)
+	}
 	if arg[0] == '^' {
 		if len(arg) == 1 {
 			return &CharClass{
@@ -96,8 +114,10 @@ func Parse(arg string) (*CharClass, error) {
 			Special: special,
 		}, nil
 	}
-	var last rune = 0
-	var start rune = 0
+	// last is the previous rune and start is the start of a range, or -1 if
+	// there is none, since \x00 is a valid rune of a char class.
+	var last rune = -1
+	var start rune = -1
 	ret := &CharClass{}
 	for pos := 0; pos < len(arg); {
 		r, w := utf8.DecodeRuneInString(arg[pos:])
@@ -109,7 +129,7 @@ func Parse(arg string) (*CharClass, error) {
 			if pos != 0 && pos+w != len(arg) {
 				// Mark the start of the range.
 				start = last
-				last = 0
+				last = -1
 				pos += w
 				continue
 			}
@@ -117,7 +137,7 @@ func Parse(arg string) (*CharClass, error) {
 		if r == '\\' {
 			if pos+1 < len(arg) {
 				switch arg[pos+1] {
-				case '^', '-', '[', ']':
+				case '^', '-', '[', ']', '&':
 					// Special charclass-specific non-standard escapes.
 					r = rune(arg[pos+1])
 					w = 2
@@ -132,7 +152,7 @@ func Parse(arg string) (*CharClass, error) {
 				// Fallthrough and use r as in normal case.
 			}
 		}
-		if start != 0 {
+		if start >= 0 {
 			// Close the range.
 			if r <= start {
 				return nil, fmt.Errorf("invalid interval in %c-%c in %q", start, r, arg)
@@ -148,11 +168,11 @@ func Parse(arg string) (*CharClass, error) {
 				return nil, fmt.Errorf("%q: invalid char range across 16-bit and 32-bit boundary: %d to %d", arg, start, r)
 			}
 			pos += w
-			start = 0
-			last = 0
+			start = -1
+			last = -1
 			continue
 		}
-		if last != 0 {
+		if last >= 0 {
 			if ret.Map == nil {
 				ret.Map = make(map[rune]bool)
 			}
@@ -161,7 +181,7 @@ func Parse(arg string) (*CharClass, error) {
 		last = r
 		pos += w
 	}
-	if last != 0 {
+	if last >= 0 {
 		if ret.Map == nil {
 			ret.Map = make(map[rune]bool)
 		}
@@ -195,6 +215,12 @@ func (cc *CharClass) String() string {
 	if cc.Negated {
 		ret = append(ret, "^")
 	}
+	if cc.Expr != "" {
+		return strings.Join(append(ret, cc.Expr), "")
+	}
+	if cc.Property != "" {
+		return strings.Join(append(ret, `\p{`, cc.Property, "}"), "")
+	}
 	if cc.Special != "" {
 		for k, v := range specialClasses {
 			if cc.Special == v {
@@ -236,3 +262,48 @@ func (cc *CharClass) String() string {
 	}
 	return strings.Join(ret, "")
 }
+
+// Matches reports whether the rune c belongs to the char class.
+func (cc *CharClass) Matches(c rune) bool {
+	match := cc.contains(c)
+	if cc.IgnoreCase {
+		for f := unicode.SimpleFold(c); !match && f != c; f = unicode.SimpleFold(f) {
+			match = cc.contains(f)
+		}
+	}
+	return match != cc.Negated
+}
+
+// contains reports whether the rune c belongs to the char class
+// ignoring the Negated and IgnoreCase flags.
+func (cc *CharClass) contains(c rune) bool {
+	switch cc.Special {
+	case "":
+	case "[:any:]":
+		return true
+	case "[:alnum:]":
+		return unicode.IsLetter(c) || unicode.IsDigit(c)
+	case "IsLetter":
+		return unicode.IsLetter(c)
+	case "IsNumber":
+		return unicode.IsNumber(c)
+	case "IsSpace":
+		return unicode.IsSpace(c)
+	case "IsLower":
+		return unicode.IsLower(c)
+	case "IsUpper":
+		return unicode.IsUpper(c)
+	case "IsPunct":
+		return unicode.IsPunct(c)
+	case "IsPrint":
+		return unicode.IsPrint(c)
+	case "IsGraphic":
+		return unicode.IsGraphic(c)
+	case "IsControl":
+		return unicode.IsControl(c)
+	}
+	if cc.Map[c] {
+		return true
+	}
+	return cc.RangeTable != nil && unicode.Is(cc.RangeTable, c)
+}
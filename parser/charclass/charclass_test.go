@@ -76,6 +76,12 @@ func TestParseCanonical(t *testing.T) {
 			},
 			}}},
 		{`\x0-\x0d`, nil},
+		{`\x00-\x1f`, &CharClass{RangeTable: &unicode.RangeTable{
+			R16: []unicode.Range16{
+				unicode.Range16{0x00, 0x1f, 1},
+			},
+		}}},
+		{`\x00\x7f`, &CharClass{Map: map[rune]bool{0x00: true, 0x7f: true}}},
 		{"А-Я", &CharClass{RangeTable: &unicode.RangeTable{
 			R16: []unicode.Range16{
 				unicode.Range16{0x410, 0x42f, 1},
@@ -220,3 +226,37 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		cc    *CharClass
+		match string
+		other string
+	}{
+		{&CharClass{Map: map[rune]bool{'a': true}}, "a", "AbK"},
+		{&CharClass{Map: map[rune]bool{'a': true}, IgnoreCase: true}, "aA", "bB"},
+		{&CharClass{Map: map[rune]bool{'k': true}, IgnoreCase: true}, "kK\u212a", "j"},
+		{&CharClass{Map: map[rune]bool{'a': true}, IgnoreCase: true, Negated: true}, "bB", "aA"},
+		{&CharClass{
+			RangeTable: &unicode.RangeTable{R16: []unicode.Range16{{'a', 'z', 1}}},
+			IgnoreCase: true}, "azAZ\u017f", "09_"},
+		{&CharClass{
+			RangeTable: &unicode.RangeTable{R16: []unicode.Range16{{0x430, 0x44f, 1}}},
+			IgnoreCase: true}, "яЯ", "z"},
+		{&CharClass{Special: "IsLower"}, "a", "A"},
+		{&CharClass{Special: "IsLower", IgnoreCase: true}, "aA", "1"},
+		{&CharClass{Special: "[:any:]"}, "a1 ", ""},
+	}
+	for _, tt := range tests {
+		for _, c := range tt.match {
+			if !tt.cc.Matches(c) {
+				t.Errorf("%+v.Matches(%q) returns false, want true", tt.cc, c)
+			}
+		}
+		for _, c := range tt.other {
+			if tt.cc.Matches(c) {
+				t.Errorf("%+v.Matches(%q) returns true, want false", tt.cc, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charclass
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// The property classes \p{Name} match the runes of a Unicode general
+// category (\p{Lu}), script (\p{Greek}) or property (\p{White_Space}),
+// and \P{Name} matches the other runes. The identifier properties of
+// Unicode TR31 (ID_Start, ID_Continue, XID_Start, XID_Continue) are not
+// provided by the unicode package and are derived from the other tables.
+
+// unicodeTable returns the table of a general category, script or property
+// from the unicode package.
+func unicodeTable(name string) (*unicode.RangeTable, bool) {
+	for _, tables := range []map[string]*unicode.RangeTable{
+		unicode.Categories, unicode.Scripts, unicode.Properties} {
+		if t, ok := tables[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func tablesSet(tables ...*unicode.RangeTable) Set {
+	var r Set
+	for _, t := range tables {
+		r = r.Union(tableSet(t))
+	}
+	return r
+}
+
+func patternSet() Set {
+	return tablesSet(unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+func idStart() Set {
+	return tablesSet(unicode.L, unicode.Nl, unicode.Other_ID_Start).Subtract(patternSet())
+}
+
+func idContinue() Set {
+	return idStart().Union(tablesSet(unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc,
+		unicode.Other_ID_Continue)).Subtract(patternSet())
+}
+
+// xidExcluded lists the runes that are removed from ID_Continue to make
+// XID_Continue closed under NFKC normalization, see Unicode TR31.
+var xidExcluded = Set{
+	{0x037a, 0x037a}, {0x309b, 0x309c}, {0xfc5e, 0xfc63}, {0xfdfa, 0xfdfb},
+	{0xfe70, 0xfe70}, {0xfe72, 0xfe72}, {0xfe74, 0xfe74}, {0xfe76, 0xfe76},
+	{0xfe78, 0xfe78}, {0xfe7a, 0xfe7a}, {0xfe7c, 0xfe7c}, {0xfe7e, 0xfe7e},
+}
+
+// xidStartExcluded lists the runes that are additionally removed from
+// ID_Start to make XID_Start.
+var xidStartExcluded = Set{{0x0e33, 0x0e33}, {0x0eb3, 0x0eb3}, {0xff9e, 0xff9f}}
+
+var derivedProperties = map[string]func() Set{
+	"ID_Start":     idStart,
+	"ID_Continue":  idContinue,
+	"XID_Start":    func() Set { return idStart().Subtract(xidExcluded.Union(xidStartExcluded)) },
+	"XID_Continue": func() Set { return idContinue().Subtract(xidExcluded) },
+}
+
+// propertySet returns the set of runes with the named property.
+func propertySet(name string) (Set, error) {
+	if t, ok := unicodeTable(name); ok {
+		return tableSet(t), nil
+	}
+	if derived, ok := derivedProperties[name]; ok {
+		return derived(), nil
+	}
+	return nil, fmt.Errorf("unknown Unicode category, script or property: %q", name)
+}
+
+// parsePropertyName parses the property class \p{Name} or \P{Name} at the
+// beginning of s, and returns the name, whether the class is negated, and
+// the length of the class. It returns zero length if s does not start with
+// a property class.
+func parsePropertyName(s string) (name string, negated bool, n int) {
+	if !strings.HasPrefix(s, `\p{`) && !strings.HasPrefix(s, `\P{`) {
+		return "", false, 0
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return "", false, 0
+	}
+	return s[3:end], s[1] == 'P', end + 1
+}
+
+// parseProperty parses a char class that consists of a single property
+// class from the unicode package, e.g. \p{Greek} or ^\P{Lu}.
+func parseProperty(arg string) (*CharClass, bool) {
+	negated := strings.HasPrefix(arg, "^")
+	if negated {
+		arg = arg[1:]
+	}
+	name, neg, n := parsePropertyName(arg)
+	if n == 0 || n != len(arg) {
+		return nil, false
+	}
+	t, ok := unicodeTable(name)
+	if !ok {
+		return nil, false
+	}
+	return &CharClass{Property: name, RangeTable: t, Negated: negated != neg}, true
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charclass
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestParseProperty(t *testing.T) {
+	tests := []struct {
+		input     string
+		canonical string
+		property  string
+		match     string
+		other     string
+	}{
+		{`\p{Lu}`, `\p{Lu}`, "Lu", "AZÉΣ", "az1_"},
+		{`\p{Greek}`, `\p{Greek}`, "Greek", "αΩ", "aя"},
+		{`\P{L}`, `^\p{L}`, "L", "1_ ", "aя"},
+		{`^\P{L}`, `\p{L}`, "L", "aя", "1_ "},
+		{`\p{White_Space}`, `\p{White_Space}`, "White_Space", " \t ", "a"},
+		{`\p{XID_Start}`, `\p{XID_Start}`, "", "aяℵ", "1_ͺ゛"},
+		{`\p{XID_Continue}`, `\p{XID_Continue}`, "", "a1_́ำ", " -ͺ"},
+		{`\p{ID_Start}`, `\p{ID_Start}`, "", "aͺำ", "1_"},
+		{`\p{L}_\p{Nd}`, `\p{L}_\p{Nd}`, "", "a_1٣", "- "},
+		{`\p{L}--[\p{Latin}]`, `\p{L}--[\p{Latin}]`, "", "яα", "az"},
+		{`\P{L}a`, `\P{L}a`, "", "a1", "b"},
+	}
+	for _, tt := range tests {
+		cc, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %s, want success", tt.input, err)
+			continue
+		}
+		if cc.Property != tt.property {
+			t.Errorf("Parse(%q) returned property %q, want %q", tt.input, cc.Property, tt.property)
+		}
+		for _, c := range tt.match {
+			if !cc.Matches(c) {
+				t.Errorf("Parse(%q).Matches(%q) = false, want true", tt.input, c)
+			}
+		}
+		for _, c := range tt.other {
+			if cc.Matches(c) {
+				t.Errorf("Parse(%q).Matches(%q) = true, want false", tt.input, c)
+			}
+		}
+		if back := cc.String(); back != tt.canonical {
+			t.Errorf("Parse(%q).String() returned %q, want %q", tt.input, back, tt.canonical)
+		}
+	}
+}
+
+func TestParsePropertyError(t *testing.T) {
+	for _, input := range []string{`\p{Foo}`, `a\p{Foo}`, `\p{L`, `\q{L}`} {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) returned %v, want error", input, got)
+		}
+	}
+}
+
+// TestIDProperties checks that the derived identifier properties are
+// nested as required by Unicode TR31.
+func TestIDProperties(t *testing.T) {
+	sets := map[string]*unicode.RangeTable{}
+	for name, derived := range derivedProperties {
+		sets[name] = derived().RangeTable()
+	}
+	for _, sub := range [][2]string{
+		{"ID_Start", "ID_Continue"},
+		{"XID_Start", "XID_Continue"},
+		{"XID_Start", "ID_Start"},
+		{"XID_Continue", "ID_Continue"},
+	} {
+		for c := rune(0); c <= unicode.MaxRune; c++ {
+			if unicode.Is(sets[sub[0]], c) && !unicode.Is(sets[sub[1]], c) {
+				t.Errorf("%U is in %s, but not in %s", c, sub[0], sub[1])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,432 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charclass
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The composite char classes mix special classes, nested classes and
+// ordinary characters, and combine them with the set operations:
+//
+//	[[:alpha:]_0-9]       union of the members
+//	[[a-z]&&[^aeiou]]     intersection
+//	[[:alpha:]--[aeiou]]  subtraction
+//	[\p{L}\p{Nd}_]         property classes, see property.go
+//	[\p{Lu}&&\p{Greek}]    operands without brackets
+//
+// The operators -- and && take the union of the members up to the next
+// operator as the right operand, and are applied from left to right, so
+// [a-z--aeiou] is the same as [[a-z]--[aeiou]]. An operator without the
+// right operand is an error, except for the range ending with '-' in
+// [+--]. The ranges ending with '-' followed by more members, such as
+// [!--/], are ambiguous and must be written with an escape as [!-\-/].
+// A composite class is compiled into a single sorted range table.
+
+// Span is an inclusive range of runes.
+type Span struct {
+	Lo, Hi rune
+}
+
+// Set is a sorted list of disjoint and non-adjacent spans.
+type Set []Span
+
+// Normalize sorts and merges the spans.
+func Normalize(spans []Span) Set {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Lo < spans[j].Lo })
+	var r Set
+	for _, s := range spans {
+		if last := len(r) - 1; last >= 0 && s.Lo <= r[last].Hi+1 {
+			if s.Hi > r[last].Hi {
+				r[last].Hi = s.Hi
+			}
+			continue
+		}
+		r = append(r, s)
+	}
+	return r
+}
+
+// Union returns the runes that are in a or in b.
+func (a Set) Union(b Set) Set {
+	return Normalize(append(append([]Span{}, a...), b...))
+}
+
+// Complement returns the runes up to unicode.MaxRune that are not in a.
+func (a Set) Complement() Set {
+	var r Set
+	next := rune(0)
+	for _, s := range a {
+		if s.Lo > next {
+			r = append(r, Span{next, s.Lo - 1})
+		}
+		next = s.Hi + 1
+	}
+	if next <= unicode.MaxRune {
+		r = append(r, Span{next, unicode.MaxRune})
+	}
+	return r
+}
+
+// Intersect returns the runes that are both in a and in b.
+func (a Set) Intersect(b Set) Set {
+	var r Set
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		lo, hi := a[i].Lo, a[i].Hi
+		if b[j].Lo > lo {
+			lo = b[j].Lo
+		}
+		if b[j].Hi < hi {
+			hi = b[j].Hi
+		}
+		if lo <= hi {
+			r = append(r, Span{lo, hi})
+		}
+		if a[i].Hi < b[j].Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return r
+}
+
+// Subtract returns the runes that are in a but not in b.
+func (a Set) Subtract(b Set) Set {
+	return a.Intersect(b.Complement())
+}
+
+// tableSet converts a range table into a set.
+func tableSet(t *unicode.RangeTable) Set {
+	var spans []Span
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			spans = append(spans, Span{lo, hi})
+			return
+		}
+		for c := lo; c <= hi; c += stride {
+			spans = append(spans, Span{c, c})
+		}
+	}
+	for _, r := range t.R16 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range t.R32 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return Normalize(spans)
+}
+
+// Contains reports whether the rune c is in the set.
+func (a Set) Contains(c rune) bool {
+	i := sort.Search(len(a), func(i int) bool { return a[i].Hi >= c })
+	return i < len(a) && a[i].Lo <= c
+}
+
+// fold returns the runes that are equivalent to the runes of the set
+// under Unicode simple case folding. Every rune with a nontrivial case
+// folding orbit has a case mapping or shares the orbit with one that has,
+// so only the runes of unicode.CaseRanges need to be folded.
+func (a Set) fold() Set {
+	spans := append([]Span{}, a...)
+	for _, cr := range unicode.CaseRanges {
+		for c := rune(cr.Lo); c <= rune(cr.Hi); c++ {
+			in := a.Contains(c)
+			for f := unicode.SimpleFold(c); !in && f != c; f = unicode.SimpleFold(f) {
+				in = a.Contains(f)
+			}
+			if !in {
+				continue
+			}
+			spans = append(spans, Span{c, c})
+			for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+				spans = append(spans, Span{f, f})
+			}
+		}
+	}
+	return Normalize(spans)
+}
+
+// Set returns the set of runes matched by the char class.
+func (cc *CharClass) Set() Set {
+	var r Set
+	if cc.Special != "" {
+		r = specialSet(cc.Special)
+	} else {
+		spans := make([]Span, 0, len(cc.Map))
+		for c := range cc.Map {
+			spans = append(spans, Span{c, c})
+		}
+		r = Normalize(spans)
+		if cc.RangeTable != nil {
+			r = r.Union(tableSet(cc.RangeTable))
+		}
+	}
+	if cc.IgnoreCase {
+		r = r.fold()
+	}
+	if cc.Negated {
+		r = r.Complement()
+	}
+	return r
+}
+
+// RangeTable converts the set into a range table with stride 1.
+func (a Set) RangeTable() *unicode.RangeTable {
+	t := &unicode.RangeTable{}
+	for _, s := range a {
+		if s.Lo < 1<<16 {
+			hi := s.Hi
+			if hi >= 1<<16 {
+				hi = 1<<16 - 1
+			}
+			t.R16 = append(t.R16, unicode.Range16{Lo: uint16(s.Lo), Hi: uint16(hi), Stride: 1})
+			if hi == s.Hi {
+				continue
+			}
+			s.Lo = 1 << 16
+		}
+		t.R32 = append(t.R32, unicode.Range32{Lo: uint32(s.Lo), Hi: uint32(s.Hi), Stride: 1})
+	}
+	for _, r := range t.R16 {
+		if r.Hi > unicode.MaxLatin1 {
+			break
+		}
+		t.LatinOffset++
+	}
+	return t
+}
+
+// specialTables lists the range tables that make up the special classes,
+// matching the definitions of the corresponding functions of the unicode
+// package.
+var specialTables = map[string][]*unicode.RangeTable{
+	"IsLetter":  {unicode.Letter},
+	"IsNumber":  {unicode.Number},
+	"IsSpace":   {unicode.White_Space},
+	"IsLower":   {unicode.Lower},
+	"IsUpper":   {unicode.Upper},
+	"IsPunct":   {unicode.Punct},
+	"IsPrint":   {unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, {R16: []unicode.Range16{{' ', ' ', 1}}}},
+	"IsGraphic": {unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Zs},
+	"IsControl": {{R16: []unicode.Range16{{0, 0x1f, 1}, {0x7f, 0x9f, 1}}}},
+	"[:alnum:]": {unicode.Letter, unicode.Digit},
+	"[:any:]":   {{R16: []unicode.Range16{{0, 0xffff, 1}}, R32: []unicode.Range32{{0x10000, unicode.MaxRune, 1}}}},
+}
+
+func specialSet(special string) Set {
+	var r Set
+	for _, t := range specialTables[special] {
+		r = r.Union(tableSet(t))
+	}
+	return r
+}
+
+// isComposite reports whether the char class arg has nested, special or
+// property classes or set operators inside, i.e. contains an unescaped '[',
+// \p{...}, -- or && after the first member.
+func isComposite(arg string) bool {
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			if _, _, n := parsePropertyName(arg[i:]); n > 0 {
+				return true
+			}
+			i++
+		case '[':
+			return true
+		case '-', '&':
+			if i > 0 && operator(arg[i:]) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setParser parses the composite char classes.
+type setParser struct {
+	src string
+	pos int
+}
+
+// parseComposite parses a composite char class and compiles it into
+// a range table.
+func parseComposite(arg string) (*CharClass, error) {
+	p := &setParser{src: arg}
+	ret := &CharClass{Expr: arg}
+	if strings.HasPrefix(arg, "^") {
+		ret.Negated = true
+		ret.Expr = arg[1:]
+		p.pos = 1
+	}
+	s, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at pos %d in %q", p.src[p.pos], p.pos, arg)
+	}
+	ret.RangeTable = s.RangeTable()
+	return ret, nil
+}
+
+// operator returns the set operator at the start of s, if any.
+func operator(s string) string {
+	for _, op := range []string{"--", "&&"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// operator returns the set operator at the current position, if any.
+func (p *setParser) operator() string {
+	return operator(p.src[p.pos:])
+}
+
+func (p *setParser) parseExpr() (Set, error) {
+	s, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.operator(); op != ""; op = p.operator() {
+		p.pos += len(op)
+		if p.pos == len(p.src) || p.src[p.pos] == ']' {
+			return nil, fmt.Errorf("missing the right operand of %s at pos %d in %q", op, p.pos, p.src)
+		}
+		t, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		if op == "--" {
+			s = s.Subtract(t)
+		} else {
+			s = s.Intersect(t)
+		}
+	}
+	return s, nil
+}
+
+func (p *setParser) parseUnion() (Set, error) {
+	var s Set
+	empty := true
+	for p.pos < len(p.src) && p.src[p.pos] != ']' && (empty || p.operator() == "") {
+		t, err := p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+		s = s.Union(t)
+		empty = false
+	}
+	if empty {
+		return nil, fmt.Errorf("empty char class at pos %d in %q", p.pos, p.src)
+	}
+	return s, nil
+}
+
+func (p *setParser) parseItem() (Set, error) {
+	if end := strings.Index(p.src[p.pos:], ":]"); strings.HasPrefix(p.src[p.pos:], "[:") && end > 0 {
+		name := p.src[p.pos : p.pos+end+2]
+		special, ok := specialClasses[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown char class: %q", name)
+		}
+		p.pos += len(name)
+		return specialSet(special), nil
+	}
+	if name, negated, n := parsePropertyName(p.src[p.pos:]); n > 0 {
+		s, err := propertySet(name)
+		if err != nil {
+			return nil, err
+		}
+		p.pos += n
+		if negated {
+			s = s.Complement()
+		}
+		return s, nil
+	}
+	if p.src[p.pos] == '[' {
+		p.pos++
+		negated := strings.HasPrefix(p.src[p.pos:], "^")
+		if negated {
+			p.pos++
+		}
+		s, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos == len(p.src) {
+			return nil, fmt.Errorf("missing ']' in %q", p.src)
+		}
+		p.pos++
+		if negated {
+			s = s.Complement()
+		}
+		return s, nil
+	}
+	lo, err := p.parseRune()
+	if err != nil {
+		return nil, err
+	}
+	if p.operator() == "--" && p.pos+2 < len(p.src) && p.src[p.pos+2] != ']' {
+		if lo <= '-' {
+			return nil, fmt.Errorf("ambiguous %c-- at pos %d in %q, escape the end of the range as \\-", lo, p.pos, p.src)
+		}
+		// The subtraction is parsed by parseExpr.
+		return Set{{lo, lo}}, nil
+	}
+	if p.pos+1 < len(p.src) && p.src[p.pos] == '-' && p.src[p.pos+1] != ']' {
+		p.pos++
+		hi, err := p.parseRune()
+		if err != nil {
+			return nil, err
+		}
+		if hi <= lo {
+			return nil, fmt.Errorf("invalid interval in %c-%c in %q", lo, hi, p.src)
+		}
+		return Set{{lo, hi}}, nil
+	}
+	return Set{{lo, lo}}, nil
+}
+
+func (p *setParser) parseRune() (rune, error) {
+	r, w := utf8.DecodeRuneInString(p.src[p.pos:])
+	if r == utf8.RuneError {
+		return 0, fmt.Errorf("error parsing utf8 rune at pos %d: %q", p.pos, p.src)
+	}
+	if r == '\\' && p.pos+1 < len(p.src) {
+		switch p.src[p.pos+1] {
+		case '^', '-', '[', ']', '&':
+			r = rune(p.src[p.pos+1])
+			w = 2
+		default:
+			val, _, tail, err := strconv.UnquoteChar(p.src[p.pos:], 0)
+			if err != nil {
+				return 0, fmt.Errorf("error parsing escape at pos %d in %q: %s", p.pos, p.src, err)
+			}
+			r = val
+			w = len(p.src) - p.pos - len(tail)
+		}
+	}
+	p.pos += w
+	return r, nil
+}
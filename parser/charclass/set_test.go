@@ -0,0 +1,171 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package charclass
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestSetOperations(t *testing.T) {
+	a := Set{{'a', 'f'}, {'x', 'z'}}
+	b := Set{{'d', 'y'}}
+	if got, want := a.Union(b), (Set{{'a', 'z'}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("%v.Union(%v) = %v, want %v", a, b, got, want)
+	}
+	if got, want := a.Intersect(b), (Set{{'d', 'f'}, {'x', 'y'}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("%v.Intersect(%v) = %v, want %v", a, b, got, want)
+	}
+	if got, want := a.Subtract(b), (Set{{'a', 'c'}, {'z', 'z'}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("%v.Subtract(%v) = %v, want %v", a, b, got, want)
+	}
+	want := Set{{0, 'a' - 1}, {'g', 'w'}, {'z' + 1, unicode.MaxRune}}
+	if got := a.Complement(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%v.Complement() = %v, want %v", a, got, want)
+	}
+	if got := Normalize([]Span{{'c', 'd'}, {'a', 'b'}, {'b', 'c'}}); !reflect.DeepEqual(got, Set{{'a', 'd'}}) {
+		t.Errorf("Normalize() = %v, want [{a d}]", got)
+	}
+}
+
+func TestRangeTable(t *testing.T) {
+	s := Set{{'a', 'z'}, {0x400, 0x12000}}
+	want := &unicode.RangeTable{
+		R16:         []unicode.Range16{{'a', 'z', 1}, {0x400, 0xffff, 1}},
+		R32:         []unicode.Range32{{0x10000, 0x12000, 1}},
+		LatinOffset: 1,
+	}
+	if got := s.RangeTable(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%v.RangeTable() = %#v, want %#v", s, got, want)
+	}
+}
+
+// TestSpecialSet checks that the sets of the special classes match the
+// same runes as the special classes themselves.
+func TestSpecialSet(t *testing.T) {
+	for name, special := range specialClasses {
+		table := specialSet(special).RangeTable()
+		cc := &CharClass{Special: special}
+		for c := rune(0); c <= unicode.MaxRune; c++ {
+			if unicode.Is(table, c) != cc.Matches(c) {
+				t.Errorf("specialSet(%q) disagrees with Matches on %U", name, c)
+				break
+			}
+		}
+	}
+}
+
+func TestParseComposite(t *testing.T) {
+	tests := []struct {
+		input string
+		match string
+		other string
+	}{
+		{"[:alpha:]_0-9", "aZя_09", "-+ "},
+		{"_[:digit:]", "_09٣", "a"},
+		{"[a-z]--[aeiou]", "bcxz", "aeiouA"},
+		{"[a-z]&&[^m-z]", "abl", "mzA"},
+		{"[a-z]&&[a-p]--[aeiou]", "bcp", "aeqz"},
+		{"[:alpha:]--[a-z]", "AZя", "az0"},
+		{"^[:digit:][:space:]", "a_-", "09 \t"},
+		{"[[a-c][x-z]]--[bx]", "acyz", "bxd"},
+		{`[\[\]]-`, "[]-", "a"},
+		{"[:alpha:]\U00010400-\U0001044f", "a\U00010400", "0"},
+		{`\p{Lu}&&\p{Greek}`, "ΔΩ", "Aα&"},
+		{`\p{L}--\p{Lu}`, "aя", "AЯ-"},
+		{"a-z--aeiou", "bcxz", "aeiou-"},
+		{"[:alpha:]&&a-f", "af", "gA&"},
+		{`a\&&&\&-z`, "&a", "bz"},
+		{"+--", "+,-", "a"},
+	}
+	for _, tt := range tests {
+		cc, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %s, want success", tt.input, err)
+			continue
+		}
+		for _, c := range tt.match {
+			if !cc.Matches(c) {
+				t.Errorf("Parse(%q).Matches(%q) = false, want true", tt.input, c)
+			}
+		}
+		for _, c := range tt.other {
+			if cc.Matches(c) {
+				t.Errorf("Parse(%q).Matches(%q) = true, want false", tt.input, c)
+			}
+		}
+		if back := cc.String(); back != tt.input {
+			t.Errorf("Parse(%q).String() returned %q, want %q", tt.input, back, tt.input)
+		}
+	}
+}
+
+func TestParseCompositeError(t *testing.T) {
+	tests := []string{
+		"[:alpha:",
+		"[a-z",
+		"[:foo:]a",
+		"[]a",
+		"[a-z]--[",
+		"a--[]",
+		"[z-a]b",
+		"[a-z]]",
+		`\p{L}--`,
+		"a&&",
+		"a-z--",
+		"!--/",
+	}
+	for _, input := range tests {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) returned %v, want error", input, got)
+		}
+	}
+}
+
+// TestCharClassSet checks that the sets of the char classes match the same
+// runes as the char classes themselves.
+func TestCharClassSet(t *testing.T) {
+	tests := []struct {
+		input      string
+		ignoreCase bool
+	}{
+		{"a-z_", false},
+		{"^a-z\n", false},
+		{"[:alpha:]", false},
+		{"^[:space:]", false},
+		{`\p{Greek}`, false},
+		{`^\P{Lu}`, false},
+		{"[:alpha:]--[a-z]", false},
+		{"a-zß", true},
+		{"^KΣ", true},
+		{`\p{Lu}`, true},
+	}
+	for _, tt := range tests {
+		cc, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %s", tt.input, err)
+			continue
+		}
+		cc.IgnoreCase = tt.ignoreCase
+		s := cc.Set()
+		for c := rune(0); c <= unicode.MaxRune; c++ {
+			if s.Contains(c) != cc.Matches(c) {
+				t.Errorf("Parse(%q).Set() disagrees with Matches on %U", tt.input, c)
+				break
+			}
+		}
+	}
+}
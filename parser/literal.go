@@ -0,0 +1,140 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The grammar literals come in three forms, shared by parser, parser2 and
+// the parser generator:
+//
+//   "a\t\"\'\u00e9\x41"  the escapes of Go string literals and \'
+//   'a\t'                no escapes, matches a backslash and t
+//   `a\t"'`              no escapes, may contain both quotes
+
+// LiteralError is an error in a grammar literal, such as a malformed
+// escape sequence.
+type LiteralError struct {
+	// Literal is the source text of the literal, including the quotes.
+	Literal string
+	// Offset is the byte offset of the error in Literal.
+	Offset  int
+	Message string
+}
+
+func (e *LiteralError) Error() string {
+	return fmt.Sprintf("%s in literal %s", e.Message, e.Literal)
+}
+
+// Locate returns the error prefixed with the row and column of the error
+// in the grammar source, where the literal starts at byte pos.
+// The rows are 1-based and the columns are 0-based.
+func (e *LiteralError) Locate(source string, pos int) error {
+	row, col := 1, 0
+	for i := 0; i < pos+e.Offset && i < len(source); i++ {
+		if source[i] == '\n' {
+			row++
+			col = 0
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("%d:%d: %s", row, col, e)
+}
+
+// ScanLiteral returns the length of the grammar literal at the beginning
+// of s, including the quotes, or -1 if the literal is not terminated.
+func ScanLiteral(s string) int {
+	if s == "" {
+		return -1
+	}
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == q:
+			return i + 1
+		case s[i] == '\\' && q == '"':
+			i++
+		case s[i] == '\n' && q == '"':
+			return -1
+		}
+	}
+	return -1
+}
+
+// UnquoteLiteral returns the value of the grammar literal lit including
+// the quotes. The errors are returned as *LiteralError.
+func UnquoteLiteral(lit string) (string, error) {
+	if len(lit) < 2 || lit[len(lit)-1] != lit[0] {
+		return "", &LiteralError{Literal: lit, Message: "unterminated quote"}
+	}
+	s := lit[1 : len(lit)-1]
+	if lit[0] != '"' {
+		return s, nil
+	}
+	var buf []byte
+	for i := 0; i < len(s); {
+		if s[i] == '\n' {
+			return "", &LiteralError{Literal: lit, Offset: i + 1, Message: "newline"}
+		}
+		if s[i] != '\\' {
+			buf = append(buf, s[i])
+			i++
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			buf = append(buf, '\'')
+			i += 2
+			continue
+		}
+		c, multibyte, tail, err := strconv.UnquoteChar(s[i:], '"')
+		if err != nil {
+			return "", &LiteralError{Literal: lit, Offset: i + 1,
+				Message: "invalid escape sequence " + escapeText(s[i:])}
+		}
+		if c < utf8.RuneSelf || !multibyte {
+			buf = append(buf, byte(c))
+		} else {
+			buf = append(buf, string(c)...)
+		}
+		i = len(s) - len(tail)
+	}
+	return string(buf), nil
+}
+
+// escapeText returns the escape sequence at the beginning of s for
+// the error messages.
+func escapeText(s string) string {
+	n := 2
+	if len(s) > 1 {
+		switch s[1] {
+		case 'x':
+			n = 4
+		case 'u':
+			n = 6
+		case 'U':
+			n = 10
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n = 4
+		}
+	}
+	if n > len(s) {
+		n = len(s)
+	}
+	return s[:n]
+}
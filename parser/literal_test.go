@@ -0,0 +1,82 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnquoteLiteral(t *testing.T) {
+	tests := []struct {
+		lit  string
+		want string
+	}{
+		{`"abc"`, "abc"},
+		{`"a\tb\n"`, "a\tb\n"},
+		{`"\"\'\\"`, `"'\`},
+		{`"é\U0001F600"`, "é😀"},
+		{`"\x41\101\xff"`, "AA\xff"},
+		{`'a\tb'`, `a\tb`},
+		{`'"'`, `"`},
+		{"`a\\n\"'`", `a\n"'`},
+		{"`a\nb`", "a\nb"},
+	}
+	for _, tt := range tests {
+		got, err := UnquoteLiteral(tt.lit)
+		if err != nil {
+			t.Errorf("UnquoteLiteral(%s) returns error %s, want success", tt.lit, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("UnquoteLiteral(%s) = %q, want %q", tt.lit, got, tt.want)
+		}
+		if n := ScanLiteral(tt.lit + " x"); n != len(tt.lit) {
+			t.Errorf("ScanLiteral(%s) = %d, want %d", tt.lit, n, len(tt.lit))
+		}
+	}
+}
+
+func TestUnquoteLiteralError(t *testing.T) {
+	tests := []struct {
+		lit    string
+		offset int
+		want   string
+	}{
+		{`"a\qb"`, 2, `invalid escape sequence \q in literal "a\qb"`},
+		{`"\x4"`, 1, `invalid escape sequence \x4 in literal "\x4"`},
+		{`"ab\u00g0"`, 3, `invalid escape sequence \u00g0 in literal "ab\u00g0"`},
+		{"\"a\nb\"", 2, "newline in literal \"a\nb\""},
+	}
+	for _, tt := range tests {
+		_, err := UnquoteLiteral(tt.lit)
+		lerr, ok := err.(*LiteralError)
+		if !ok {
+			t.Errorf("UnquoteLiteral(%s) returns error %v, want *LiteralError", tt.lit, err)
+			continue
+		}
+		if lerr.Offset != tt.offset || lerr.Error() != tt.want {
+			t.Errorf("UnquoteLiteral(%s) returns error %q at %d, want %q at %d",
+				tt.lit, lerr, lerr.Offset, tt.want, tt.offset)
+		}
+	}
+}
+
+func TestLiteralErrorLocation(t *testing.T) {
+	want := `2:7: invalid escape sequence \q in literal "x\qy"`
+	if _, err := New("A <- 'a' B\nB <- \"x\\qy\""); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
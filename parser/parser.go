@@ -131,13 +131,25 @@ func New(gsource string) (Grammar, error) {
 		gsource: gsource,
 		rules:   make(map[string]*rule),
 	}
+	// pos is the byte position of the next line in gsource.
+	pos := 0
 	for scanner.Scan() {
-		line := strings.Trim(scanner.Text(), " \t\n")
+		raw := scanner.Text()
+		start := pos
+		pos += len(raw)
+		if pos < len(gsource) && gsource[pos] == '\r' {
+			pos++
+		}
+		pos++
+		line := strings.Trim(raw, " \t\n")
 		if line == "" || line[0] == '#' {
 			// ignore comments and empty lines
 			continue
 		}
 		err := g.addRule(line)
+		if lerr, ok := err.(*LiteralError); ok {
+			return nil, lerr.Locate(gsource, start+strings.Index(raw, lerr.Literal))
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -195,42 +207,18 @@ func ParseEscape(s string) (rune, int, error) {
 	return c, w, nil
 }
 
-func parseSingleQuoted(s string, q rune) (string, int, error) {
-	var i int
-	for i = 0; i < len(s) && s[i] != byte(q); i++ {
-	}
-	if i == len(s) {
-		return "", 0, fmt.Errorf("unclosed quote %c", q)
+// parseQuoted parses the literal at the beginning of s, see literal.go.
+// It returns the value of the literal and the number of consumed bytes.
+func parseQuoted(s string) (string, int, error) {
+	n := ScanLiteral(s)
+	if n < 0 {
+		return "", 0, fmt.Errorf("unterminated quoted literal: %s", s)
 	}
-	val := s[0:i]
-	return val, i + 1, nil
-}
-
-func parseDoubleQuoted(s string, q rune) (string, int, error) {
-	//log.Infof("parseDoubleQuoted(%q,%q)", s, q)
-	var r []rune
-	for i, w := 0, 0; i < len(s); i += w {
-		var c rune
-		c, w = utf8.DecodeRuneInString(s[i:])
-		switch c {
-		case q:
-			//log.Infof("returning %q, %d, nil", string(r), i+w)
-			return string(r), i + w, nil
-		case '\\':
-			i += w
-			var err error
-			c, w, err = ParseEscape(s[i:])
-			if err != nil {
-				return string(r), i + w, err
-			}
-			//log.Infof("parsed escape %q", c)
-		}
-		r = append(r, c)
-		if w == 0 {
-			return "", 0, fmt.Errorf("internal error in parseQuoted, w = 0")
-		}
+	val, err := UnquoteLiteral(s[:n])
+	if err != nil {
+		return "", n, err
 	}
-	return "", 0, fmt.Errorf("unterminated quoted literal: %c%s", q, s)
+	return val, n, nil
 }
 
 func parseBrackets(s string, qo, qc rune) (string, int, error) {
@@ -280,19 +268,12 @@ func parseParens(s string) (string, int, error) {
 			if level == 0 {
 				return s[0:i], i + w, nil
 			}
-		case '\'':
-			_, wq, err := parseSingleQuoted(s[i+w:], c)
+		case '\'', '"', '`':
+			_, wq, err := parseQuoted(s[i:])
 			if err != nil {
-				return s[0 : i+w+wq], i + w + wq, err
+				return s[0 : i+wq], i + wq, err
 			}
-			w += wq
-		case '"':
-			_, wq, err := parseDoubleQuoted(s[i+w:], c)
-			if err != nil {
-				return s[0 : i+w+wq], i + w + wq, err
-			}
-			//log.Infof("parsed literal %q", val)
-			w += wq
+			w = wq
 		}
 	}
 	return s, len(s), fmt.Errorf("reached end of line while expecting ')'")
@@ -331,21 +312,13 @@ func (g *grammar) createRule(label, rhs string) (*rule, error) {
 		c, w = utf8.DecodeRuneInString(rhs[i:])
 		//log.Infof("c = %q", c)
 		switch c {
-		case '\'':
-			val, wq, err := parseSingleQuoted(rhs[i+w:], c)
-			if err != nil {
-				return nil, err
-			}
-			handlers = append(handlers, g.newLiteralHandler(val))
-			w += wq
-		case '"':
-			val, wq, err := parseDoubleQuoted(rhs[i+w:], c)
+		case '\'', '"', '`':
+			val, wq, err := parseQuoted(rhs[i:])
 			if err != nil {
 				return nil, err
 			}
-			//log.Infof("parsed literal %q", val)
 			handlers = append(handlers, g.newLiteralHandler(val))
-			w += wq
+			w = wq
 		case '<':
 			switch captureCount {
 			case 1:
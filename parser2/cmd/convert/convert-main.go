@@ -0,0 +1,116 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary convert-main converts PEG grammars to other grammar notations, and
+// the grammars in other notations to PEG.
+//
+// Usage: convert-main --grammar=file.peg --to=ebnf|abnf|tree-sitter [--output=file]
+//
+//	convert-main --grammar=file --from=abnf|pigeon|pointlander [--strip_actions] [--output=file]
+//
+// It writes the converted grammar to --output or to stdout, and prints the
+// warnings about the constructs that the notation cannot express to stderr.
+// The grammars of pigeon and pointlander/peg are converted with their Go
+// code in comments, unless --strip_actions is set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/salikh/peg/parser2"
+	"github.com/salikh/peg/parser2/convert"
+)
+
+var (
+	grammarFlag = flag.String("grammar", "", "The path to the grammar file.")
+	toFlag      = flag.String("to", "", "The target notation: ebnf, abnf or tree-sitter.")
+	fromFlag    = flag.String("from", "", "The notation of the grammar file to convert to PEG: abnf, pigeon or pointlander.")
+	stripFlag   = flag.Bool("strip_actions", false, "Remove the Go code of pigeon and pointlander/peg grammars instead of keeping it in comments.")
+	nameFlag    = flag.String("name", "", "The name of the tree-sitter grammar. Defaults to the grammar file name.")
+	outputFlag  = flag.String("output", "", "The path to the output file. Defaults to stdout.")
+)
+
+func main() {
+	flag.Parse()
+	if *grammarFlag == "" {
+		log.Exitf("--grammar must not be empty.")
+	}
+	var out string
+	var warnings []*convert.Warning
+	if *fromFlag != "" {
+		if *toFlag != "" {
+			log.Exitf("--from and --to cannot be used together.")
+		}
+		source, err := ioutil.ReadFile(*grammarFlag)
+		if err != nil {
+			log.Exitf("Cannot read %q: %s", *grammarFlag, err)
+		}
+		opts := &convert.ImportOptions{StripActions: *stripFlag}
+		switch *fromFlag {
+		case "abnf":
+			out, warnings, err = convert.FromABNF(string(source))
+		case "pigeon":
+			out, warnings, err = convert.FromPigeon(string(source), opts)
+		case "pointlander":
+			out, warnings, err = convert.FromPointlander(string(source), opts)
+		default:
+			log.Exitf("Unknown --from=%q, want abnf, pigeon or pointlander.", *fromFlag)
+		}
+		if err != nil {
+			log.Exitf("Error converting %q: %s", *grammarFlag, err)
+		}
+		write(out, warnings)
+		return
+	}
+	g, err := parser2.ParseGrammarFS(os.DirFS(filepath.Dir(*grammarFlag)), filepath.Base(*grammarFlag))
+	if err != nil {
+		log.Exitf("Error parsing the grammar file %q: %s", *grammarFlag, err)
+	}
+	switch *toFlag {
+	case "ebnf":
+		out, warnings = convert.EBNF(g)
+	case "abnf":
+		out, warnings = convert.ABNF(g)
+	case "tree-sitter":
+		name := *nameFlag
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(*grammarFlag), filepath.Ext(*grammarFlag))
+		}
+		out, warnings = convert.TreeSitter(g, name)
+	default:
+		log.Exitf("Unknown --to=%q, want ebnf, abnf or tree-sitter.", *toFlag)
+	}
+	write(out, warnings)
+}
+
+// write writes the converted grammar to --output or to stdout, and the
+// warnings to stderr.
+func write(out string, warnings []*convert.Warning) {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", *grammarFlag, w)
+	}
+	if *outputFlag == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := ioutil.WriteFile(*outputFlag, []byte(out), 0644); err != nil {
+		log.Exitf("Cannot write %q: %s", *outputFlag, err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary lint-main checks PEG grammars for common problems, such as
+// undefined or unreachable rules, shadowed choices, repetitions of
+// expressions matching empty input and left recursion.
+//
+// Usage: lint-main --grammar=file.peg[,other.peg...]
+//
+// The files imported by the grammar files are checked as well. It prints one line per problem and exits with non-zero status if any
+// problems were found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/salikh/peg/parser2"
+)
+
+var grammarFlag = flag.String("grammar", "",
+	"The comma-separated list of paths to the grammar files.")
+
+func main() {
+	flag.Parse()
+	if *grammarFlag == "" {
+		log.Exitf("--grammar must not be empty.")
+	}
+	found := false
+	for _, filename := range strings.Split(*grammarFlag, ",") {
+		dir := filepath.Dir(filename)
+		prefix := ""
+		if dir != "." {
+			prefix = dir + string(filepath.Separator)
+		}
+		g, err := parser2.ParseGrammarFS(os.DirFS(dir), filepath.Base(filename))
+		if err != nil {
+			log.Exitf("Error parsing the grammar file %q: %s", filename, err)
+		}
+		for _, issue := range parser2.Lint(g) {
+			fmt.Printf("%s%s\n", prefix, issue)
+			found = true
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
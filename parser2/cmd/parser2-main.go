@@ -35,6 +35,7 @@ var (
 		"The input to feed to the parser. Takes precedence over inputFile")
 	ignoreUnconsumedTail = flag.Bool("ignore_unconsumed_tail", false, "ParserOptions.IgnoreUnconsumedTail")
 	skipEmptyNodes       = flag.Bool("skip_empty_nodes", false, "ParserOptions.SkipEmptyNodes")
+	byteMode             = flag.Bool("byte_mode", false, "ParserOptions.ByteMode")
 )
 
 var grammar *parser2.Grammar
@@ -49,6 +50,7 @@ func main() {
 	options := &parser2.ParserOptions{
 		IgnoreUnconsumedTail: *ignoreUnconsumedTail,
 		SkipEmptyNodes:       *skipEmptyNodes,
+		ByteMode:             *byteMode,
 	}
 	grammar, err = parser2.New(grammarSource, options)
 	if err != nil {
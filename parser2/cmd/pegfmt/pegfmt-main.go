@@ -0,0 +1,79 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary pegfmt formats PEG grammars in the canonical layout of
+// parser2.Format.
+//
+// Usage: pegfmt [--write] [--list] [file.peg...]
+//
+// Without files it formats the standard input. By default the formatted
+// grammars are printed to the standard output.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	log "github.com/golang/glog"
+	"github.com/salikh/peg/parser2"
+)
+
+var (
+	write = flag.Bool("write", false, "If true, write the formatted grammar back to the file instead of the standard output.")
+	list  = flag.Bool("list", false, "If true, print the names of the files whose formatting differs instead of the formatted grammars.")
+)
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		if *write || *list {
+			log.Exitf("--write and --list require file arguments.")
+		}
+		source, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Exitf("Error reading the standard input: %s", err)
+		}
+		formatted, err := parser2.Format(string(source))
+		if err != nil {
+			log.Exitf("Error formatting the standard input: %s", err)
+		}
+		fmt.Print(formatted)
+		return
+	}
+	for _, filename := range flag.Args() {
+		source, err := ioutil.ReadFile(filename)
+		if err != nil {
+			log.Exitf("Error reading %q: %s", filename, err)
+		}
+		formatted, err := parser2.Format(string(source))
+		if err != nil {
+			log.Exitf("Error formatting %q: %s", filename, err)
+		}
+		changed := !bytes.Equal(source, []byte(formatted))
+		if *list && changed {
+			fmt.Println(filename)
+		}
+		if *write && changed {
+			if err := ioutil.WriteFile(filename, []byte(formatted), 0644); err != nil {
+				log.Exitf("Error writing %q: %s", filename, err)
+			}
+		}
+		if !*write && !*list {
+			fmt.Print(formatted)
+		}
+	}
+}
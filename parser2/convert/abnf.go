@@ -0,0 +1,220 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// maxSetRanges is the maximum number of ranges of a character set written
+// as the alternation of the ranges in ABNF. The larger sets, such as
+// [:alpha:], are written as prose.
+const maxSetRanges = 32
+
+// ABNF converts the grammar to the ABNF notation of RFC 5234:
+//
+//	expr = term *("+" term)
+//	term = 1*%x30-39
+//
+// The rule names are converted to the ABNF syntax, e.g. Ident_List becomes
+// Ident-List and _ becomes ws, and made unique, since the ABNF rule names
+// are case-insensitive. The case-sensitive literals with letters are written
+// as the sequences of character codes, since the ABNF strings are
+// case-insensitive. The %skip directive is applied to the rules, and the
+// precedence tables are converted to the operands separated by the
+// operators.
+func ABNF(g *parser2.Grammar) (string, []*Warning) {
+	b := &builder{target: "ABNF"}
+	names, exprs := prepare(g, b)
+	p := &abnfPrinter{builder: b, names: make(map[string]string), used: make(map[string]bool)}
+	width := 0
+	for _, name := range names {
+		if n := len(p.name(name)); n > width {
+			width = n
+		}
+	}
+	var r strings.Builder
+	for _, name := range names {
+		b.rule = name
+		s, _ := p.expr(exprs[name])
+		fmt.Fprintf(&r, "%-*s = %s\n", width, p.name(name), s)
+	}
+	return r.String(), b.warnings
+}
+
+type abnfPrinter struct {
+	*builder
+	// names maps the rule names to the ABNF rule names, and used is the
+	// set of the lowercased ABNF rule names.
+	names map[string]string
+	used  map[string]bool
+}
+
+// name returns the ABNF rule name of the rule.
+func (p *abnfPrinter) name(rule string) string {
+	if name, ok := p.names[rule]; ok {
+		return name
+	}
+	base := strings.Replace(rule, "_", "-", -1)
+	if strings.Trim(base, "-") == "" {
+		// The whitespace rule _.
+		base = "ws"
+	} else if c := base[0]; !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+		base = "r" + base
+	}
+	name := base
+	for i := 2; p.used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	p.used[strings.ToLower(name)] = true
+	p.names[rule] = name
+	return name
+}
+
+// expr returns the ABNF of the expression and its precedence level.
+func (p *abnfPrinter) expr(e *expr) (string, int) {
+	switch e.kind {
+	case seqExpr:
+		if len(e.items) == 0 {
+			return `""`, primaryLevel
+		}
+		var parts []string
+		for _, item := range e.items {
+			s, level := p.expr(item)
+			parts = append(parts, paren(s, level, seqLevel))
+		}
+		return strings.Join(parts, " "), seqLevel
+	case choiceExpr:
+		var parts []string
+		for _, item := range e.items {
+			s, _ := p.expr(item)
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " / "), choiceLevel
+	case repeatExpr:
+		s, level := p.expr(e.items[0])
+		if e.min == 0 && e.max == 1 {
+			return "[" + s + "]", primaryLevel
+		}
+		s = paren(s, level, primaryLevel)
+		switch {
+		case e.min == e.max:
+			return fmt.Sprintf("%d%s", e.min, s), primaryLevel
+		case e.max < 0 && e.min == 0:
+			return "*" + s, primaryLevel
+		case e.max < 0:
+			return fmt.Sprintf("%d*%s", e.min, s), primaryLevel
+		case e.min == 0:
+			return fmt.Sprintf("*%d%s", e.max, s), primaryLevel
+		}
+		return fmt.Sprintf("%d*%d%s", e.min, e.max, s), primaryLevel
+	case litExpr:
+		return abnfLiteral(e.lit, e.fold)
+	case setExpr:
+		return p.set(e)
+	}
+	return p.name(e.ref), primaryLevel
+}
+
+// isQuotable reports whether the character can be written in an ABNF
+// string.
+func isQuotable(c rune) bool {
+	return c >= 0x20 && c <= 0x7e && c != '"'
+}
+
+// abnfLiteral returns the literal as a sequence of strings and character
+// codes %xN.N.
+func abnfLiteral(lit string, fold bool) (string, int) {
+	var parts []string
+	var quoted, codes []string
+	flush := func() {
+		if len(quoted) > 0 {
+			parts = append(parts, `"`+strings.Join(quoted, "")+`"`)
+			quoted = nil
+		}
+		if len(codes) > 0 {
+			parts = append(parts, "%x"+strings.Join(codes, "."))
+			codes = nil
+		}
+	}
+	for _, c := range lit {
+		letter := unicode.IsLetter(c)
+		switch {
+		case isQuotable(c) && (fold || !letter):
+			if len(codes) > 0 {
+				flush()
+			}
+			quoted = append(quoted, string(c))
+		case fold && letter && len(foldSet(c)) > 1:
+			flush()
+			s, _ := abnfSet(foldSet(c))
+			parts = append(parts, "("+s+")")
+		default:
+			if len(quoted) > 0 {
+				flush()
+			}
+			codes = append(codes, fmt.Sprintf("%X", c))
+		}
+	}
+	flush()
+	if len(parts) == 1 {
+		return parts[0], primaryLevel
+	}
+	return strings.Join(parts, " "), seqLevel
+}
+
+// abnfSet returns the alternation of the ranges of the set.
+func abnfSet(set charclass.Set) (string, int) {
+	var parts []string
+	for _, iv := range set {
+		if iv.Lo == iv.Hi {
+			parts = append(parts, fmt.Sprintf("%%x%X", iv.Lo))
+		} else {
+			parts = append(parts, fmt.Sprintf("%%x%X-%X", iv.Lo, iv.Hi))
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], primaryLevel
+	}
+	return strings.Join(parts, " / "), choiceLevel
+}
+
+func (p *abnfPrinter) set(e *expr) (string, int) {
+	if len(e.set) > 0 && len(e.set) <= maxSetRanges {
+		return abnfSet(e.set)
+	}
+	desc := "no character"
+	if len(e.set) == 0 {
+		p.warn("the character set matches no characters and was written as prose")
+	} else {
+		desc = "character set"
+		if e.cc != nil {
+			desc = "[" + e.cc.String() + "]"
+		}
+		p.warn("the character set %s has %d ranges and was written as prose", desc, len(e.set))
+	}
+	prose := strings.Map(func(c rune) rune {
+		if c < 0x20 || c > 0x7e || c == '>' {
+			return '?'
+		}
+		return c
+	}, desc)
+	return "<" + prose + ">", primaryLevel
+}
@@ -0,0 +1,471 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert converts PEG grammars to other grammar notations:
+// W3C EBNF, RFC 5234 ABNF and tree-sitter grammar.js.
+//
+// The other notations describe context-free grammars, so the ordered choice
+// of PEG is converted to the unordered alternation, which may accept more
+// inputs or make the grammar ambiguous. The constructs that the notations
+// cannot express, such as predicates, cuts and semantic predicates, are
+// dropped and reported as warnings. The predicates that restrict a single
+// character, as in (!"\n" .), are folded into character sets, which all
+// notations can express.
+//
+// In the other direction, FromABNF, FromPigeon and FromPointlander convert
+// ABNF documents and the grammars of the pigeon and pointlander/peg parser
+// generators to the PEG source.
+package convert
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// Warning is a construct of the grammar that the target notation cannot
+// express exactly.
+type Warning struct {
+	// Rule is the name of the rule with the construct.
+	Rule    string
+	Message string
+}
+
+func (w *Warning) String() string {
+	return w.Rule + ": " + w.Message
+}
+
+type exprKind int
+
+const (
+	// seqExpr is a sequence of items. The empty sequence matches empty input.
+	seqExpr exprKind = iota
+	choiceExpr
+	litExpr
+	// setExpr matches a single character from the set.
+	setExpr
+	refExpr
+	// repeatExpr is the repetition of items[0] from min to max times, where
+	// max is -1 if the repetition is unbounded.
+	repeatExpr
+	// precExpr is a precedence table of the operand rule ref.
+	precExpr
+)
+
+// expr is the grammar expression in the form common to all notations.
+type expr struct {
+	kind  exprKind
+	items []*expr
+	// lit is the literal, matched case-insensitively if fold is true.
+	lit  string
+	fold bool
+	set  charclass.Set
+	// cc is the source char class of the set, if the set was not changed
+	// by the predicates.
+	cc *charclass.CharClass
+	// ref is the name of the referenced rule, and label is the label of
+	// the reference.
+	ref, label string
+	min, max   int
+	prec       *parser2.Precedence
+}
+
+func seq(items ...*expr) *expr {
+	return &expr{kind: seqExpr, items: items}
+}
+
+func ref(name string) *expr {
+	return &expr{kind: refExpr, ref: name}
+}
+
+func repeat(item *expr, min, max int) *expr {
+	return &expr{kind: repeatExpr, items: []*expr{item}, min: min, max: max}
+}
+
+// isAny reports whether the set has all characters.
+func isAny(set charclass.Set) bool {
+	return len(set) == 1 && set[0].Lo == 0 && set[0].Hi == unicode.MaxRune
+}
+
+// foldSet returns the set of the runes equivalent to c under Unicode simple
+// case folding.
+func foldSet(c rune) charclass.Set {
+	s := []charclass.Span{{Lo: c, Hi: c}}
+	for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+		s = append(s, charclass.Span{Lo: f, Hi: f})
+	}
+	return charclass.Normalize(s)
+}
+
+// isLexical reports whether the rule is exempt from the %skip directive,
+// as in parser2.
+func isLexical(g *parser2.Grammar, rule *parser2.Rule) bool {
+	if rule.Token || rule.Ident == g.Skip {
+		return true
+	}
+	letters := false
+	for _, c := range rule.Ident {
+		if unicode.IsLower(c) {
+			return false
+		}
+		letters = letters || unicode.IsLetter(c)
+	}
+	return letters
+}
+
+// builder converts the rules of the grammar to expressions, and collects
+// the warnings about the constructs that the target notation cannot
+// express.
+type builder struct {
+	// target is the name of the target notation for the warnings.
+	target   string
+	rule     string
+	warnings []*Warning
+}
+
+func (b *builder) warn(format string, args ...interface{}) {
+	b.warnings = append(b.warnings, &Warning{Rule: b.rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// convert returns the expressions of the rules of the grammar in the order
+// of the rules. The parameterized rules are skipped, since the grammar has
+// their instances.
+func (b *builder) convert(g *parser2.Grammar) (names []string, exprs map[string]*expr) {
+	exprs = make(map[string]*expr)
+	for _, name := range g.RuleNames {
+		rule := g.Rules[name]
+		if len(rule.Params) > 0 {
+			continue
+		}
+		b.rule = name
+		names = append(names, name)
+		exprs[name] = b.rhs(rule.RHS)
+	}
+	return names, exprs
+}
+
+func (b *builder) rhs(rhs *parser2.RHS) *expr {
+	if rhs == nil {
+		return seq()
+	}
+	c := &expr{kind: choiceExpr}
+	for _, terms := range rhs.Terms {
+		e := b.seq(terms)
+		if e.kind == seqExpr && len(e.items) == 0 {
+			// The empty alternative always matches, so the alternatives after
+			// it are never tried.
+			if len(c.items) == 0 {
+				return e
+			}
+			return repeat(single(c), 0, 1)
+		}
+		c.items = append(c.items, e)
+	}
+	return single(c)
+}
+
+// single returns the only item of the sequence or choice, or the expression.
+func single(e *expr) *expr {
+	if (e.kind == seqExpr || e.kind == choiceExpr) && len(e.items) == 1 {
+		return e.items[0]
+	}
+	return e
+}
+
+func (b *builder) seq(terms []*parser2.Term) *expr {
+	s := seq()
+	for i := 0; i < len(terms); i++ {
+		if set, n, ok := b.predSet(terms[i:]); ok {
+			s.items = append(s.items, &expr{kind: setExpr, set: set})
+			i += n - 1
+			continue
+		}
+		if e := b.term(terms[i]); e != nil {
+			if e.kind == seqExpr {
+				s.items = append(s.items, e.items...)
+			} else {
+				s.items = append(s.items, e)
+			}
+		}
+	}
+	return single(s)
+}
+
+// termSet returns the set of characters matched by the term, if it
+// matches a single character.
+func termSet(term *parser2.Term) (charclass.Set, bool) {
+	switch {
+	case term.Recover != "" || term.Special != nil || term.Prec != nil:
+		return nil, false
+	case term.Parens != nil && len(term.Parens.Terms) == 1 && len(term.Parens.Terms[0]) == 1:
+		return termSet(term.Parens.Terms[0][0])
+	case term.CharClass != nil:
+		return term.CharClass.Set(), true
+	case term.Literal != "":
+		runes := []rune(term.Literal)
+		if len(runes) != 1 {
+			return nil, false
+		}
+		if term.IgnoreCase {
+			return foldSet(runes[0]), true
+		}
+		return charclass.Set{{Lo: runes[0], Hi: runes[0]}}, true
+	}
+	return nil, false
+}
+
+// predSet folds the predicates on a single character followed by a term
+// matching a single character, as in !"\n" ., into the set of characters.
+// It returns the number of the folded terms.
+func (b *builder) predSet(terms []*parser2.Term) (charclass.Set, int, bool) {
+	type pred struct {
+		set charclass.Set
+		neg bool
+	}
+	var preds []pred
+	n := 0
+	for ; n < len(terms); n++ {
+		term := terms[n]
+		if term.Recover != "" || term.NegPred == nil && term.Pred == nil {
+			break
+		}
+		if term.NegPred != nil {
+			set, ok := termSet(term.NegPred)
+			if !ok {
+				return nil, 0, false
+			}
+			preds = append(preds, pred{set, true})
+		} else {
+			set, ok := termSet(term.Pred)
+			if !ok {
+				return nil, 0, false
+			}
+			preds = append(preds, pred{set, false})
+		}
+	}
+	if len(preds) == 0 || n == len(terms) {
+		return nil, 0, false
+	}
+	set, ok := termSet(terms[n])
+	if !ok {
+		return nil, 0, false
+	}
+	for _, p := range preds {
+		if p.neg {
+			set = set.Subtract(p.set)
+		} else {
+			set = set.Intersect(p.set)
+		}
+	}
+	return set, n + 1, true
+}
+
+func (b *builder) term(term *parser2.Term) *expr {
+	if term.Recover != "" {
+		b.warn("the error recovery ^%s is not supported in %s and was dropped", term.Recover, b.target)
+		t := *term
+		t.Recover = ""
+		return b.term(&t)
+	}
+	switch {
+	case term.Prec != nil:
+		return &expr{kind: precExpr, ref: term.Ident, prec: term.Prec}
+	case term.Special != nil:
+		item := b.term(term.Special.Term)
+		if item == nil {
+			return nil
+		}
+		switch term.Special.Rune {
+		case '?':
+			return repeat(item, 0, 1)
+		case '*':
+			return repeat(item, 0, -1)
+		case '+':
+			return repeat(item, 1, -1)
+		}
+		return repeat(item, term.Special.Min, term.Special.Max)
+	case term.Parens != nil:
+		return b.rhs(term.Parens)
+	case term.Capture != nil:
+		return b.rhs(term.Capture)
+	case term.NegPred != nil:
+		b.warn("the negative predicate %s is not supported in %s and was dropped", term.ShortString(), b.target)
+		return nil
+	case term.Pred != nil:
+		b.warn("the positive predicate %s is not supported in %s and was dropped", term.ShortString(), b.target)
+		return nil
+	case term.SemPred != "" || term.SemNegPred != "":
+		b.warn("the semantic predicate %s is not supported in %s and was dropped", term.ShortString(), b.target)
+		return nil
+	case term.Cut:
+		b.warn("the cut ~ is not supported in %s and was dropped", b.target)
+		return nil
+	case term.Indent != "":
+		b.warn("the indentation term %s is not supported in %s and was dropped", term.Indent, b.target)
+		return nil
+	case term.BackRef != "":
+		b.warn("the back-reference $%s is not supported in %s and was dropped", term.BackRef, b.target)
+		return nil
+	case term.CharClass != nil:
+		return &expr{kind: setExpr, set: term.CharClass.Set(), cc: term.CharClass}
+	case term.Ident != "":
+		return &expr{kind: refExpr, ref: term.Ident, label: term.Label}
+	case term.Literal != "":
+		return &expr{kind: litExpr, lit: term.Literal, fold: term.IgnoreCase}
+	}
+	// The empty literal.
+	return seq()
+}
+
+// flattenPrec converts the precedence table to the operands separated by
+// the infix operators, with the optional prefix and postfix operators.
+func flattenPrec(e *expr) *expr {
+	var prefix, postfix, infix []*expr
+	for _, level := range e.prec.Levels {
+		for _, op := range level.Ops {
+			lit := &expr{kind: litExpr, lit: op.Literal}
+			switch level.Kind {
+			case "prefix":
+				prefix = append(prefix, lit)
+			case "postfix":
+				postfix = append(postfix, lit)
+			default:
+				infix = append(infix, lit)
+			}
+		}
+	}
+	unit := seq()
+	if len(prefix) > 0 {
+		unit.items = append(unit.items, repeat(single(&expr{kind: choiceExpr, items: prefix}), 0, -1))
+	}
+	unit.items = append(unit.items, ref(e.ref))
+	if len(postfix) > 0 {
+		unit.items = append(unit.items, repeat(single(&expr{kind: choiceExpr, items: postfix}), 0, -1))
+	}
+	if len(infix) == 0 {
+		return single(unit)
+	}
+	op := single(&expr{kind: choiceExpr, items: infix})
+	return seq(single(unit), repeat(seq(op, single(unit)), 0, -1))
+}
+
+// insertSkip returns the expression with the references to the skip rule
+// inserted between the items of sequences and between the iterations of
+// repetitions, as parser2 does for the %skip directive.
+func insertSkip(e *expr, skip string) *expr {
+	switch e.kind {
+	case precExpr:
+		return insertSkip(flattenPrec(e), skip)
+	case seqExpr:
+		s := seq()
+		for i, item := range e.items {
+			if i > 0 {
+				s.items = append(s.items, ref(skip))
+			}
+			s.items = append(s.items, insertSkip(item, skip))
+		}
+		return s
+	case choiceExpr:
+		c := &expr{kind: choiceExpr}
+		for _, item := range e.items {
+			c.items = append(c.items, insertSkip(item, skip))
+		}
+		return c
+	case repeatExpr:
+		item := insertSkip(e.items[0], skip)
+		if e.max == 0 || e.max == 1 {
+			return repeat(item, e.min, e.max)
+		}
+		// X{n,m} is (X (_ X){n-1,m-1}), optional if n is 0.
+		min, max := e.min-1, e.max-1
+		if min < 0 {
+			min = 0
+		}
+		if e.max < 0 {
+			max = -1
+		}
+		r := seq(item, repeat(seq(ref(skip), item), min, max))
+		if e.min == 0 {
+			return repeat(r, 0, 1)
+		}
+		return r
+	}
+	return e
+}
+
+// expandRepeat returns the expression with the bounded repetitions
+// replaced by the sequences of the optional (?), zero or more (*) and one
+// or more (+) repetitions.
+func expandRepeat(e *expr) *expr {
+	switch e.kind {
+	case seqExpr, choiceExpr:
+		r := &expr{kind: e.kind}
+		for _, item := range e.items {
+			r.items = append(r.items, expandRepeat(item))
+		}
+		return r
+	case repeatExpr:
+		item := expandRepeat(e.items[0])
+		min, max := e.min, e.max
+		switch {
+		case min == 0 && (max == 1 || max == -1), min == 1 && max == -1:
+			return repeat(item, min, max)
+		case max == -1:
+			// X{n,} is X X ... X+ with n-1 copies before X+.
+			s := seq()
+			for i := 0; i < min-1; i++ {
+				s.items = append(s.items, item)
+			}
+			s.items = append(s.items, repeat(item, 1, -1))
+			return single(s)
+		}
+		// X{n,m} is n copies of X followed by (X (X ...)?)? with m-n
+		// optional copies.
+		var opt *expr
+		for i := 0; i < max-min; i++ {
+			if opt == nil {
+				opt = repeat(item, 0, 1)
+			} else {
+				opt = repeat(seq(item, opt), 0, 1)
+			}
+		}
+		s := seq()
+		for i := 0; i < min; i++ {
+			s.items = append(s.items, item)
+		}
+		if opt != nil {
+			s.items = append(s.items, opt)
+		}
+		return single(s)
+	}
+	return e
+}
+
+// prepare returns the expressions of the rules for the notations without
+// precedence tables and the %skip directive.
+func prepare(g *parser2.Grammar, b *builder) ([]string, map[string]*expr) {
+	names, exprs := b.convert(g)
+	for _, name := range names {
+		e := exprs[name]
+		if g.Skip != "" && !isLexical(g, g.Rules[name]) {
+			e = insertSkip(e, g.Skip)
+		} else if e.kind == precExpr {
+			e = flattenPrec(e)
+		}
+		exprs[name] = e
+	}
+	return names, exprs
+}
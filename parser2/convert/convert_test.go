@@ -0,0 +1,534 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/salikh/peg/compat/runfiles"
+	"github.com/salikh/peg/parser2"
+)
+
+type convertTest struct {
+	grammar string
+	want    string
+	// warnings are the expected warnings, one per line.
+	warnings string
+}
+
+func runConvertTests(t *testing.T, name string, convert func(*parser2.Grammar) (string, []*Warning), tests []convertTest) {
+	for _, tt := range tests {
+		g, err := parser2.ParseGrammar(tt.grammar)
+		if err != nil {
+			t.Errorf("ParseGrammar(%q) returns error %s", tt.grammar, err)
+			continue
+		}
+		got, warnings := convert(g)
+		if got != tt.want {
+			t.Errorf("%s(%q) returns\n%s---, want\n%s---", name, tt.grammar, got, tt.want)
+		}
+		var ws []string
+		for _, w := range warnings {
+			ws = append(ws, w.String())
+		}
+		if got := strings.Join(ws, "\n"); got != tt.warnings {
+			t.Errorf("%s(%q) returns warnings\n%s\nwant\n%s", name, tt.grammar, got, tt.warnings)
+		}
+	}
+}
+
+func TestEBNF(t *testing.T) {
+	runConvertTests(t, "EBNF", EBNF, []convertTest{
+		{grammar: `Expr <- Num ("+" Num)*
+Num <- [0-9]+`, want: `Expr ::= Num ("+" Num)*
+Num  ::= [0-9]+
+`},
+		{grammar: `Str <- '"' (!'"' .)* '"' / "'" [^']* "'" / "a\tb" / 'x"y' "'"`,
+			want: `Str ::= '"' [^"]* '"' | "'" [^']* "'" | "a" #x9 "b" | 'x"y' "'"` + "\n"},
+		{grammar: `A <- "if"i B{2,3} B{2,} B?
+B <- "b"`, want: `A ::= [Ii] [Ff] B B B? B B+ B?
+B ::= "b"
+`},
+		{grammar: `%skip _
+List <- Item ("," Item)*
+%token Item <- [a-z]+
+_ <- " "*`, want: `List ::= Item _ ("," _ Item (_ "," _ Item)*)?
+Item ::= [a-z]+
+_    ::= " "*
+`},
+		{grammar: `A <- &B B ~ !{p} $x ^R <x: "x">
+B <- "b"
+R <- "r"`, want: `A ::= B "x"
+B ::= "b"
+R ::= "r"
+`, warnings: `A: the positive predicate &B is not supported in EBNF and was dropped
+A: the cut ~ is not supported in EBNF and was dropped
+A: the semantic predicate !{p} is not supported in EBNF and was dropped
+A: the error recovery ^R is not supported in EBNF and was dropped
+A: the back-reference $x is not supported in EBNF and was dropped`},
+	})
+}
+
+func TestABNF(t *testing.T) {
+	runConvertTests(t, "ABNF", ABNF, []convertTest{
+		{grammar: `Expr <- Num ("+" Num)*
+Num <- [0-9]+`, want: `Expr = Num *("+" Num)
+Num  = 1*%x30-39
+`},
+		{grammar: `Key_Word <- "if" / "IF"i / "\x00;" ";é"
+_ <- [ \t]* !. ^key_word
+key_word <- "k"{1,2} [a-c]{0,3} [xz]{2}`, want: `Key-Word   = %x69.66 / "IF" / %x0 ";" ";" %xE9
+ws         = *(%x9 / %x20)
+key-word-2 = 1*2%x6B *3%x61-63 2(%x78 / %x7A)
+`, warnings: `_: the error recovery ^key_word is not supported in ABNF and was dropped
+_: the negative predicate ![[:any:]] is not supported in ABNF and was dropped`},
+		{grammar: `Expr <- %prec Num %left "+" %prefix "-"
+Num <- [[:alpha:]]`, want: `Expr = *"-" Num *("+" *"-" Num)
+Num  = <[[:alpha:]]>
+`, warnings: `Num: the character set [[:alpha:]] has 684 ranges and was written as prose`},
+	})
+}
+
+func TestTreeSitter(t *testing.T) {
+	runConvertTests(t, "TreeSitter", func(g *parser2.Grammar) (string, []*Warning) {
+		return TreeSitter(g, "test-grammar")
+	}, []convertTest{
+		{grammar: `Expr <- %prec Num %left "+" "-" %right "^" %prefix "-"
+Num <- [0-9]+ / "(" Expr ")"`, want: `module.exports = grammar({
+  name: "test_grammar",
+  extras: $ => [],
+  rules: {
+    Expr: $ => choice(
+      $.Num,
+      prec.left(1, seq($.Expr, choice("+", "-"), $.Expr)),
+      prec.right(2, seq($.Expr, "^", $.Expr)),
+      prec(3, seq("-", $.Expr)),
+    ),
+    Num: $ => choice(
+      repeat1(/[0-9]/),
+      seq("(", $.Expr, ")"),
+    ),
+  },
+});
+`},
+		{grammar: `%skip _
+Assign <- _ name:Ident "=" VALUE{1,2} _
+%token Ident <- [a-z]+
+VALUE <- "null"i / [^\n/]
+_ <- ([ \t] / "#" [^\n]*)*`, want: `module.exports = grammar({
+  name: "test_grammar",
+  extras: $ => [/[\t ]/, seq("#", repeat(/[^\n]/))],
+  rules: {
+    Assign: $ => seq(field("name", $.Ident), "=", $.VALUE, optional($.VALUE)),
+    Ident: $ => token(repeat1(/[a-z]/)),
+    VALUE: $ => token(choice(
+      /[Nn][Uu][Ll][Ll]/,
+      /[^\n\/]/,
+    )),
+  },
+});
+`},
+		{grammar: `A <- B? C
+B <- "b"*
+C <- (!"c" [a-c])+ INDENT`, want: `module.exports = grammar({
+  name: "test_grammar",
+  extras: $ => [],
+  rules: {
+    A: $ => seq(optional($.B), $.C),
+    B: $ => repeat("b"),
+    C: $ => repeat1(/[ab]/),
+  },
+});
+`, warnings: `C: the indentation term INDENT is not supported in tree-sitter and was dropped
+B: the rule matches empty input, which tree-sitter does not allow`},
+	})
+}
+
+func TestFromABNF(t *testing.T) {
+	tests := []struct {
+		source   string
+		want     string
+		warnings string
+	}{
+		{source: "ip-address = 1*3DIGIT 3(\".\" 1*3DIGIT)\r\n", want: `ip_address <- [0-9]{1,3} ("." [0-9]{1,3}){3}
+`},
+		{source: `; Comments and continuation lines.
+greeting = %s"Hello" 0*1SP Name ; case-sensitive
+           [","] *WSP
+name     = "world" / %x41-5A *(ALPHA / "-")
+name     =/ %d72.105 / %b1111111
+`, want: `greeting <- "Hello" " "? name ","? [\t ]*
+name     <- "world"i / [A-Z] [\-A-Za-z]* / "Hi" / "\x7f"
+`},
+		{source: `tokens = token / token "," tokens
+token  = "a" / "ab" / ALPHA / "x"
+number = *DIGIT DIGIT / <any number>
+INDENT = [ "-" ] / "+"
+`, want: `tokens  <- token / token "," tokens
+token   <- "a"i / "ab"i / [A-Za-z]
+number  <- [0-9]* [0-9] / &. !.
+INDENT_ <- "-"? / "+"
+`, warnings: `number: the prose <any number> cannot be converted and was replaced by a term that never matches
+tokens: the alternative token "," tokens never matches, since the earlier alternative token matches first; consider reordering them
+token: the alternative "ab"i never matches, since the earlier alternative "a"i matches first; consider reordering them
+number: the repetition [0-9]* never leaves input for the following [0-9], since PEG repetitions are greedy
+INDENT_: the alternative "-"? matches empty input, so the later alternatives are never tried`},
+	}
+	for _, tt := range tests {
+		got, warnings, err := FromABNF(tt.source)
+		if err != nil {
+			t.Errorf("FromABNF(%q) returns error %s", tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FromABNF(%q) returns\n%s---, want\n%s---", tt.source, got, tt.want)
+		}
+		var ws []string
+		for _, w := range warnings {
+			ws = append(ws, w.String())
+		}
+		if got := strings.Join(ws, "\n"); got != tt.warnings {
+			t.Errorf("FromABNF(%q) returns warnings\n%s\nwant\n%s", tt.source, got, tt.warnings)
+		}
+		if _, err := parser2.New(got, nil); err != nil {
+			t.Errorf("FromABNF(%q) returns grammar with error %s:\n%s", tt.source, err, got)
+		}
+	}
+}
+
+func TestFromABNFParse(t *testing.T) {
+	source, _, err := FromABNF(`date     = year "-" month "-" day
+year     = 4DIGIT
+month    = "1" %x30-32 / "0" %x31-39
+day      = 2DIGIT
+`)
+	if err != nil {
+		t.Fatalf("FromABNF returns error %s", err)
+	}
+	g, err := parser2.New(source, nil)
+	if err != nil {
+		t.Fatalf("New(%q) returns error %s", source, err)
+	}
+	for _, input := range []string{"2019-12-31", "2019-01-01"} {
+		if _, err := g.Parse(input); err != nil {
+			t.Errorf("Parse(%q) returns error %s", input, err)
+		}
+	}
+	for _, input := range []string{"2019-13-01", "19-01-01", "2019-1-01"} {
+		if _, err := g.Parse(input); err == nil {
+			t.Errorf("Parse(%q) returns no error, want error", input)
+		}
+	}
+}
+
+func TestFromABNFErrors(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"a = \"a\"\nA = \"b\"\n",
+		"a = (\"a\"\n",
+		"a = %q41\n",
+	} {
+		if got, _, err := FromABNF(source); err == nil {
+			t.Errorf("FromABNF(%q) returns %q, want error", source, got)
+		}
+	}
+}
+
+type importTest struct {
+	source string
+	opts   *ImportOptions
+	want   string
+	// warnings are the expected warnings, one per line.
+	warnings string
+}
+
+func runImportTests(t *testing.T, name string, convert func(string, *ImportOptions) (string, []*Warning, error), tests []importTest) {
+	for _, tt := range tests {
+		got, warnings, err := convert(tt.source, tt.opts)
+		if err != nil {
+			t.Errorf("%s(%q) returns error %s", name, tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s(%q) returns\n%s---, want\n%s---", name, tt.source, got, tt.want)
+		}
+		var ws []string
+		for _, w := range warnings {
+			ws = append(ws, w.String())
+		}
+		if got := strings.Join(ws, "\n"); got != tt.warnings {
+			t.Errorf("%s(%q) returns warnings\n%s\nwant\n%s", name, tt.source, got, tt.warnings)
+		}
+		if _, err := parser2.New(got, nil); err != nil {
+			t.Errorf("%s(%q) returns grammar with error %s:\n%s", name, tt.source, err, got)
+		}
+	}
+}
+
+const pigeonCalculator = `{
+package main
+
+func toInt(v interface{}) int { return v.(int) }
+}
+
+Input <- expr:Expr EOF {
+    return expr, nil
+}
+
+Expr "expression" <- _ first:Term rest:( _ AddOp _ Term )* _ {
+    return eval(first, rest), nil
+}
+Term <- Factor ( _ MulOp _ Factor )*
+Factor <- '(' _ Expr _ ')' / Integer
+AddOp <- ( "+" / "-" )
+MulOp <- ( "*" / "/" )
+Integer <- '-'? [0-9]+ { return strconv.Atoi(string(c.text)) }
+_ "whitespace" <- [ \n\t\r]*
+EOF <- !.
+`
+
+func TestFromPigeon(t *testing.T) {
+	runImportTests(t, "FromPigeon", FromPigeon, []importTest{
+		{source: pigeonCalculator, want: `# {
+# package main
+#
+# func toInt(v interface{}) int { return v.(int) }
+# }
+
+# {
+#     return expr, nil
+# }
+Input   <- Expr EOF
+# {
+#     return eval(first, rest), nil
+# }
+Expr    <- _ Term (_ AddOp _ Term)* _
+Term    <- Factor (_ MulOp _ Factor)*
+Factor  <- "(" _ Expr _ ")" / Integer
+AddOp   <- "+" / "-"
+MulOp   <- "*" / "/"
+# { return strconv.Atoi(string(c.text)) }
+Integer <- "-"? [0-9]+
+_       <- [ \n\t\r]*
+EOF     <- !.
+`},
+		{source: pigeonCalculator, opts: &ImportOptions{StripActions: true}, want: `Input   <- Expr EOF
+Expr    <- _ Term (_ AddOp _ Term)* _
+Term    <- Factor (_ MulOp _ Factor)*
+Factor  <- "(" _ Expr _ ")" / Integer
+AddOp   <- "+" / "-"
+MulOp   <- "*" / "/"
+Integer <- "-"? [0-9]+
+_       <- [ \n\t\r]*
+EOF     <- !.
+`},
+		{source: "Ident ← [\\pL_]i [\\pL\\p{Nd}_\\]-]* !\"\\x00\"+ &'\\''; Raw = `\\d`i ( [] / [^] ) Ünïcode\n" +
+			"Ünïcode <- \"é\"i \"\" [\\u00e0-\\U0001F600]\n" +
+			"INDENT <- &{ return true, nil } #{ return nil } !{ return false, nil } Ident / %{fail} //{fail} Ident\n" +
+			"Bad <- Missing Missing", opts: &ImportOptions{StripActions: true},
+			want: `Ident   <- [\p{L}_]i [\p{L}\p{Nd}_\]\-]* !("\x00"+) &"'"
+Raw     <- "\\d"i (&. !. / .) _n_code
+_n_code <- "é"i .{0} [à-\uffff𐀀-😀]
+INDENT_ <- Ident / &. !.
+Bad     <- &. !. &. !.
+`, warnings: `INDENT_: the semantic predicate &{ return true, nil } cannot be converted and was dropped
+INDENT_: the state block #{ return nil } cannot be converted and was dropped
+INDENT_: the semantic predicate !{ return false, nil } cannot be converted and was dropped
+INDENT_: the throw expression %{fail} cannot be converted and was replaced by a term that never matches
+INDENT_: the recovery expression //{fail} cannot be converted and was dropped
+Bad: the rule Missing is not defined and was replaced by a term that never matches`},
+		// The recovery expression binds more loosely than the choice, so
+		// the whole choice after //{...} is dropped.
+		{source: "A <- 'a' //{x} 'c' / 'd'\n" +
+			"B <- ( 'a' / 'b' //{x, y} 'c' / 'd' //{z} 'e' ) 'f'",
+			want: `A <- "a"
+B <- ("a" / "b") "f"
+`, warnings: `A: the recovery expression //{x} cannot be converted and was dropped
+B: the recovery expression //{x, y} cannot be converted and was dropped
+B: the recovery expression //{z} cannot be converted and was dropped`},
+	})
+}
+
+func TestFromPigeonParse(t *testing.T) {
+	source, _, err := FromPigeon(pigeonCalculator, nil)
+	if err != nil {
+		t.Fatalf("FromPigeon returns error %s", err)
+	}
+	g, err := parser2.New(source, nil)
+	if err != nil {
+		t.Fatalf("New(%q) returns error %s", source, err)
+	}
+	for _, input := range []string{"1", "1 + 2 * (3 - -4)"} {
+		if _, err := g.Parse(input); err != nil {
+			t.Errorf("Parse(%q) returns error %s", input, err)
+		}
+	}
+	for _, input := range []string{"1 +", "(1"} {
+		if _, err := g.Parse(input); err == nil {
+			t.Errorf("Parse(%q) returns no error, want error", input)
+		}
+	}
+}
+
+func TestFromPointlander(t *testing.T) {
+	runImportTests(t, "FromPointlander", FromPointlander, []importTest{
+		{source: `package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type Calculator Peg {
+	*Tree
+}
+
+e <- sp e1 !.
+e1 <- e2 ( add e2 { p.AddOperator(TypeAdd) }
+         / minus e2 { p.AddOperator(TypeSubtract) }
+         )*
+e2 <- value / open e1 close
+value <- < [0-9]+ > sp { p.AddValue(buffer[begin:end]) }
+add <- '+' sp
+minus <- '-' sp
+open <- '(' sp
+close <- ')' sp
+sp <- ( ' ' / '\t' )*
+
+%%
+func main() {
+	fmt.Println(strconv.Itoa(1))
+}
+`, want: `# package main
+#
+# import (
+# 	"fmt"
+# 	"strconv"
+# )
+#
+# type Calculator Peg {
+# 	*Tree
+# }
+
+e     <- sp e1 !.
+# { p.AddOperator(TypeAdd) }
+# { p.AddOperator(TypeSubtract) }
+e1    <- e2 (add e2 / minus e2)*
+e2    <- value / open e1 close
+# { p.AddValue(buffer[begin:end]) }
+value <- <[0-9]+> sp
+add   <- "+" sp
+minus <- "-" sp
+open  <- "(" sp
+close <- ")" sp
+sp    <- (" " / "\t")*
+
+# func main() {
+# 	fmt.Println(strconv.Itoa(1))
+# }
+`},
+		{source: `package p
+type P Peg {}
+keyword <- "if\t\0x41\101" [[a-z\-]] [^\]\e] &{ p.ok() } sp
+sp <- ' ' / '\'' /
+`, opts: &ImportOptions{StripActions: true}, want: `keyword <- "if\tAA"i [a-z\-]i [^\]\x1b] sp
+sp      <- (" " / "'")?
+`, warnings: `keyword: the semantic predicate &{ p.ok() } cannot be converted and was dropped`},
+	})
+}
+
+func TestImportErrors(t *testing.T) {
+	for _, tt := range []struct {
+		convert func(string, *ImportOptions) (string, []*Warning, error)
+		source  string
+	}{
+		{FromPigeon, ""},
+		{FromPigeon, "A <- 'a'\nA <- 'b'"},
+		{FromPigeon, "A <- ( 'a'"},
+		{FromPigeon, "A <- [\\p{Klingon}]"},
+		{FromPigeon, "A <- [z-a]"},
+		{FromPointlander, "A <- '\\z'"},
+		{FromPointlander, "A <- { p.x() "},
+	} {
+		if got, _, err := tt.convert(tt.source, nil); err == nil {
+			t.Errorf("convert(%q) returns %q, want error", tt.source, got)
+		}
+	}
+}
+
+// TestTestdata checks that all rules of the grammars used in the tests are
+// converted without warnings.
+func TestTestdata(t *testing.T) {
+	dirname := runfiles.Path("github.com/salikh/peg/tests/testdata")
+	names, err := filepath.Glob(filepath.Join(dirname, "*.g"))
+	if err != nil || len(names) == 0 {
+		t.Fatalf("Cannot list testdata: %v", err)
+	}
+	for _, name := range names {
+		source, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Error reading %q: %s", name, err)
+		}
+		g, err := parser2.ParseGrammar(string(source))
+		if err != nil {
+			t.Errorf("%s: ParseGrammar returns error %s", name, err)
+			continue
+		}
+		for _, target := range []struct {
+			name string
+			// prefix is the prefix of the rule definitions.
+			prefix  func(rule string) string
+			convert func(*parser2.Grammar) (string, []*Warning)
+		}{
+			{"EBNF", func(rule string) string { return rule + " " }, EBNF},
+			{"ABNF", func(rule string) string { return strings.Replace(rule, "_", "ws", 1) + " " }, ABNF},
+			{"TreeSitter", func(rule string) string { return "    " + rule + ": $ => " }, func(g *parser2.Grammar) (string, []*Warning) {
+				return TreeSitter(g, "test")
+			}},
+		} {
+			got, warnings := target.convert(g)
+			for _, w := range warnings {
+				if target.name == "TreeSitter" && w.Rule == "_" {
+					// The whitespace rules match empty input.
+					continue
+				}
+				t.Errorf("%s: %s returns warning %s", filepath.Base(name), target.name, w)
+			}
+			for _, rule := range g.RuleNames {
+				if !strings.Contains(got, fmt.Sprintf("\n%s", target.prefix(rule))) &&
+					!strings.HasPrefix(got, target.prefix(rule)) {
+					t.Errorf("%s: %s has no rule %s:\n%s", filepath.Base(name), target.name, rule, got)
+				}
+			}
+		}
+		// The ABNF converted back to PEG is a valid grammar.
+		abnf, _ := ABNF(g)
+		peg, warnings, err := FromABNF(abnf)
+		if err != nil {
+			t.Errorf("%s: FromABNF returns error %s:\n%s", filepath.Base(name), err, abnf)
+			continue
+		}
+		for _, w := range warnings {
+			t.Errorf("%s: FromABNF returns warning %s", filepath.Base(name), w)
+		}
+		if _, err := parser2.New(peg, nil); err != nil {
+			t.Errorf("%s: FromABNF returns grammar with error %s:\n%s", filepath.Base(name), err, peg)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// The precedence levels of the expressions, used to decide where the
+// parentheses are needed.
+const (
+	choiceLevel = iota
+	seqLevel
+	primaryLevel
+)
+
+// EBNF converts the grammar to the EBNF notation of the W3C XML
+// specification:
+//
+//	Expr ::= Term ( "+" Term )*
+//	Term ::= [0-9]+
+//
+// The bounded repetitions are expanded, the %skip directive is applied to
+// the rules, and the precedence tables are converted to the operands
+// separated by the operators.
+func EBNF(g *parser2.Grammar) (string, []*Warning) {
+	b := &builder{target: "EBNF"}
+	names, exprs := prepare(g, b)
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	var r strings.Builder
+	for _, name := range names {
+		s, _ := ebnfExpr(expandRepeat(exprs[name]))
+		fmt.Fprintf(&r, "%-*s ::= %s\n", width, name, s)
+	}
+	return r.String(), b.warnings
+}
+
+// paren returns s in parentheses if its precedence level is lower than
+// the level required by the context.
+func paren(s string, level, want int) string {
+	if level < want {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// ebnfExpr returns the EBNF of the expression and its precedence level.
+func ebnfExpr(e *expr) (string, int) {
+	switch e.kind {
+	case seqExpr:
+		if len(e.items) == 0 {
+			return `""`, primaryLevel
+		}
+		var parts []string
+		for _, item := range e.items {
+			s, level := ebnfExpr(item)
+			parts = append(parts, paren(s, level, seqLevel))
+		}
+		return strings.Join(parts, " "), seqLevel
+	case choiceExpr:
+		var parts []string
+		for _, item := range e.items {
+			s, _ := ebnfExpr(item)
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " | "), choiceLevel
+	case repeatExpr:
+		s, level := ebnfExpr(e.items[0])
+		s = paren(s, level, primaryLevel)
+		switch {
+		case e.min == 0 && e.max == 1:
+			return s + "?", primaryLevel
+		case e.min == 0:
+			return s + "*", primaryLevel
+		}
+		return s + "+", primaryLevel
+	case litExpr:
+		return ebnfLiteral(e.lit, e.fold)
+	case setExpr:
+		return ebnfSet(e.set), primaryLevel
+	}
+	return e.ref, primaryLevel
+}
+
+// ebnfLiteral returns the literal as a sequence of quoted strings,
+// hexadecimal characters #xN and, for the case-insensitive letters,
+// character classes.
+func ebnfLiteral(lit string, fold bool) (string, int) {
+	var parts []string
+	var run strings.Builder
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		q := `"`
+		if strings.Contains(run.String(), `"`) {
+			q = "'"
+		}
+		parts = append(parts, q+run.String()+q)
+		run.Reset()
+	}
+	for _, c := range lit {
+		if fold {
+			if set := foldSet(c); len(set) > 1 || set[0].Lo != set[0].Hi {
+				flush()
+				parts = append(parts, ebnfSet(set))
+				continue
+			}
+		}
+		if !unicode.IsPrint(c) {
+			flush()
+			parts = append(parts, ebnfChar(c))
+			continue
+		}
+		if c == '"' && strings.Contains(run.String(), "'") || c == '\'' && strings.Contains(run.String(), `"`) {
+			flush()
+		}
+		run.WriteRune(c)
+	}
+	flush()
+	if len(parts) == 1 {
+		return parts[0], primaryLevel
+	}
+	return strings.Join(parts, " "), seqLevel
+}
+
+// ebnfChar returns the character as it is written in the character classes.
+func ebnfChar(c rune) string {
+	if c > ' ' && c < unicode.MaxASCII && !strings.ContainsRune(`#-[\]^`, c) {
+		return string(c)
+	}
+	return fmt.Sprintf("#x%X", c)
+}
+
+// ebnfSet returns the character class [...] or [^...] of the set,
+// whichever is shorter.
+func ebnfSet(set charclass.Set) string {
+	neg := ""
+	if comp := set.Complement(); len(set) == 0 || len(comp) > 0 && len(comp) < len(set) {
+		neg, set = "^", comp
+	}
+	var r strings.Builder
+	r.WriteString("[" + neg)
+	for _, iv := range set {
+		r.WriteString(ebnfChar(iv.Lo))
+		switch {
+		case iv.Hi == iv.Lo+1:
+			r.WriteString(ebnfChar(iv.Hi))
+		case iv.Hi != iv.Lo:
+			r.WriteString("-" + ebnfChar(iv.Hi))
+		}
+	}
+	r.WriteString("]")
+	return r.String()
+}
@@ -0,0 +1,454 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/salikh/peg/parser"
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// abnfSource is the grammar of ABNF documents of RFC 5234, with the
+// case-sensitive strings %s"..." of RFC 7405.
+var abnfSource = `
+RuleList <- (Rule / Empty)* !.
+Rule <- RuleName DefinedAs Alternation CWsp* End
+keep RuleName <- <[a-zA-Z] [a-zA-Z0-9-]*>
+DefinedAs <- CWsp* <"=/" / "="> CWsp*
+Alternation <- Concatenation (CWsp* "/" CWsp* Concatenation)*
+Concatenation <- Repetition (CWsp+ Repetition)*
+Repetition <- Repeat? (RuleRef / Group / Option / CharVal / NumVal / ProseVal)
+Repeat <- <[0-9]* "*" [0-9]* / [0-9]+>
+RuleRef <- <[a-zA-Z] [a-zA-Z0-9-]*>
+Group <- "(" CWsp* Alternation CWsp* ")"
+Option <- "[" CWsp* Alternation CWsp* "]"
+CharVal <- <("%" [sSiI])? ["] [^"\r\n]* ["]>
+NumVal <- "%" <[bBdDxX] [0-9a-fA-F]+ (("." [0-9a-fA-F]+)+ / "-" [0-9a-fA-F]+)?>
+keep ProseVal <- "<" <[^>\r\n]*> ">"
+drop Empty <- [ \t]* NL / [ \t]* Comment !. / [ \t]+ !.
+drop CWsp <- [ \t] / NL [ \t]
+drop NL <- Comment? ("\r\n" / "\n")
+drop End <- NL / Comment? !.
+drop Comment <- ";" [^\r\n]*
+`
+
+var (
+	abnfOnce    sync.Once
+	abnfGrammar *parser2.Grammar
+	abnfErr     error
+)
+
+// coreRules are the expressions of the core rules of RFC 5234, which are
+// used by the ABNF documents without definitions.
+var coreRules = map[string]*expr{
+	"ALPHA":  {kind: setExpr, set: charclass.Set{{Lo: 'A', Hi: 'Z'}, {Lo: 'a', Hi: 'z'}}},
+	"BIT":    {kind: setExpr, set: charclass.Set{{Lo: '0', Hi: '1'}}},
+	"CHAR":   {kind: setExpr, set: charclass.Set{{Lo: 0x01, Hi: 0x7f}}},
+	"CR":     {kind: litExpr, lit: "\r"},
+	"CRLF":   {kind: litExpr, lit: "\r\n"},
+	"CTL":    {kind: setExpr, set: charclass.Set{{Lo: 0x00, Hi: 0x1f}, {Lo: 0x7f, Hi: 0x7f}}},
+	"DIGIT":  {kind: setExpr, set: charclass.Set{{Lo: '0', Hi: '9'}}},
+	"DQUOTE": {kind: litExpr, lit: `"`},
+	"HEXDIG": {kind: setExpr, set: charclass.Set{{Lo: '0', Hi: '9'}, {Lo: 'A', Hi: 'F'}, {Lo: 'a', Hi: 'f'}}},
+	"HTAB":   {kind: litExpr, lit: "\t"},
+	"LF":     {kind: litExpr, lit: "\n"},
+	"LWSP": repeat(&expr{kind: choiceExpr, items: []*expr{
+		{kind: setExpr, set: charclass.Set{{Lo: '\t', Hi: '\t'}, {Lo: ' ', Hi: ' '}}},
+		seq(&expr{kind: litExpr, lit: "\r\n"}, &expr{kind: setExpr, set: charclass.Set{{Lo: '\t', Hi: '\t'}, {Lo: ' ', Hi: ' '}}}),
+	}}, 0, -1),
+	"OCTET": {kind: setExpr, set: charclass.Set{{Lo: 0x00, Hi: 0xff}}},
+	"SP":    {kind: litExpr, lit: " "},
+	"VCHAR": {kind: setExpr, set: charclass.Set{{Lo: 0x21, Hi: 0x7e}}},
+	"WSP":   {kind: setExpr, set: charclass.Set{{Lo: '\t', Hi: '\t'}, {Lo: ' ', Hi: ' '}}},
+}
+
+// FromABNF converts the ABNF document of RFC 5234 to the source of the
+// equivalent PEG grammar. The first rule of the document is the top rule.
+//
+// The alternations become ordered choices. Since an ordered choice stops
+// at the first matching alternative and a repetition never gives back what
+// it matched, the grammar may reject the inputs that the ABNF document
+// accepts, e.g. with "a" / "ab" or *DIGIT DIGIT. Such places are reported
+// as warnings, and can be fixed by reordering the alternatives. The
+// rule names are converted to PEG identifiers, e.g. ip-address becomes
+// ip_address. The character codes %x41 and the ranges %x41-5A become char
+// classes, the repetitions n*m become bounded repetitions {n,m}, and the
+// references to the core rules, such as ALPHA and DIGIT, are replaced with
+// their definitions unless the document defines them. The strings are
+// case-insensitive as in ABNF, unless they are written as %s"...". The
+// prose <...> cannot be converted and is replaced by a term that never
+// matches.
+func FromABNF(source string) (string, []*Warning, error) {
+	abnfOnce.Do(func() {
+		abnfGrammar, abnfErr = parser2.New(abnfSource, &parser2.ParserOptions{SkipEmptyNodes: true})
+	})
+	if abnfErr != nil {
+		return "", nil, fmt.Errorf("error in the ABNF grammar: %s", abnfErr)
+	}
+	result, err := abnfGrammar.Parse(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("error parsing ABNF: %s", err)
+	}
+	result.ComputeContent()
+	c := &abnfConverter{
+		builder: &builder{target: "PEG"},
+		names:   make(map[string]string),
+		rules:   make(map[string][]*parser.Node),
+	}
+	// The rule names are case-insensitive, so the references are resolved
+	// after all rules are known.
+	var order []string
+	for _, n := range result.Tree.Children {
+		name := n.Children[0].Text
+		key := strings.ToLower(name)
+		if _, ok := c.names[key]; !ok {
+			c.names[key] = pegIdent(name)
+			order = append(order, key)
+		} else if n.Children[1].Text == "=" {
+			return "", nil, fmt.Errorf("%d:%d: rule %s is already defined", n.Row, n.Col, name)
+		}
+		c.rules[key] = append(c.rules[key], n.Children[2])
+	}
+	if len(order) == 0 {
+		return "", nil, fmt.Errorf("no rules in ABNF")
+	}
+	var names []string
+	exprs := make(map[string]*expr)
+	for _, key := range order {
+		name := c.names[key]
+		c.rule = name
+		alt := &expr{kind: choiceExpr}
+		// The incremental alternatives =/ are added to the alternatives of
+		// the rule.
+		for _, n := range c.rules[key] {
+			e := c.alternation(n)
+			if e.kind == choiceExpr {
+				alt.items = append(alt.items, e.items...)
+			} else {
+				alt.items = append(alt.items, e)
+			}
+		}
+		names = append(names, name)
+		exprs[name] = single(alt)
+	}
+	nullable := computeNullable(exprs)
+	for _, name := range names {
+		c.rule = name
+		c.check(exprs[name], nullable)
+	}
+	out, err := pegSource(names, exprs)
+	if err != nil {
+		return "", nil, err
+	}
+	return out, c.warnings, nil
+}
+
+type abnfConverter struct {
+	*builder
+	// names maps the lowercased ABNF rule names to the PEG rule names, and
+	// rules to the alternations of the definitions.
+	names map[string]string
+	rules map[string][]*parser.Node
+}
+
+// alternation converts the alternation. Since ABNF has no sets of
+// characters other than the ranges, the adjacent alternatives of single
+// characters are joined to a set, and the adjacent strings are joined to a
+// single literal.
+func (c *abnfConverter) alternation(n *parser.Node) *expr {
+	alt := &expr{kind: choiceExpr}
+	for _, ch := range n.Children {
+		s := seq()
+		for _, rep := range ch.Children {
+			e := c.repetition(rep)
+			items := []*expr{e}
+			if e.kind == seqExpr {
+				items = e.items
+			}
+			for _, item := range items {
+				if k := len(s.items) - 1; k >= 0 && item.kind == litExpr && s.items[k].kind == litExpr &&
+					item.fold == s.items[k].fold {
+					s.items[k] = &expr{kind: litExpr, lit: s.items[k].lit + item.lit, fold: item.fold}
+					continue
+				}
+				s.items = append(s.items, item)
+			}
+		}
+		e := single(s)
+		if k := len(alt.items) - 1; k >= 0 {
+			set, ok := charSet(e)
+			prev, prevOK := charSet(alt.items[k])
+			if ok && prevOK {
+				alt.items[k] = &expr{kind: setExpr, set: prev.Union(set)}
+				continue
+			}
+		}
+		alt.items = append(alt.items, e)
+	}
+	return single(alt)
+}
+
+// charSet returns the set of the characters matched by the expression, if
+// it matches a single character.
+func charSet(e *expr) (charclass.Set, bool) {
+	switch {
+	case e.kind == setExpr && len(e.set) > 0:
+		return e.set, true
+	case e.kind == litExpr && utf8.RuneCountInString(e.lit) == 1:
+		c, _ := utf8.DecodeRuneInString(e.lit)
+		if e.fold {
+			return foldSet(c), true
+		}
+		return charclass.Set{{Lo: c, Hi: c}}, true
+	}
+	return nil, false
+}
+
+func (c *abnfConverter) repetition(n *parser.Node) *expr {
+	if len(n.Children) == 1 {
+		return c.element(n.Children[0])
+	}
+	item := c.element(n.Children[1])
+	repeat := n.Children[0].Text
+	min, max := 0, -1
+	if i := strings.Index(repeat, "*"); i < 0 {
+		min, _ = strconv.Atoi(repeat)
+		max = min
+	} else {
+		if i > 0 {
+			min, _ = strconv.Atoi(repeat[:i])
+		}
+		if i < len(repeat)-1 {
+			max, _ = strconv.Atoi(repeat[i+1:])
+		}
+	}
+	if max >= 0 && max < min {
+		c.warn("the repetition %s has the maximum less than the minimum, the maximum is ignored", repeat)
+		max = min
+	}
+	if min == 1 && max == 1 {
+		return item
+	}
+	return &expr{kind: repeatExpr, items: []*expr{item}, min: min, max: max}
+}
+
+func (c *abnfConverter) element(n *parser.Node) *expr {
+	switch n.Label {
+	case "RuleRef":
+		key := strings.ToLower(n.Text)
+		if name, ok := c.names[key]; ok {
+			return ref(name)
+		}
+		if e, ok := coreRules[strings.ToUpper(n.Text)]; ok {
+			return e
+		}
+		c.warn("the rule %s is not defined", n.Text)
+		return ref(pegIdent(n.Text))
+	case "Group":
+		return c.alternation(n.Children[0])
+	case "Option":
+		return repeat(c.alternation(n.Children[0]), 0, 1)
+	case "CharVal":
+		text := n.Text
+		fold := true
+		if text[0] == '%' {
+			fold = text[1] == 'i' || text[1] == 'I'
+			text = text[2:]
+		}
+		if len(text) == 2 {
+			return seq()
+		}
+		lit := text[1 : len(text)-1]
+		return &expr{kind: litExpr, lit: lit, fold: fold && strings.IndexFunc(lit, unicode.IsLetter) >= 0}
+	case "NumVal":
+		return c.numVal(n.Text)
+	}
+	c.warn("the prose <%s> cannot be converted and was replaced by a term that never matches", n.Text)
+	return &expr{kind: setExpr}
+}
+
+// numVal converts the character codes b1.0, d65-90 or x41.42.
+func (c *abnfConverter) numVal(text string) *expr {
+	base := map[byte]int{'b': 2, 'd': 10, 'x': 16}[text[0]|0x20]
+	parse := func(s string) rune {
+		v, err := strconv.ParseInt(s, base, 32)
+		if err != nil || v > 0x10ffff {
+			c.warn("invalid character code %%%s", text)
+			return 0
+		}
+		return rune(v)
+	}
+	if i := strings.Index(text, "-"); i >= 0 {
+		lo, hi := parse(text[1:i]), parse(text[i+1:])
+		if hi < lo {
+			c.warn("the character range %%%s is empty", text)
+			return &expr{kind: setExpr}
+		}
+		return &expr{kind: setExpr, set: charclass.Set{{Lo: lo, Hi: hi}}}
+	}
+	var runes []rune
+	for _, s := range strings.Split(text[1:], ".") {
+		runes = append(runes, parse(s))
+	}
+	return &expr{kind: litExpr, lit: string(runes)}
+}
+
+// atom is a character set or a rule reference at a fixed position of the
+// inputs matched by an expression.
+type atom struct {
+	set charclass.Set
+	ref string
+}
+
+// covers reports whether the atom a matches everything the atom b matches.
+func (a atom) covers(b atom) bool {
+	if a.ref != "" || b.ref != "" {
+		return a.ref == b.ref
+	}
+	return len(b.set.Subtract(a.set)) == 0
+}
+
+// atoms returns the atoms at the beginning of every input matched by the
+// expression, and whether the expression matches exactly the sequence of
+// the atoms.
+func atoms(e *expr) ([]atom, bool) {
+	switch e.kind {
+	case litExpr:
+		var r []atom
+		for _, c := range e.lit {
+			if e.fold {
+				r = append(r, atom{set: foldSet(c)})
+			} else {
+				r = append(r, atom{set: charclass.Set{{Lo: c, Hi: c}}})
+			}
+		}
+		return r, true
+	case setExpr:
+		return []atom{{set: e.set}}, true
+	case refExpr:
+		return []atom{{ref: e.ref}}, true
+	case seqExpr:
+		var r []atom
+		for _, item := range e.items {
+			a, exact := atoms(item)
+			r = append(r, a...)
+			if !exact {
+				return r, false
+			}
+		}
+		return r, true
+	case repeatExpr:
+		a, exact := atoms(e.items[0])
+		if e.min == 0 {
+			return nil, false
+		}
+		if !exact || e.min != e.max {
+			return a, false
+		}
+		var r []atom
+		for i := 0; i < e.min; i++ {
+			r = append(r, a...)
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+// shadows reports whether the alternative a always matches before the
+// later alternative b, so that b never matches.
+func shadows(a, b *expr) bool {
+	itemsA, itemsB := []*expr{a}, []*expr{b}
+	if a.kind == seqExpr {
+		itemsA = a.items
+	}
+	if b.kind == seqExpr {
+		itemsB = b.items
+	}
+	if len(itemsB) > len(itemsA) {
+		// An alternative that starts with the earlier alternative.
+		prefix := true
+		for i, item := range itemsA {
+			sa, _ := pegExpr(item)
+			sb, _ := pegExpr(itemsB[i])
+			prefix = prefix && sa == sb
+		}
+		if prefix {
+			return true
+		}
+	}
+	atomsA, exact := atoms(a)
+	atomsB, _ := atoms(b)
+	for _, x := range atomsB {
+		if x.ref == "" && len(x.set) == 0 {
+			// The alternative b never matches anyway.
+			return false
+		}
+	}
+	if !exact || len(atomsA) == 0 || len(atomsB) < len(atomsA) {
+		return false
+	}
+	for i, x := range atomsA {
+		if !x.covers(atomsB[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// check reports the ordered choices and the repetitions that reject some
+// inputs that the ABNF alternations and repetitions accept.
+func (c *abnfConverter) check(e *expr, nullable map[string]bool) {
+	switch e.kind {
+	case choiceExpr:
+		for j, b := range e.items {
+			sb, _ := pegExpr(b)
+			for _, a := range e.items[:j] {
+				if shadows(a, b) {
+					sa, _ := pegExpr(a)
+					c.warn("the alternative %s never matches, since the earlier alternative %s matches first; "+
+						"consider reordering them", sb, sa)
+					break
+				}
+			}
+			if j < len(e.items)-1 && isNullable(b, nullable) {
+				c.warn("the alternative %s matches empty input, so the later alternatives are never tried", sb)
+				break
+			}
+		}
+	case seqExpr:
+		for i, item := range e.items {
+			if i == len(e.items)-1 || item.kind != repeatExpr || item.min == item.max {
+				continue
+			}
+			repeated, exact := atoms(item.items[0])
+			next, _ := atoms(e.items[i+1])
+			if exact && len(repeated) == 1 && len(next) > 0 && repeated[0].covers(next[0]) {
+				s, _ := pegExpr(item)
+				sn, _ := pegExpr(e.items[i+1])
+				c.warn("the repetition %s never leaves input for the following %s, since PEG repetitions are greedy", s, sn)
+			}
+		}
+	}
+	for _, item := range e.items {
+		c.check(item, nullable)
+	}
+}
@@ -0,0 +1,646 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/salikh/peg/parser"
+	"github.com/salikh/peg/parser2"
+)
+
+// The grammars of the grammar files of pigeon and pointlander/peg produce
+// the syntax trees of the same shape, which are converted by the importer.
+// The Go code of the actions and the headers is captured as a whole, with
+// the nested braces, strings and comments.
+const goCodeSource = `
+drop Code <- "{" (Code / String / GoComment / [^{}"'\x60/] / "/")* "}"
+drop String <- ["] ("\\" . / [^"\\\n])* ["] / ['] ("\\" . / [^'\\\n])* ['] / [\x60] [^\x60]* [\x60]
+drop GoComment <- "//" [^\n]* / "/*" (!"*/" .)* "*/"
+`
+
+// pigeonSource is the grammar of the grammar files of pigeon
+// (github.com/mna/pigeon).
+var pigeonSource = `
+Grammar <- _ Header? Rule* _ !.
+Header <- <Code>
+Rule <- _ RuleName _ (DisplayName _)? Arrow Expr
+keep RuleName <- <Ident>
+drop DisplayName <- String
+drop Arrow <- "<-" / "←" / "⟵" / "="
+Expr <- Choice (_ Recover Choice)*
+Recover <- "//{" <[^}]*> "}"
+Choice <- Alt (_ "/" Alt)*
+Alt <- Item+
+inline Item <- _ (Label _ ":" _)? (SemPred / State / Throw / Action / Pred / Repeat / Primary)
+drop Label <- Ident
+Pred <- <[&!]> _ (Repeat / Primary)
+Repeat <- Primary [ \t]* <[?*+]>
+inline Primary <- RuleRef / Group / Lit / Class / Dot
+RuleRef <- <Ident> !(_ (DisplayName _)? Arrow)
+Group <- "(" Expr _ ")"
+Lit <- <String "i"?>
+Class <- <"[" ("\\" . / [^\]\\\n])* "]" "i"?>
+Dot <- <".">
+Action <- <Code>
+SemPred <- <[&!] Code>
+State <- <"#" Code>
+Throw <- "%{" <Ident> "}"
+drop Ident <- [\p{L}_] [\p{L}\p{Nd}_]*
+drop _ <- ([ \t\r\n;] / !"//{" GoComment)*
+` + goCodeSource
+
+// pointlanderSource is the grammar of the grammar files of pointlander/peg
+// (github.com/pointlander/peg).
+var pointlanderSource = `
+Grammar <- _ Header? Rule+ _ Trailer? !.
+Header <- <"package" [ \t]+ Ident (_ Import)* _ "type" [ \t]+ Ident [ \t]+ "Peg" _ Code>
+drop Import <- "import" _ ((Ident [ \t]+)? String / "(" (_ (Ident [ \t]+)? String)* _ ")")
+Rule <- _ RuleName _ "<-" Choice
+keep RuleName <- <Ident>
+Choice <- Alt (_ "/" Alt)*
+keep Alt <- Item*
+inline Item <- _ (SemPred / Action / Pred / Repeat / Primary)
+Pred <- <[&!]> _ (Repeat / Primary)
+Repeat <- Primary [ \t]* <[?*+]>
+inline Primary <- RuleRef / Group / Capture / Lit / Class / Dot
+RuleRef <- <Ident> !(_ "<-")
+Group <- "(" Choice _ ")"
+Capture <- "<" Choice _ ">"
+Lit <- <['] ("\\" . / [^'\\])* ['] / ["] ("\\" . / [^"\\])* ["]>
+Class <- <"[[" ("\\" . / !"]]" .)* "]]" / "[" ("\\" . / [^\]\\])* "]">
+Dot <- <".">
+Action <- <Code>
+SemPred <- <[&!] Code>
+Trailer <- "%%" <.*>
+drop Ident <- [a-zA-Z_] [a-zA-Z0-9_]*
+drop _ <- ([ \t\r\n] / "#" [^\n]*)*
+` + goCodeSource
+
+var (
+	pigeonOnce         sync.Once
+	pigeonGrammar      *parser2.Grammar
+	pigeonErr          error
+	pointlanderOnce    sync.Once
+	pointlanderGrammar *parser2.Grammar
+	pointlanderErr     error
+)
+
+// ImportOptions control the conversion of the grammars of other PEG
+// parser generators.
+type ImportOptions struct {
+	// StripActions removes the Go code of the grammar, such as the actions
+	// and the package header, instead of keeping it in # comments before
+	// the rules.
+	StripActions bool
+}
+
+// FromPigeon converts the grammar of the pigeon parser generator
+// (github.com/mna/pigeon) to the source of the equivalent PEG grammar.
+// The literals, char classes, predicates and repetitions are translated to
+// the syntax of this package, e.g. [\pL_]i becomes [\p{L}_]i. The labels
+// are removed, and the code blocks, i.e. the initializer, the actions
+// { ... }, the semantic predicates &{ ... } and !{ ... } and the state
+// blocks #{ ... }, are kept as comments before the rules unless
+// opts.StripActions is set. The semantic predicates, state blocks, throw
+// expressions %{label} and recovery expressions //{label} cannot be
+// converted, and are reported as warnings.
+func FromPigeon(source string, opts *ImportOptions) (string, []*Warning, error) {
+	pigeonOnce.Do(func() {
+		pigeonGrammar, pigeonErr = parser2.New(pigeonSource, &parser2.ParserOptions{SkipEmptyNodes: true})
+	})
+	if pigeonErr != nil {
+		return "", nil, fmt.Errorf("error in the pigeon grammar: %s", pigeonErr)
+	}
+	return importGrammar(pigeonGrammar, "pigeon", source, opts, &importer{
+		literal: pigeonLiteral,
+		class:   pigeonClass,
+	})
+}
+
+// FromPointlander converts the grammar of the peg parser generator
+// github.com/pointlander/peg to the source of the equivalent PEG grammar.
+// The literals, char classes, predicates, repetitions and captures < ... >
+// are translated to the syntax of this package. The double-quoted literals
+// and the double-bracketed char classes [[...]] of pointlander/peg are case
+// insensitive, and get the suffix i. The Go code, i.e. the package header,
+// the actions { ... }, the semantic predicates &{ ... } and the code after
+// %%, is kept as comments unless opts.StripActions is set. The semantic
+// predicates cannot be converted, and are reported as warnings.
+func FromPointlander(source string, opts *ImportOptions) (string, []*Warning, error) {
+	pointlanderOnce.Do(func() {
+		pointlanderGrammar, pointlanderErr = parser2.New(pointlanderSource, &parser2.ParserOptions{SkipEmptyNodes: true})
+	})
+	if pointlanderErr != nil {
+		return "", nil, fmt.Errorf("error in the pointlander/peg grammar: %s", pointlanderErr)
+	}
+	return importGrammar(pointlanderGrammar, "pointlander/peg", source, opts, &importer{
+		literal: pointlanderLiteral,
+		class:   pointlanderClass,
+	})
+}
+
+// importer converts the syntax trees of the grammars of other PEG parser
+// generators to the PEG source.
+type importer struct {
+	*builder
+	// literal returns the value of a literal and whether it is case
+	// insensitive, and class converts a char class.
+	literal func(text string) (string, bool, error)
+	class   func(text string) (string, int, error)
+	opts    *ImportOptions
+	// names maps the rule names of the grammar to the PEG rule names, and
+	// undefined is the set of the reported undefined rules.
+	names     map[string]string
+	undefined map[string]bool
+	// comments are the code blocks of the current rule.
+	comments []string
+	err      error
+}
+
+func importGrammar(g *parser2.Grammar, tool, source string, opts *ImportOptions, c *importer) (string, []*Warning, error) {
+	result, err := g.Parse(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("error parsing %s grammar: %s", tool, err)
+	}
+	result.ComputeContent()
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	c.builder = &builder{target: "PEG"}
+	c.opts = opts
+	c.names = make(map[string]string)
+	c.undefined = make(map[string]bool)
+	var rules []*parser.Node
+	for _, n := range result.Tree.Children {
+		if n.Label != "Rule" {
+			continue
+		}
+		name := n.Children[0].Text
+		if _, ok := c.names[name]; ok {
+			return "", nil, fmt.Errorf("%d:%d: rule %s is already defined", n.Row, n.Col, name)
+		}
+		c.names[name] = pegIdent(name)
+		rules = append(rules, n)
+	}
+	if len(rules) == 0 {
+		return "", nil, fmt.Errorf("no rules in %s grammar", tool)
+	}
+	var r strings.Builder
+	for _, n := range result.Tree.Children {
+		switch n.Label {
+		case "Header":
+			// The code before the first rule and after the last rule is
+			// written in separate blocks.
+			if !c.opts.StripActions {
+				writeComment(&r, n.Text)
+				r.WriteString("\n")
+			}
+		case "Trailer":
+			if !c.opts.StripActions {
+				r.WriteString("\n")
+				writeComment(&r, strings.TrimSpace(n.Text))
+			}
+		default:
+			c.rule = c.names[n.Children[0].Text]
+			s, _ := c.expr(n.Children[1])
+			if c.err != nil {
+				return "", nil, c.err
+			}
+			for _, code := range c.comments {
+				writeComment(&r, code)
+			}
+			c.comments = nil
+			fmt.Fprintf(&r, "%s <- %s\n", c.rule, s)
+		}
+	}
+	out, err := parser2.Format(r.String())
+	if err != nil {
+		return "", nil, err
+	}
+	return out, c.warnings, nil
+}
+
+// writeComment writes the code as # comments.
+func writeComment(w *strings.Builder, code string) {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			w.WriteString("#\n")
+			continue
+		}
+		w.WriteString("# " + line + "\n")
+	}
+}
+
+// code records the code block to be written as a comment.
+func (c *importer) code(text string) {
+	if !c.opts.StripActions {
+		c.comments = append(c.comments, text)
+	}
+}
+
+// shortCode returns the code on a single line, shortened for the warnings.
+func shortCode(code string) string {
+	s := strings.Join(strings.Fields(code), " ")
+	if r := []rune(s); len(r) > 30 {
+		s = string(r[:27]) + "..."
+	}
+	return s
+}
+
+// expr converts the expression of a rule or a group, and returns the PEG
+// and its precedence level. In pigeon grammars, the expression may be
+// a choice followed by the recovery expressions //{labels} with their own
+// choices, which bind more loosely than the alternatives.
+func (c *importer) expr(n *parser.Node) (string, int) {
+	if n.Label != "Expr" {
+		return c.choice(n)
+	}
+	s, level := c.choice(n.Children[0])
+	for _, ch := range n.Children {
+		if ch.Label == "Recover" {
+			// The recovery expression is tried only if the labels are
+			// thrown, so the grammar is the same without it.
+			c.warn("the recovery expression //{%s} cannot be converted and was dropped", ch.Text)
+		}
+	}
+	return s, level
+}
+
+// choice converts the alternatives, and returns the PEG and its
+// precedence level.
+func (c *importer) choice(n *parser.Node) (string, int) {
+	var parts []string
+	level := choiceLevel
+	empty := false
+	for i := 0; i < len(n.Children) && !empty; i++ {
+		ch := n.Children[i]
+		switch {
+		case len(ch.Children) == 0:
+			// The empty alternative always matches, so the alternatives after
+			// it are never tried.
+			empty = true
+		default:
+			var s string
+			s, level = c.seq(ch)
+			parts = append(parts, s)
+		}
+	}
+	s := strings.Join(parts, " / ")
+	if len(parts) != 1 {
+		level = choiceLevel
+	}
+	switch {
+	case len(parts) == 0:
+		return ".{0}", primaryLevel
+	case empty:
+		return paren(s, level, primaryLevel) + "?", seqLevel
+	}
+	return s, level
+}
+
+// seq converts the items of an alternative.
+func (c *importer) seq(n *parser.Node) (string, int) {
+	var parts []string
+	var last string
+	level := primaryLevel
+	for _, ch := range n.Children {
+		s, l, ok := c.item(ch)
+		if !ok {
+			continue
+		}
+		parts = append(parts, paren(s, l, seqLevel))
+		last, level = s, l
+	}
+	switch len(parts) {
+	case 0:
+		return ".{0}", primaryLevel
+	case 1:
+		return last, level
+	}
+	return strings.Join(parts, " "), seqLevel
+}
+
+// item converts the item of an alternative, and returns false if the item
+// is dropped. The predicates and the repetitions are returned at the
+// sequence level, so that they are put in parentheses when they are
+// operands of the other predicates or repetitions.
+func (c *importer) item(n *parser.Node) (string, int, bool) {
+	switch n.Label {
+	case "RuleRef":
+		if name, ok := c.names[n.Text]; ok {
+			return name, primaryLevel, true
+		}
+		if !c.undefined[n.Text] {
+			c.warn("the rule %s is not defined and was replaced by a term that never matches", n.Text)
+			c.undefined[n.Text] = true
+		}
+		return "&. !.", seqLevel, true
+	case "Group":
+		s, level := c.expr(n.Children[0])
+		return s, level, true
+	case "Capture":
+		s, _ := c.choice(n.Children[0])
+		return "<" + s + ">", primaryLevel, true
+	case "Lit":
+		lit, fold, err := c.literal(n.Text)
+		if err != nil {
+			c.fail(n, "invalid literal %s: %s", n.Text, err)
+			return "", primaryLevel, false
+		}
+		if lit == "" {
+			return ".{0}", primaryLevel, true
+		}
+		s := strconv.Quote(lit)
+		if fold && strings.IndexFunc(lit, unicode.IsLetter) >= 0 {
+			s += "i"
+		}
+		return s, primaryLevel, true
+	case "Class":
+		s, level, err := c.class(n.Text)
+		if err != nil {
+			c.fail(n, "invalid char class %s: %s", n.Text, err)
+			return "", primaryLevel, false
+		}
+		return s, level, true
+	case "Dot":
+		return ".", primaryLevel, true
+	case "Pred":
+		s, level, ok := c.item(n.Children[0])
+		if !ok {
+			return "", primaryLevel, false
+		}
+		return n.Text + paren(s, level, primaryLevel), seqLevel, true
+	case "Repeat":
+		s, level, ok := c.item(n.Children[0])
+		if !ok {
+			return "", primaryLevel, false
+		}
+		return paren(s, level, primaryLevel) + n.Text, seqLevel, true
+	case "Action":
+		c.code(n.Text)
+		return "", primaryLevel, false
+	case "SemPred":
+		c.warn("the semantic predicate %s cannot be converted and was dropped", shortCode(n.Text))
+		c.code(n.Text)
+		return "", primaryLevel, false
+	case "State":
+		c.warn("the state block %s cannot be converted and was dropped", shortCode(n.Text))
+		c.code(n.Text)
+		return "", primaryLevel, false
+	case "Throw":
+		c.warn("the throw expression %%{%s} cannot be converted and was replaced by a term that never matches", n.Text)
+		return "&. !.", seqLevel, true
+	}
+	c.fail(n, "unexpected %s", n.Label)
+	return "", primaryLevel, false
+}
+
+// fail records the first error of the conversion.
+func (c *importer) fail(n *parser.Node, format string, args ...interface{}) {
+	if c.err == nil {
+		c.err = fmt.Errorf("%d:%d: %s", n.Row, n.Col, fmt.Sprintf(format, args...))
+	}
+}
+
+// classItem is a character, a range of characters or a Unicode class
+// \p{Name} of a char class.
+type classItem struct {
+	lo, hi rune
+	// class is the name of the Unicode category, script or property, and
+	// negated is set for \P{Name}.
+	class   string
+	negated bool
+}
+
+// parseClass parses the items of a char class, reading the escapes with
+// the function escape. The Unicode classes are written as \pL or \p{Name}.
+func parseClass(s string, escape func(string) (rune, int, error)) ([]classItem, error) {
+	char := func() (rune, error) {
+		if s[0] == '\\' {
+			c, n, err := escape(s)
+			if err != nil {
+				return 0, err
+			}
+			s = s[n:]
+			return c, nil
+		}
+		c, n := utf8.DecodeRuneInString(s)
+		s = s[n:]
+		return c, nil
+	}
+	var items []classItem
+	for len(s) > 0 {
+		if strings.HasPrefix(s, `\p`) || strings.HasPrefix(s, `\P`) {
+			name, n := s[2:3], 3
+			if strings.HasPrefix(s[2:], "{") {
+				end := strings.Index(s, "}")
+				if end < 0 {
+					return nil, fmt.Errorf("missing } in %q", s)
+				}
+				name, n = s[3:end], end+1
+			}
+			if _, ok := unicodeTable(name); !ok {
+				return nil, fmt.Errorf("unknown Unicode class %q", name)
+			}
+			items = append(items, classItem{class: name, negated: s[1] == 'P'})
+			s = s[n:]
+			continue
+		}
+		lo, err := char()
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if len(s) > 1 && s[0] == '-' {
+			s = s[1:]
+			if hi, err = char(); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, fmt.Errorf("invalid range %q-%q", lo, hi)
+			}
+		}
+		items = append(items, classItem{lo: lo, hi: hi})
+	}
+	return items, nil
+}
+
+// unicodeTable returns the Unicode category, script or property.
+func unicodeTable(name string) (*unicode.RangeTable, bool) {
+	for _, tables := range []map[string]*unicode.RangeTable{
+		unicode.Categories, unicode.Scripts, unicode.Properties} {
+		if t, ok := tables[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// pegClass returns the char class with the items, the dot for the negated
+// empty class, or a term that never matches for the empty class.
+func pegClass(negated bool, items []classItem, fold bool) (string, int) {
+	if len(items) == 0 {
+		if negated {
+			return ".", primaryLevel
+		}
+		return "&. !.", seqLevel
+	}
+	var r strings.Builder
+	r.WriteString("[")
+	if negated {
+		r.WriteString("^")
+	}
+	for _, item := range items {
+		switch {
+		case item.class != "" && item.negated:
+			r.WriteString(`\P{` + item.class + "}")
+		case item.class != "":
+			r.WriteString(`\p{` + item.class + "}")
+		case item.lo == item.hi:
+			r.WriteString(pegClassChar(item.lo))
+		case item.lo <= 0xffff && item.hi > 0xffff:
+			// The ranges cannot cross the boundary of 16-bit runes.
+			r.WriteString(pegClassChar(item.lo) + "-" + pegClassChar(0xffff) +
+				pegClassChar(0x10000) + "-" + pegClassChar(item.hi))
+		default:
+			r.WriteString(pegClassChar(item.lo) + "-" + pegClassChar(item.hi))
+		}
+	}
+	r.WriteString("]")
+	if fold {
+		r.WriteString("i")
+	}
+	return r.String(), primaryLevel
+}
+
+// pigeonEscape reads the escape at the beginning of s, which has the Go
+// syntax, or escapes one of the characters ]-[^ in the char classes.
+func pigeonEscape(s string) (rune, int, error) {
+	if len(s) > 1 && strings.IndexByte(`]-[^'"`, s[1]) >= 0 {
+		return rune(s[1]), 2, nil
+	}
+	c, _, tail, err := strconv.UnquoteChar(s, 0)
+	return c, len(s) - len(tail), err
+}
+
+// pigeonLiteral returns the value of the pigeon literal "...", '...' or
+// `...`, which has the Go syntax, with the optional suffix i.
+func pigeonLiteral(text string) (string, bool, error) {
+	fold := strings.HasSuffix(text, "i")
+	if fold {
+		text = text[:len(text)-1]
+	}
+	s, err := strconv.Unquote(text)
+	return s, fold, err
+}
+
+// pigeonClass converts the pigeon char class [...] with the optional
+// prefix ^ and suffix i.
+func pigeonClass(text string) (string, int, error) {
+	fold := strings.HasSuffix(text, "i")
+	if fold {
+		text = text[:len(text)-1]
+	}
+	text = text[1 : len(text)-1]
+	negated := strings.HasPrefix(text, "^")
+	if negated {
+		text = text[1:]
+	}
+	items, err := parseClass(text, pigeonEscape)
+	if err != nil {
+		return "", 0, err
+	}
+	s, level := pegClass(negated, items, fold)
+	return s, level, nil
+}
+
+// pointlanderEscapes are the escapes of pointlander/peg with a single
+// character.
+var pointlanderEscapes = map[byte]rune{
+	'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\'': '\'', '"': '"', '[': '[', ']': ']', '-': '-', '^': '^', '\\': '\\',
+}
+
+// pointlanderEscape reads the escape at the beginning of s: a character
+// escape, a hexadecimal code \0x41 or an octal code \101.
+func pointlanderEscape(s string) (rune, int, error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("invalid escape %q", s)
+	}
+	if c, ok := pointlanderEscapes[s[1]]; ok {
+		return c, 2, nil
+	}
+	digits, base, n := "01234567", 8, 1
+	if strings.HasPrefix(s[1:], "0x") {
+		digits, base, n = "0123456789abcdefABCDEF", 16, 3
+	}
+	end := n
+	for end < len(s) && strings.IndexByte(digits, s[end]) >= 0 && (base == 16 || end < n+3) {
+		end++
+	}
+	v, err := strconv.ParseInt(s[n:end], base, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, 0, fmt.Errorf("invalid escape %q", s[:end])
+	}
+	return rune(v), end, nil
+}
+
+// pointlanderLiteral returns the value of the literal '...' or "...", the
+// latter being case insensitive.
+func pointlanderLiteral(text string) (string, bool, error) {
+	var r strings.Builder
+	for s := text[1 : len(text)-1]; len(s) > 0; {
+		if s[0] != '\\' {
+			c, n := utf8.DecodeRuneInString(s)
+			r.WriteRune(c)
+			s = s[n:]
+			continue
+		}
+		c, n, err := pointlanderEscape(s)
+		if err != nil {
+			return "", false, err
+		}
+		r.WriteRune(c)
+		s = s[n:]
+	}
+	return r.String(), text[0] == '"', nil
+}
+
+// pointlanderClass converts the char class [...] or the case-insensitive
+// char class [[...]] with the optional prefix ^.
+func pointlanderClass(text string) (string, int, error) {
+	fold := strings.HasPrefix(text, "[[")
+	if fold {
+		text = text[1 : len(text)-1]
+	}
+	text = text[1 : len(text)-1]
+	negated := strings.HasPrefix(text, "^")
+	if negated {
+		text = text[1:]
+	}
+	items, err := parseClass(text, pointlanderEscape)
+	if err != nil {
+		return "", 0, err
+	}
+	s, level := pegClass(negated, items, fold)
+	return s, level, nil
+}
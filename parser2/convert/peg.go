@@ -0,0 +1,157 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// pegSource returns the PEG grammar of the rules in the canonical layout
+// of parser2.Format.
+func pegSource(names []string, exprs map[string]*expr) (string, error) {
+	var r strings.Builder
+	for _, name := range names {
+		s, _ := pegExpr(exprs[name])
+		fmt.Fprintf(&r, "%s <- %s\n", name, s)
+	}
+	return parser2.Format(r.String())
+}
+
+// reservedNames are the rule names that have special meaning in PEG
+// grammars, and get the suffix _.
+var reservedNames = map[string]bool{"INDENT": true, "DEDENT": true, "SAMEDENT": true}
+
+// pegIdent returns the PEG identifier for the rule name of other grammar
+// notations. The characters that are not allowed in the identifiers, such
+// as - in ABNF, are replaced with _.
+func pegIdent(name string) string {
+	r := strings.Map(func(c rune) rune {
+		if c == '_' || c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return c
+		}
+		return '_'
+	}, name)
+	if reservedNames[r] {
+		r += "_"
+	}
+	return r
+}
+
+// pegExpr returns the PEG of the expression and its precedence level.
+func pegExpr(e *expr) (string, int) {
+	switch e.kind {
+	case seqExpr:
+		if len(e.items) == 0 {
+			// The empty literal cannot be used in the grammars.
+			return ".{0}", primaryLevel
+		}
+		var parts []string
+		for _, item := range e.items {
+			s, level := pegExpr(item)
+			parts = append(parts, paren(s, level, seqLevel))
+		}
+		return strings.Join(parts, " "), seqLevel
+	case choiceExpr:
+		var parts []string
+		for _, item := range e.items {
+			s, _ := pegExpr(item)
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " / "), choiceLevel
+	case repeatExpr:
+		s, level := pegExpr(e.items[0])
+		s = paren(s, level, primaryLevel)
+		switch {
+		case e.min == 0 && e.max == 1:
+			return s + "?", primaryLevel
+		case e.min == 0 && e.max < 0:
+			return s + "*", primaryLevel
+		case e.min == 1 && e.max < 0:
+			return s + "+", primaryLevel
+		case e.max < 0:
+			return fmt.Sprintf("%s{%d,}", s, e.min), primaryLevel
+		case e.min == e.max:
+			return fmt.Sprintf("%s{%d}", s, e.min), primaryLevel
+		}
+		return fmt.Sprintf("%s{%d,%d}", s, e.min, e.max), primaryLevel
+	case litExpr:
+		s := strconv.Quote(e.lit)
+		if e.fold && strings.IndexFunc(e.lit, unicode.IsLetter) >= 0 {
+			s += "i"
+		}
+		return s, primaryLevel
+	case setExpr:
+		if len(e.set) == 0 {
+			// A character that is present and absent.
+			return "&. !.", seqLevel
+		}
+		return pegSet(e.set), primaryLevel
+	}
+	return e.ref, primaryLevel
+}
+
+// pegClassChar returns the character as it is written in the char classes.
+func pegClassChar(c rune) string {
+	switch {
+	case strings.ContainsRune(`\]^-[`, c):
+		return `\` + string(c)
+	case unicode.IsPrint(c):
+		return string(c)
+	}
+	q := strconv.QuoteRune(c)
+	return q[1 : len(q)-1]
+}
+
+// pegSet returns the char class [...] or [^...] of the set, whichever is
+// shorter, the dot for all characters, or a literal for a single character.
+func pegSet(set charclass.Set) string {
+	// The surrogates never occur in the input, and cannot be written in the
+	// char classes.
+	surrogates := charclass.Set{{Lo: 0xd800, Hi: 0xdfff}}
+	if len(surrogates.Intersect(set)) == 0 && len(charclass.Set{{Lo: 0xd7ff, Hi: 0xd7ff}, {Lo: 0xe000, Hi: 0xe000}}.Intersect(set)) == 2 {
+		set = set.Union(surrogates)
+	}
+	switch {
+	case isAny(set):
+		return "."
+	case len(set) == 1 && set[0].Lo == set[0].Hi:
+		return strconv.Quote(string(set[0].Lo))
+	}
+	neg := ""
+	if comp := set.Complement(); len(comp) > 0 && len(comp) < len(set) {
+		neg, set = "^", comp
+	}
+	// The ranges cannot cross the boundary of 16-bit runes.
+	set = set.Intersect(charclass.Set{{Lo: 0, Hi: 0xd7ff}, {Lo: 0xe000, Hi: 0xffff}, {Lo: 0x10000, Hi: unicode.MaxRune}})
+	var r strings.Builder
+	r.WriteString("[" + neg)
+	for _, iv := range set {
+		r.WriteString(pegClassChar(iv.Lo))
+		switch {
+		case iv.Hi == iv.Lo+1:
+			r.WriteString(pegClassChar(iv.Hi))
+		case iv.Hi != iv.Lo:
+			r.WriteString("-" + pegClassChar(iv.Hi))
+		}
+	}
+	r.WriteString("]")
+	return r.String()
+}
@@ -0,0 +1,396 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/salikh/peg/parser/charclass"
+	"github.com/salikh/peg/parser2"
+)
+
+// regexpClasses are the regular expression classes of the special char
+// classes.
+var regexpClasses = map[string]string{
+	"IsLetter":  `\p{L}`,
+	"IsNumber":  `\p{N}`,
+	"IsLower":   `\p{Ll}`,
+	"IsUpper":   `\p{Lu}`,
+	"IsPunct":   `\p{P}`,
+	"IsControl": `\p{Cc}`,
+}
+
+// TreeSitter converts the grammar to a tree-sitter grammar.js with the
+// given name:
+//
+//	module.exports = grammar({
+//	  name: "calc",
+//	  extras: $ => [],
+//	  rules: {
+//	    Expr: $ => seq($.Term, repeat(seq("+", $.Term))),
+//	    Term: $ => /[0-9]/,
+//	  },
+//	});
+//
+// The first rule is the start rule. The skip rule of the %skip directive
+// becomes the extras, and the lexical rules become tokens. Without the
+// directive the grammar has no extras, as PEG grammars match the
+// whitespace explicitly. The precedence tables are converted to the
+// tree-sitter precedences, and the labeled rule references to fields.
+// Since tree-sitter does not allow the rules other than the start rule to
+// match empty input, such rules are reported as warnings.
+func TreeSitter(g *parser2.Grammar, name string) (string, []*Warning) {
+	b := &builder{target: "tree-sitter"}
+	names, exprs := b.convert(g)
+	for _, name := range names {
+		e := exprs[name]
+		if g.Skip != "" && name != g.Skip {
+			e = dropRef(e, g.Skip)
+		}
+		exprs[name] = expandRepeat(e)
+	}
+	extras := "[]"
+	if skip, ok := exprs[g.Skip]; ok {
+		if skip.kind == repeatExpr && skip.min == 0 {
+			// The extras are repeated by tree-sitter.
+			items := skip.items[0].items
+			if skip.items[0].kind != choiceExpr {
+				items = []*expr{skip.items[0]}
+			}
+			var parts []string
+			for _, item := range items {
+				parts = append(parts, tsExpr(item))
+			}
+			extras = "[" + strings.Join(parts, ", ") + "]"
+			delete(exprs, g.Skip)
+		} else {
+			extras = "[$." + g.Skip + "]"
+		}
+	}
+	nullable := computeNullable(exprs)
+	var r strings.Builder
+	fmt.Fprintf(&r, "module.exports = grammar({\n  name: %s,\n  extras: $ => %s,\n  rules: {\n",
+		jsString(identName(name)), extras)
+	for i, name := range names {
+		e, ok := exprs[name]
+		if !ok {
+			continue
+		}
+		b.rule = name
+		if i > 0 && nullable[name] {
+			b.warn("the rule matches empty input, which tree-sitter does not allow")
+		}
+		var s string
+		if e.kind == choiceExpr {
+			var parts []string
+			for _, item := range e.items {
+				parts = append(parts, "      "+tsExpr(item)+",\n")
+			}
+			s = "choice(\n" + strings.Join(parts, "") + "    )"
+		} else if e.kind == precExpr {
+			s = tsPrec(e, name)
+		} else {
+			s = tsExpr(e)
+		}
+		if g.Skip != "" && name != g.Skip && isLexical(g, g.Rules[name]) {
+			if hasRef(e) {
+				b.warn("the lexical rule refers to other rules, so the extras may appear inside it")
+			} else {
+				s = "token(" + s + ")"
+			}
+		}
+		fmt.Fprintf(&r, "    %s: $ => %s,\n", name, s)
+	}
+	r.WriteString("  },\n});\n")
+	return r.String(), b.warnings
+}
+
+// identName returns the name with the characters other than letters,
+// digits and underscores replaced by underscores.
+func identName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+// dropRef returns the expression without the references to the rule.
+func dropRef(e *expr, name string) *expr {
+	switch e.kind {
+	case refExpr:
+		if e.ref == name {
+			return seq()
+		}
+	case seqExpr:
+		s := seq()
+		for _, item := range e.items {
+			if item = dropRef(item, name); item.kind != seqExpr || len(item.items) > 0 {
+				s.items = append(s.items, item)
+			}
+		}
+		return single(s)
+	case choiceExpr:
+		c := &expr{kind: choiceExpr}
+		for _, item := range e.items {
+			c.items = append(c.items, dropRef(item, name))
+		}
+		return c
+	case repeatExpr:
+		item := dropRef(e.items[0], name)
+		if item.kind == seqExpr && len(item.items) == 0 {
+			return item
+		}
+		return repeat(item, e.min, e.max)
+	}
+	return e
+}
+
+func hasRef(e *expr) bool {
+	if e.kind == refExpr || e.kind == precExpr {
+		return true
+	}
+	for _, item := range e.items {
+		if hasRef(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// computeNullable returns the set of the rules that match empty input.
+func computeNullable(exprs map[string]*expr) map[string]bool {
+	nullable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for name, e := range exprs {
+			if !nullable[name] && isNullable(e, nullable) {
+				nullable[name] = true
+				changed = true
+			}
+		}
+	}
+	return nullable
+}
+
+// isNullable reports whether the expression matches empty input, given the
+// set of the rules that match empty input.
+func isNullable(e *expr, nullable map[string]bool) bool {
+	switch e.kind {
+	case seqExpr:
+		for _, item := range e.items {
+			if !isNullable(item, nullable) {
+				return false
+			}
+		}
+		return true
+	case choiceExpr:
+		for _, item := range e.items {
+			if isNullable(item, nullable) {
+				return true
+			}
+		}
+		return false
+	case repeatExpr:
+		return e.min == 0 || isNullable(e.items[0], nullable)
+	case refExpr, precExpr:
+		return nullable[e.ref]
+	}
+	return false
+}
+
+// jsString returns the JavaScript string literal of s.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func tsExpr(e *expr) string {
+	switch e.kind {
+	case seqExpr:
+		if len(e.items) == 0 {
+			return "blank()"
+		}
+		var items []*expr
+		for _, item := range e.items {
+			if item.kind == seqExpr {
+				items = append(items, item.items...)
+			} else {
+				items = append(items, item)
+			}
+		}
+		return "seq(" + tsList(items) + ")"
+	case choiceExpr:
+		return "choice(" + tsList(e.items) + ")"
+	case repeatExpr:
+		s := tsExpr(e.items[0])
+		switch {
+		case e.min == 0 && e.max == 1:
+			return "optional(" + s + ")"
+		case e.min == 0:
+			return "repeat(" + s + ")"
+		}
+		return "repeat1(" + s + ")"
+	case litExpr:
+		if !e.fold {
+			return jsString(e.lit)
+		}
+		var r strings.Builder
+		for _, c := range e.lit {
+			if set := foldSet(c); len(set) > 1 {
+				r.WriteString(regexpSet(set))
+			} else {
+				r.WriteString(regexpChar(c, false))
+			}
+		}
+		return "/" + r.String() + "/"
+	case setExpr:
+		return "/" + tsSet(e) + "/"
+	case precExpr:
+		// The precedence tables are the only terms of their rules, which
+		// TreeSitter converts with tsPrec.
+		return tsExpr(expandRepeat(flattenPrec(e)))
+	}
+	if e.label != "" {
+		return fmt.Sprintf("field(%s, $.%s)", jsString(e.label), e.ref)
+	}
+	return "$." + e.ref
+}
+
+func tsList(items []*expr) string {
+	var parts []string
+	for _, item := range items {
+		parts = append(parts, tsExpr(item))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// tsPrec returns the choice of the operand and the operators of the
+// precedence table of the rule, with the precedences of the levels.
+func tsPrec(e *expr, rule string) string {
+	self := "$." + rule
+	parts := []string{"$." + e.ref}
+	for i, level := range e.prec.Levels {
+		var ops []string
+		for _, op := range level.Ops {
+			ops = append(ops, jsString(op.Literal))
+		}
+		op := ops[0]
+		if len(ops) > 1 {
+			op = "choice(" + strings.Join(ops, ", ") + ")"
+		}
+		var s string
+		switch level.Kind {
+		case "prefix":
+			s = fmt.Sprintf("prec(%d, seq(%s, %s))", i+1, op, self)
+		case "postfix":
+			s = fmt.Sprintf("prec(%d, seq(%s, %s))", i+1, self, op)
+		default:
+			s = fmt.Sprintf("prec.%s(%d, seq(%s, %s, %s))", level.Kind, i+1, self, op, self)
+		}
+		parts = append(parts, s)
+	}
+	var r strings.Builder
+	r.WriteString("choice(\n")
+	for _, part := range parts {
+		r.WriteString("      " + part + ",\n")
+	}
+	r.WriteString("    )")
+	return r.String()
+}
+
+// regexpChar returns the character escaped for the regular expressions,
+// inside or outside of the character classes.
+func regexpChar(c rune, inClass bool) string {
+	special := `\/.*+?()[]{}|^$`
+	if inClass {
+		special = `\/[]^-`
+	}
+	switch {
+	case strings.ContainsRune(special, c):
+		return `\` + string(c)
+	case c == '\n':
+		return `\n`
+	case c == '\t':
+		return `\t`
+	case c == '\r':
+		return `\r`
+	case c >= 0x20 && c < 0x7f:
+		return string(c)
+	case c > 0xffff:
+		return fmt.Sprintf(`\u{%x}`, c)
+	}
+	return fmt.Sprintf(`\u%04x`, c)
+}
+
+// regexpSet returns the character class [...] or [^...] of the set,
+// whichever is shorter.
+func regexpSet(set charclass.Set) string {
+	if isAny(set) {
+		return `[\s\S]`
+	}
+	neg := ""
+	if comp := set.Complement(); len(set) == 0 || len(comp) < len(set) {
+		neg, set = "^", comp
+	}
+	if len(set) == 0 {
+		return `[^\s\S]`
+	}
+	var r strings.Builder
+	r.WriteString("[" + neg)
+	for _, iv := range set {
+		r.WriteString(regexpChar(iv.Lo, true))
+		switch {
+		case iv.Hi == iv.Lo+1:
+			r.WriteString(regexpChar(iv.Hi, true))
+		case iv.Hi != iv.Lo:
+			r.WriteString("-" + regexpChar(iv.Hi, true))
+		}
+	}
+	r.WriteString("]")
+	return r.String()
+}
+
+// tsSet returns the regular expression of the character set, using the
+// Unicode classes for the special char classes and the Unicode properties.
+func tsSet(e *expr) string {
+	cc := e.cc
+	if cc == nil || cc.IgnoreCase || cc.Expr != "" {
+		return regexpSet(e.set)
+	}
+	class := ""
+	if cc.Property != "" {
+		class = `\p{` + cc.Property + `}`
+	} else if cc.Special == "[:alnum:]" {
+		class = `\p{L}\p{Nd}`
+	} else {
+		class = regexpClasses[cc.Special]
+	}
+	if class == "" {
+		return regexpSet(e.set)
+	}
+	if cc.Negated {
+		return "[^" + class + "]"
+	}
+	return "[" + class + "]"
+}
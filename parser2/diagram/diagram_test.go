@@ -0,0 +1,163 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagram
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/salikh/peg/compat/runfiles"
+	"github.com/salikh/peg/parser2"
+	"github.com/salikh/peg/tests"
+)
+
+// checkXML checks that the SVG image is well-formed XML.
+func checkXML(t *testing.T, name, svg string) {
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Errorf("%s: SVG is not well-formed: %s\n%s", name, err, svg)
+			return
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	g, err := parser2.ParseGrammar(`Top <- (A / "x" B)+ !C <Num>? C{2,3} [a-z]* . ^Rec
+A <- "<&>" &B
+B <- %prec Num %left "+" "-" %prefix "-" %postfix "!"
+C <- "c"
+Num <- <[0-9]+>
+Rec <- (!"\n" .)*`)
+	if err != nil {
+		t.Fatalf("ParseGrammar returns error %s", err)
+	}
+	href := func(rule string) string { return rule + ".svg" }
+	for _, name := range g.RuleNames {
+		svg, err := SVG(g, name, href)
+		if err != nil {
+			t.Errorf("SVG(%s) returns error %s", name, err)
+			continue
+		}
+		checkXML(t, name, svg)
+	}
+	svg, _ := SVG(g, "Top", href)
+	for _, want := range []string{`xlink:href="A.svg"`, `xlink:href="Num.svg"`, `&#34;x&#34;`,
+		"[a-z]", "any char", ">not<", ">^Rec<", ">2..3 times<"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG(Top) does not contain %q:\n%s", want, svg)
+		}
+	}
+	svg, _ = SVG(g, "A", nil)
+	if !strings.Contains(svg, "&#34;&lt;&amp;&gt;&#34;") || strings.Contains(svg, "xlink:href") {
+		t.Errorf("SVG(A) returns\n%s\nwant escaped literal and no links", svg)
+	}
+	if _, err := SVG(g, "Unknown", nil); err == nil {
+		t.Errorf("SVG(Unknown) returns success, want error")
+	}
+}
+
+func TestHTML(t *testing.T) {
+	g, err := parser2.ParseGrammar("A <- B C\nB <- 'b'\nC <- 'c' Undefined\nUndefined <- B")
+	if err != nil {
+		t.Fatalf("ParseGrammar returns error %s", err)
+	}
+	got := HTML(g, "Test & grammar")
+	for _, want := range []string{"<title>Test &amp; grammar</title>", `<a href="#B">B</a>`,
+		`<div id="C">`, `xlink:href="#C"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDOT(t *testing.T) {
+	g, err := parser2.ParseGrammar(`A <- B (C / B)* ^R
+B <- !C "b"
+C <- %prec D %left "+"
+D <- [0-9]
+R <- .`)
+	if err != nil {
+		t.Fatalf("ParseGrammar returns error %s", err)
+	}
+	want := `digraph grammar {
+  rankdir=LR;
+  node [shape=box];
+  "A" [style=bold];
+  "B";
+  "C";
+  "D";
+  "R";
+  "A" -> "B";
+  "A" -> "C";
+  "A" -> "R" [style=dashed];
+  "B" -> "C";
+  "C" -> "D";
+}
+`
+	if got := DOT(g); got != want {
+		t.Errorf("DOT returns\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestTestdata checks that the diagrams of the grammars used in the tests
+// are well-formed.
+func TestTestdata(t *testing.T) {
+	var sources []string
+	dirname := runfiles.Path("github.com/salikh/peg/tests/testdata")
+	names, err := filepath.Glob(filepath.Join(dirname, "*.g"))
+	if err != nil || len(names) == 0 {
+		t.Fatalf("Cannot list testdata: %v", err)
+	}
+	for _, name := range names {
+		source, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Error reading %q: %s", name, err)
+		}
+		sources = append(sources, string(source))
+	}
+	for _, test := range tests.Positive {
+		sources = append(sources, test.Grammar)
+	}
+	for _, suite := range [][]tests.TreeTest{tests.Parameterized, tests.Labels, tests.Cut, tests.Repeat,
+		tests.Recover, tests.Indent, tests.BackRef, tests.Precedence} {
+		for _, test := range suite {
+			sources = append(sources, test.Grammar)
+		}
+	}
+	for _, source := range sources {
+		g, err := parser2.ParseGrammar(source)
+		if err != nil {
+			continue
+		}
+		for _, name := range g.RuleNames {
+			svg, err := SVG(g, name, nil)
+			if err != nil {
+				t.Errorf("SVG(%s) returns error %s", name, err)
+				continue
+			}
+			checkXML(t, name, svg)
+		}
+		DOT(g)
+	}
+}
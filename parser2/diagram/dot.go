@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/salikh/peg/parser2"
+)
+
+// edge is a reference from one rule to another.
+type edge struct {
+	to string
+	// recover is true for the references to the recovery rules.
+	recover bool
+}
+
+// references returns the rules referenced from the rule, in the order of
+// the first reference.
+func references(rule *parser2.Rule) []edge {
+	var edges []edge
+	seen := make(map[edge]bool)
+	add := func(e edge) {
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+	var visitRHS func(rhs *parser2.RHS)
+	var visit func(term *parser2.Term)
+	visitRHS = func(rhs *parser2.RHS) {
+		if rhs == nil {
+			return
+		}
+		for _, terms := range rhs.Terms {
+			for _, term := range terms {
+				visit(term)
+			}
+		}
+	}
+	visit = func(term *parser2.Term) {
+		if term == nil {
+			return
+		}
+		if term.Ident != "" {
+			add(edge{to: term.Ident})
+		}
+		for _, arg := range term.Args {
+			visit(arg)
+		}
+		visitRHS(term.Parens)
+		visitRHS(term.Capture)
+		visit(term.NegPred)
+		visit(term.Pred)
+		if term.Special != nil {
+			visit(term.Special.Term)
+		}
+		if term.Recover != "" {
+			add(edge{to: term.Recover, recover: true})
+		}
+	}
+	visitRHS(rule.RHS)
+	return edges
+}
+
+// DOT returns the graph of the references between the rules of the grammar
+// in Graphviz DOT format. The first rule is drawn in bold, and the
+// references to the recovery rules are dashed.
+func DOT(g *parser2.Grammar) string {
+	var b strings.Builder
+	b.WriteString("digraph grammar {\n  rankdir=LR;\n  node [shape=box];\n")
+	for i, name := range g.RuleNames {
+		if i == 0 {
+			fmt.Fprintf(&b, "  %s [style=bold];\n", strconv.Quote(name))
+		} else {
+			fmt.Fprintf(&b, "  %s;\n", strconv.Quote(name))
+		}
+	}
+	for _, name := range g.RuleNames {
+		for _, e := range references(g.Rules[name]) {
+			attr := ""
+			if e.recover {
+				attr = " [style=dashed]"
+			}
+			fmt.Fprintf(&b, "  %s -> %s%s;\n", strconv.Quote(name), strconv.Quote(e.to), attr)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
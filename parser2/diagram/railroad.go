@@ -0,0 +1,462 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diagram draws the railroad diagrams of the rules of PEG grammars
+// as SVG images, and the graphs of the dependencies between the rules in
+// Graphviz DOT format.
+package diagram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/salikh/peg/parser2"
+)
+
+// The dimensions of the diagram elements in pixels. The text is drawn in
+// a monospace font, so that its width can be estimated without measuring.
+const (
+	arcRadius = 10
+	// vertSep is the vertical distance between the alternatives.
+	vertSep = 8
+	// hGap is the horizontal distance between the terms of a sequence.
+	hGap      = 10
+	charWidth = 8
+	// boxHalf is the half of the height of the boxes.
+	boxHalf = 11
+	// groupPad is the padding of the dashed boxes around the groups.
+	groupPad   = 8
+	textHeight = 14
+	margin     = 10
+)
+
+const style = `<style>
+path { fill: none; stroke: #333; stroke-width: 1.5; }
+rect { stroke: #333; stroke-width: 1.5; }
+rect.terminal { fill: #e8f4e8; }
+rect.nonterminal { fill: #e8eef8; }
+rect.special { fill: #f8f0e0; }
+rect.group { fill: none; stroke: #888; stroke-width: 1; stroke-dasharray: 4 3; }
+text { font: 13px monospace; fill: #000; }
+text.label { font-size: 11px; fill: #555; }
+text.title { font: bold 14px sans-serif; }
+</style>`
+
+// element is an element of a railroad diagram. The elements are drawn
+// left to right, entering and leaving at the baseline y.
+type element interface {
+	// size returns the width of the element and its extents above and below
+	// the baseline.
+	size() (width, up, down int)
+	draw(b *strings.Builder, x, y int)
+}
+
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s) * charWidth
+}
+
+func path(b *strings.Builder, format string, args ...interface{}) {
+	fmt.Fprintf(b, "<path d=\"%s\"/>\n", fmt.Sprintf(format, args...))
+}
+
+func hline(b *strings.Builder, x1, x2, y int) {
+	if x1 != x2 {
+		path(b, "M%d %dH%d", x1, y, x2)
+	}
+}
+
+// box is a terminal, a rule reference or a special term.
+type box struct {
+	text, class, href string
+}
+
+func (e *box) size() (int, int, int) {
+	return textWidth(e.text) + 2*hGap, boxHalf, boxHalf
+}
+
+func (e *box) draw(b *strings.Builder, x, y int) {
+	w, _, _ := e.size()
+	if e.href != "" {
+		fmt.Fprintf(b, "<a xlink:href=\"%s\">\n", html.EscapeString(e.href))
+	}
+	rx := 0
+	if e.class == "terminal" {
+		rx = boxHalf
+	}
+	fmt.Fprintf(b, "<rect class=\"%s\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\"/>\n",
+		e.class, x, y-boxHalf, w, 2*boxHalf, rx)
+	fmt.Fprintf(b, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+		x+w/2, y+4, html.EscapeString(e.text))
+	if e.href != "" {
+		b.WriteString("</a>\n")
+	}
+}
+
+// sequence is a sequence of elements. The empty sequence is a line
+// of zero length.
+type sequence []element
+
+func (e sequence) size() (int, int, int) {
+	w, up, down := 0, 0, 0
+	for i, item := range e {
+		iw, iu, id := item.size()
+		if i > 0 {
+			w += hGap
+		}
+		w += iw
+		up, down = max(up, iu), max(down, id)
+	}
+	return w, up, down
+}
+
+func (e sequence) draw(b *strings.Builder, x, y int) {
+	for i, item := range e {
+		if i > 0 {
+			hline(b, x, x+hGap, y)
+			x += hGap
+		}
+		item.draw(b, x, y)
+		w, _, _ := item.size()
+		x += w
+	}
+}
+
+// choice is an ordered choice. The first alternative is drawn on the
+// baseline, and the other alternatives below it.
+type choice []element
+
+// offsets returns the offsets of the baselines of the alternatives from
+// the baseline of the choice.
+func (e choice) offsets() []int {
+	offsets := []int{0}
+	for i := 1; i < len(e); i++ {
+		_, _, prevDown := e[i-1].size()
+		_, up, _ := e[i].size()
+		prev := offsets[i-1]
+		offsets = append(offsets, max(prev+prevDown+vertSep+up, prev+2*arcRadius))
+	}
+	return offsets
+}
+
+func (e choice) size() (int, int, int) {
+	w := 0
+	for _, item := range e {
+		iw, _, _ := item.size()
+		w = max(w, iw)
+	}
+	offsets := e.offsets()
+	_, up, _ := e[0].size()
+	_, _, down := e[len(e)-1].size()
+	return w + 4*arcRadius, up, offsets[len(e)-1] + down
+}
+
+func (e choice) draw(b *strings.Builder, x, y int) {
+	w, _, _ := e.size()
+	r := arcRadius
+	for i, item := range e {
+		iw, _, _ := item.size()
+		o := e.offsets()[i]
+		if i == 0 {
+			hline(b, x, x+2*r, y)
+		} else {
+			path(b, "M%d %da%d %d 0 0 1 %d %dV%da%d %d 0 0 0 %d %d", x, y, r, r, r, r, y+o-r, r, r, r, r)
+		}
+		item.draw(b, x+2*r, y+o)
+		if i == 0 {
+			hline(b, x+2*r+iw, x+w, y)
+		} else {
+			path(b, "M%d %dH%da%d %d 0 0 0 %d %dV%da%d %d 0 0 1 %d %d",
+				x+2*r+iw, y+o, x+w-2*r, r, r, r, -r, y+r, r, r, r, -r)
+		}
+	}
+}
+
+// loop is a repetition of the item, with the separator drawn on the way
+// back and the label below.
+type loop struct {
+	item, sep element
+	label     string
+}
+
+func (e *loop) offset() int {
+	_, _, down := e.item.size()
+	_, up, _ := e.sep.size()
+	return max(down+vertSep+up, 2*arcRadius)
+}
+
+func (e *loop) size() (int, int, int) {
+	iw, up, _ := e.item.size()
+	sw, _, sd := e.sep.size()
+	down := e.offset() + sd
+	if e.label != "" {
+		down += textHeight
+	}
+	return max(iw, sw) + 4*arcRadius, up, down
+}
+
+func (e *loop) draw(b *strings.Builder, x, y int) {
+	w, _, _ := e.size()
+	iw, _, _ := e.item.size()
+	sw, _, sd := e.sep.size()
+	r := arcRadius
+	inner := w - 4*r
+	ix := x + 2*r + (inner-iw)/2
+	hline(b, x, ix, y)
+	e.item.draw(b, ix, y)
+	hline(b, ix+iw, x+w, y)
+	o := e.offset()
+	sx := x + 2*r + (inner-sw)/2
+	path(b, "M%d %da%d %d 0 0 1 %d %dV%da%d %d 0 0 1 %d %dH%d",
+		x+w-2*r, y, r, r, r, r, y+o-r, r, r, -r, r, sx+sw)
+	e.sep.draw(b, sx, y+o)
+	path(b, "M%d %dH%da%d %d 0 0 1 %d %dV%da%d %d 0 0 1 %d %d",
+		sx, y+o, x+2*r, r, r, -r, -r, y+r, r, r, r, -r)
+	if e.label != "" {
+		fmt.Fprintf(b, "<text class=\"label\" x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>\n",
+			x+w/2, y+o+sd+textHeight-2, html.EscapeString(e.label))
+	}
+}
+
+// group is an element in a dashed box with a label, which shows captures
+// and predicates.
+type group struct {
+	item  element
+	label string
+}
+
+func (e *group) size() (int, int, int) {
+	w, up, down := e.item.size()
+	return max(w, textWidth(e.label)) + 2*groupPad, up + groupPad + textHeight, down + groupPad
+}
+
+func (e *group) draw(b *strings.Builder, x, y int) {
+	w, _, _ := e.size()
+	iw, up, down := e.item.size()
+	ix := x + (w-iw)/2
+	top := y - up - groupPad
+	fmt.Fprintf(b, "<rect class=\"group\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"4\"/>\n",
+		x, top, w, up+down+2*groupPad)
+	fmt.Fprintf(b, "<text class=\"label\" x=\"%d\" y=\"%d\">%s</text>\n", x+2, top-3, html.EscapeString(e.label))
+	hline(b, x, ix, y)
+	e.item.draw(b, ix, y)
+	hline(b, ix+iw, x+w, y)
+}
+
+// diagrammer converts the rules into diagram elements.
+type diagrammer struct {
+	// href returns the link of the references to the rule, or empty
+	// string for no link.
+	href func(rule string) string
+}
+
+func (d *diagrammer) rhs(rhs *parser2.RHS) element {
+	if len(rhs.Terms) == 1 {
+		return d.sequence(rhs.Terms[0])
+	}
+	var c choice
+	for _, terms := range rhs.Terms {
+		c = append(c, d.sequence(terms))
+	}
+	return c
+}
+
+func (d *diagrammer) sequence(terms []*parser2.Term) element {
+	if len(terms) == 1 {
+		return d.term(terms[0])
+	}
+	var s sequence
+	for _, term := range terms {
+		s = append(s, d.term(term))
+	}
+	return s
+}
+
+func optional(e element) element {
+	return choice{e, sequence{}}
+}
+
+func (d *diagrammer) term(term *parser2.Term) element {
+	switch {
+	case term.Recover != "":
+		t := *term
+		t.Recover = ""
+		return &group{d.term(&t), "^" + term.Recover}
+	case term.Prec != nil:
+		return d.prec(term)
+	case term.Parens != nil:
+		return d.rhs(term.Parens)
+	case term.NegPred != nil:
+		return &group{d.term(term.NegPred), "not"}
+	case term.Pred != nil:
+		return &group{d.term(term.Pred), "and"}
+	case term.Special != nil:
+		item := d.term(term.Special.Term)
+		switch term.Special.Rune {
+		case '?':
+			return optional(item)
+		case '*':
+			return optional(&loop{item: item, sep: sequence{}})
+		case '+':
+			return &loop{item: item, sep: sequence{}}
+		}
+		min, max := term.Special.Min, term.Special.Max
+		label := fmt.Sprintf("%d..%d times", min, max)
+		switch {
+		case max < 0:
+			label = fmt.Sprintf("%d+ times", min)
+		case min == max:
+			label = fmt.Sprintf("%d times", min)
+		}
+		e := element(&loop{item: item, sep: sequence{}, label: label})
+		if min == 0 {
+			e = optional(e)
+		}
+		return e
+	case term.Capture != nil:
+		label := "capture"
+		if term.Label != "" {
+			label = "<" + term.Label + ">"
+		}
+		return &group{d.rhs(term.Capture), label}
+	case term.CharClass != nil && term.CharClass.Special == "[:any:]":
+		return &box{text: "any char", class: "terminal"}
+	case term.CharClass != nil || term.Literal != "":
+		return &box{text: term.ShortString(), class: "terminal"}
+	case term.Ident != "":
+		return &box{text: term.ShortString(), class: "nonterminal", href: d.href(term.Ident)}
+	}
+	// Semantic predicates, cuts, indentation terms and back-references.
+	return &box{text: term.ShortString(), class: "special"}
+}
+
+// prec draws the precedence table as the operands separated by the infix
+// operators, with the optional prefix and postfix operators.
+func (d *diagrammer) prec(term *parser2.Term) element {
+	var prefix, postfix, infix choice
+	for _, level := range term.Prec.Levels {
+		for _, op := range level.Ops {
+			b := &box{text: strconv.Quote(op.Literal), class: "terminal"}
+			switch level.Kind {
+			case "prefix":
+				prefix = append(prefix, b)
+			case "postfix":
+				postfix = append(postfix, b)
+			default:
+				infix = append(infix, b)
+			}
+		}
+	}
+	var unit sequence
+	if len(prefix) > 0 {
+		unit = append(unit, optional(&loop{item: single(prefix), sep: sequence{}}))
+	}
+	unit = append(unit, &box{text: term.Ident, class: "nonterminal", href: d.href(term.Ident)})
+	if len(postfix) > 0 {
+		unit = append(unit, optional(&loop{item: single(postfix), sep: sequence{}}))
+	}
+	if len(infix) == 0 {
+		return unit
+	}
+	return &loop{item: unit, sep: single(infix)}
+}
+
+// single returns the only alternative of the choice, or the choice.
+func single(c choice) element {
+	if len(c) == 1 {
+		return c[0]
+	}
+	return c
+}
+
+// svg returns the SVG image of the railroad diagram of the rule.
+func (d *diagrammer) svg(rule *parser2.Rule) string {
+	var e element
+	if rule.RHS == nil || len(rule.RHS.Terms) == 0 {
+		e = sequence{}
+	} else {
+		e = d.rhs(rule.RHS)
+	}
+	w, up, down := e.size()
+	// The start and end markers take hGap on each side.
+	width := 2*margin + w + 2*hGap
+	height := 2*margin + textHeight + vertSep + up + down
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" "+
+		"width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	b.WriteString(style + "\n")
+	fmt.Fprintf(&b, "<text class=\"title\" x=\"%d\" y=\"%d\">%s</text>\n", margin, margin+textHeight-2,
+		html.EscapeString(rule.Ident))
+	y := margin + textHeight + vertSep + up
+	x := margin
+	path(&b, "M%d %dv%dM%d %dv%d", x, y-boxHalf/2, boxHalf, x+3, y-boxHalf/2, boxHalf)
+	hline(&b, x, x+hGap, y)
+	e.draw(&b, x+hGap, y)
+	x += hGap + w
+	hline(&b, x, x+hGap, y)
+	x += hGap
+	path(&b, "M%d %dv%dM%d %dv%d", x, y-boxHalf/2, boxHalf, x-3, y-boxHalf/2, boxHalf)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// SVG returns the railroad diagram of the rule as a self-contained SVG
+// image. The references to the other rules link to href(rule), if href
+// is not nil.
+func SVG(g *parser2.Grammar, name string, href func(rule string) string) (string, error) {
+	rule, ok := g.Rules[name]
+	if !ok {
+		return "", fmt.Errorf("unknown rule: %s", name)
+	}
+	if href == nil {
+		href = func(string) string { return "" }
+	}
+	d := &diagrammer{href: href}
+	return d.svg(rule), nil
+}
+
+// HTML returns an HTML page with the railroad diagrams of all rules of the
+// grammar and an index of the rules. The references to the rules link to
+// their diagrams on the same page.
+func HTML(g *parser2.Grammar, title string) string {
+	d := &diagrammer{href: func(rule string) string {
+		if _, ok := g.Rules[rule]; !ok {
+			return ""
+		}
+		return "#" + rule
+	}}
+	var b strings.Builder
+	title = html.EscapeString(title)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n<ul>\n", title, title)
+	for _, name := range g.RuleNames {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", html.EscapeString(name), html.EscapeString(name))
+	}
+	b.WriteString("</ul>\n")
+	for _, name := range g.RuleNames {
+		fmt.Fprintf(&b, "<div id=\"%s\">\n", html.EscapeString(name))
+		b.WriteString(d.svg(g.Rules[name]))
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/salikh/peg/parser/charclass"
+)
+
+// ParseError is the error returned by Parse and ParseRule when the input
+// does not match the grammar. It describes the farthest position in the input
+// that the parser has reached before failing, which is usually the most
+// relevant location of a syntax error.
+type ParseError struct {
+	// Pos is the byte offset of the farthest failure.
+	Pos int
+	// Row is the line number of the farthest failure. 1-based.
+	Row int
+	// Col is the column number of the farthest failure in bytes. 0-based.
+	Col int
+	// Expected is the sorted list of literals, character classes and rule
+	// names that were expected at Pos. Literals are quoted, and character
+	// classes are enclosed in brackets.
+	Expected []string
+	// Found is the input text at Pos, possibly truncated, or "end of input".
+	Found string
+	// Snippet is the line of input containing Pos, followed by a line
+	// with a caret pointing at Pos.
+	Snippet string
+	// Label is the label of the failed term for the errors recovered by
+	// the labeled failures term^label, see Result.Errors.
+	Label string
+}
+
+func (e *ParseError) Error() string {
+	prefix := fmt.Sprintf("%d:%d: ", e.Row, e.Col)
+	if e.Label != "" {
+		prefix += e.Label + ": "
+	}
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("%sunexpected %s\n%s", prefix, e.Found, e.Snippet)
+	}
+	return fmt.Sprintf("%sexpected %s, got %s\n%s",
+		prefix, strings.Join(e.Expected, " or "), e.Found, e.Snippet)
+}
+
+// expect records a failed expectation at position pos. Only expectations
+// at the farthest position are kept. Failures inside of predicates are not
+// recorded, as they do not indicate syntax errors by themselves.
+func (r *Result) expect(pos int, what string) {
+	if r.predicateLevel > 0 || pos < r.farthest {
+		return
+	}
+	if pos > r.farthest || r.expected == nil {
+		r.farthest = pos
+		r.expected = make(map[string]bool)
+	}
+	r.expected[what] = true
+}
+
+// describeCharClass returns the human-readable representation of a char
+// class for the list of expectations.
+func describeCharClass(cc *charclass.CharClass) string {
+	if cc.Special == "[:any:]" && !cc.Negated {
+		return "any character"
+	}
+	if cc.IgnoreCase {
+		return "[" + cc.String() + "]i"
+	}
+	return "[" + cc.String() + "]"
+}
+
+// parseError constructs a ParseError at the farthest failure position.
+// If the farthest failure happened before pos, i.e. the grammar matched
+// successfully but did not consume the input until the end, the error is
+// reported at pos.
+func (r *Result) parseError(pos int) *ParseError {
+	var expected []string
+	if pos <= r.farthest {
+		pos = r.farthest
+		for what := range r.expected {
+			expected = append(expected, what)
+		}
+		sort.Strings(expected)
+	}
+	row, col := r.computeRowCol(pos)
+	found := "end of input"
+	if pos < len(r.Source) {
+		content := r.Source[pos:]
+		if !r.Grammar.ParserOptions.LongErrorMessage && len(content) > 13 {
+			content = content[:10] + "..."
+		}
+		found = strconv.Quote(content)
+	}
+	return &ParseError{
+		Pos:      pos,
+		Row:      row,
+		Col:      col,
+		Expected: expected,
+		Found:    found,
+		Snippet:  snippet(r.Source, pos),
+	}
+}
+
+// snippet returns the line of the source containing pos, followed
+// by a line with a caret at pos.
+func snippet(source string, pos int) string {
+	start := strings.LastIndexByte(source[:pos], '\n') + 1
+	end := strings.IndexByte(source[pos:], '\n')
+	if end < 0 {
+		end = len(source)
+	} else {
+		end += pos
+	}
+	line := strings.TrimSuffix(source[start:end], "\r")
+	var caret []byte
+	for i := start; i < pos; {
+		c, w := utf8.DecodeRuneInString(source[i:])
+		if c == '\t' {
+			caret = append(caret, '\t')
+		} else {
+			caret = append(caret, ' ')
+		}
+		i += w
+	}
+	return line + "\n" + string(caret) + "^"
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var exprGrammar = `
+Expr <- _ Term ( _ Op _ Term )* _
+Term <- Num / "(" Expr ")"
+Op <- < [-+*/] >
+Num <- < [0-9]+ >
+_ <- [ \t\n]*
+`
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		grammar  string
+		input    string
+		pos      int
+		row, col int
+		expected []string
+		snippet  string
+	}{
+		{exprGrammar, "1 + ", 4, 1, 4,
+			[]string{`"("`, "Num", "Term", "[0-9]", `[\t\n ]`},
+			"1 + \n    ^"},
+		{exprGrammar, "1 +\n(2 3)", 7, 2, 3,
+			[]string{`")"`, "Op", "[*+/-]", `[\t\n ]`},
+			"(2 3)\n   ^"},
+		{exprGrammar, "(1))", 3, 1, 3,
+			[]string{"Op", "[*+/-]", `[\t\n ]`, "end of input"},
+			"(1))\n   ^"},
+		{exprGrammar, "\tx", 1, 1, 1,
+			[]string{`"("`, "Num", "Term", "[0-9]", `[\t\n ]`},
+			"\tx\n\t^"},
+		{`A <- !"b" .`, "b", 0, 1, 0, []string{"A"}, "b\n^"},
+		{`A <- "ab" / "a" "c"`, "ax", 1, 1, 1, []string{`"c"`}, "ax\n ^"},
+		{`A <- . .`, "a", 1, 1, 1, []string{"any character"}, "a\n ^"},
+		// The failure after a cut is reported after the cut, even if an
+		// earlier alternative went farther.
+		{`A <- "a" "b" "c" "d" / "a" ~ "x"`, "abcx", 1, 1, 1, []string{`"x"`}, "abcx\n ^"},
+		{"A <- Call / Ident\nCall <- Ident \"(\" ~ Ident \")\"\nIdent <- [a-z]+",
+			"f(x", 3, 1, 3, []string{`")"`, "[a-z]"}, "f(x\n   ^"},
+	}
+	for _, tt := range tests {
+		g, err := New(tt.grammar, nil)
+		if err != nil {
+			t.Errorf("New(%q) returns error %s, want success", tt.grammar, err)
+			continue
+		}
+		_, err = g.Parse(tt.input)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("New(%q).Parse(%q) returns error %v, want *ParseError",
+				tt.grammar, tt.input, err)
+			continue
+		}
+		if perr.Pos != tt.pos || perr.Row != tt.row || perr.Col != tt.col {
+			t.Errorf("New(%q).Parse(%q) returns error at %d (%d:%d), want %d (%d:%d)",
+				tt.grammar, tt.input, perr.Pos, perr.Row, perr.Col, tt.pos, tt.row, tt.col)
+		}
+		if !reflect.DeepEqual(perr.Expected, tt.expected) {
+			t.Errorf("New(%q).Parse(%q) expects %q, want %q",
+				tt.grammar, tt.input, perr.Expected, tt.expected)
+		}
+		if perr.Snippet != tt.snippet {
+			t.Errorf("New(%q).Parse(%q) returns snippet %q, want %q",
+				tt.grammar, tt.input, perr.Snippet, tt.snippet)
+		}
+	}
+}
+
+func TestParseRuleError(t *testing.T) {
+	g, err := New(exprGrammar, nil)
+	if err != nil {
+		t.Fatalf("New(%q) returns error %s, want success", exprGrammar, err)
+	}
+	_, err = g.ParseRule("12x", "Num")
+	var perr *ParseError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &perr) {
+		t.Fatalf("ParseRule(%q, %q) returns error %v, want *ParseError", "12x", "Num", err)
+	}
+	want := "1:2: expected [0-9] or end of input, got \"x\"\n12x\n  ^"
+	if perr.Error() != want {
+		t.Errorf("ParseRule(%q, %q) returns error %q, want %q", "12x", "Num", perr.Error(), want)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// A grammar can be split into multiple files with import directives at the
+// beginning of a file:
+//
+//   import "common.peg"
+//
+// The import path is relative to the directory of the importing file.
+// The rules of all files are merged into one grammar, with the rules of the
+// main file first, so the first rule of the main file is the top rule.
+// Defining the same rule in several files is an error, unless exactly one
+// of the definitions is marked with 'override', e.g.
+//
+//   override Space <- [ \t]*
+//
+// The %skip directive applies to the rules of all files. If several files
+// have the directive, they must name the same rule.
+
+// NewFromFS creates a new parser from the grammar file name in fsys,
+// following the import directives.
+func NewFromFS(fsys fs.FS, name string, options *ParserOptions) (*Grammar, error) {
+	grammar, err := ParseGrammarFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	err = grammar.compile(options)
+	if err != nil {
+		return nil, err
+	}
+	return grammar, nil
+}
+
+// ParseGrammarFS parses the grammar file name in fsys and the files it
+// imports into one Grammar object, without building the parse handlers.
+// Grammar.Source is set to the source of the main file, and Grammar.Files
+// holds the sources of all files.
+func ParseGrammarFS(fsys fs.FS, name string) (*Grammar, error) {
+	name = path.Clean(name)
+	l := &loader{fsys: fsys, files: make(map[string]*Grammar)}
+	err := l.load(name)
+	if err != nil {
+		return nil, err
+	}
+	g := &Grammar{
+		Rules:   make(map[string]*Rule),
+		Source:  l.files[name].Source,
+		Imports: l.files[name].Imports,
+		Files:   make(map[string]string),
+	}
+	defs := make(map[string][]*Rule)
+	for _, file := range l.order {
+		fg := l.files[file]
+		g.Files[file] = fg.Source
+		if fg.Skip != "" && g.Skip != "" && fg.Skip != g.Skip {
+			return nil, fmt.Errorf("%s: %%skip %s conflicts with %%skip %s", file, fg.Skip, g.Skip)
+		}
+		if fg.Skip != "" {
+			g.Skip = fg.Skip
+		}
+		for _, ruleName := range fg.RuleNames {
+			if _, ok := defs[ruleName]; !ok {
+				g.RuleNames = append(g.RuleNames, ruleName)
+			}
+			defs[ruleName] = append(defs[ruleName], fg.Rules[ruleName])
+		}
+	}
+	for _, ruleName := range g.RuleNames {
+		rule, err := g.mergeRule(defs[ruleName])
+		if err != nil {
+			return nil, err
+		}
+		g.Rules[ruleName] = rule
+	}
+	err = g.expandParams()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// loader reads the grammar files and their imports.
+type loader struct {
+	fsys  fs.FS
+	files map[string]*Grammar
+	// order is the list of loaded files in the order of the depth-first
+	// traversal of imports.
+	order []string
+}
+
+func (l *loader) load(name string) error {
+	if _, ok := l.files[name]; ok {
+		return nil
+	}
+	b, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return err
+	}
+	g, err := parseFile(string(b))
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	for _, rule := range g.Rules {
+		rule.File = name
+	}
+	l.files[name] = g
+	l.order = append(l.order, name)
+	for _, imp := range g.Imports {
+		err := l.load(path.Join(path.Dir(name), imp))
+		if err != nil {
+			return fmt.Errorf("%s: import %q: %s", name, imp, err)
+		}
+	}
+	return nil
+}
+
+// mergeRule selects the definition of a rule among the definitions
+// from different files.
+func (g *Grammar) mergeRule(defs []*Rule) (*Rule, error) {
+	var overrides []*Rule
+	for _, rule := range defs {
+		if rule.Override {
+			overrides = append(overrides, rule)
+		}
+	}
+	switch {
+	case len(overrides) > 1:
+		return nil, g.ruleError(overrides[1], fmt.Errorf(
+			"rule %s is already overridden in %s", overrides[1].Ident, g.location(overrides[0])))
+	case len(overrides) == 1 && len(defs) == 1:
+		return nil, g.ruleError(defs[0], fmt.Errorf(
+			"rule %s does not override any rule", defs[0].Ident))
+	case len(overrides) == 1:
+		return overrides[0], nil
+	case len(defs) > 1:
+		return nil, g.ruleError(defs[1], fmt.Errorf(
+			"rule %s is already defined in %s, use 'override' to replace it",
+			defs[1].Ident, g.location(defs[0])))
+	}
+	return defs[0], nil
+}
+
+// location returns the file name, row and column of the rule definition
+// formatted as file:row:col.
+func (g *Grammar) location(rule *Rule) string {
+	source, ok := g.Files[rule.File]
+	if !ok {
+		return rule.File
+	}
+	pos := skipSpace(source, rule.Pos)
+	row, col := countRowCol(source[:pos], 1, 0)
+	return fmt.Sprintf("%s:%d:%d", rule.File, row, col)
+}
+
+// ruleError prefixes err with the location of the rule definition
+// if the grammar was loaded from multiple files.
+func (g *Grammar) ruleError(rule *Rule, err error) error {
+	if rule.File == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %s", g.location(rule), err)
+}
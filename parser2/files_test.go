@@ -0,0 +1,185 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/salikh/peg/tree"
+)
+
+func mapFS(files map[string]string) fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for name, source := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(source)}
+	}
+	return fsys
+}
+
+var commonGrammar = `Ident <- < [a-z]+ >
+Num <- < [0-9]+ >
+_ <- [ \t]*
+List(X) <- X ( _ "," _ X )*
+`
+
+func TestNewFromFS(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		input string
+		want  string
+	}{
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- Ident _ \"=\" _ Num\n",
+			"common.peg": commonGrammar,
+		}, "x = 1", `(A (Ident "x") (Num "1"))`},
+		{map[string]string{
+			"main.peg":   "import 'common.peg'\nA <- List(Ident)\n",
+			"common.peg": commonGrammar,
+		}, "x, y", `(A (List_Ident (Ident "x") (Ident "y")))`},
+		{map[string]string{
+			"main.peg":       "import \"lib/common.peg\"\nA <- Ident\noverride Ident <- < [A-Z]+ >\n",
+			"lib/common.peg": commonGrammar,
+		}, "XY", `(A (Ident "XY"))`},
+		{map[string]string{
+			"main.peg": "import \"lib/a.peg\"\nimport \"lib/b.peg\"\nA <- B C\n",
+			// Imports are relative to the importing file, and each file is
+			// loaded once.
+			"lib/a.peg": "import \"b.peg\"\nB <- < \"b\" >\n",
+			"lib/b.peg": "import \"a.peg\"\nC <- < \"c\" >\n",
+		}, "bc", `(A (B "b") (C "c"))`},
+		{map[string]string{
+			// The %skip directive applies to the imported rules.
+			"main.peg":   "import \"common.peg\"\n%skip _\nList2 <- List(Ident) \";\"\n",
+			"common.peg": commonGrammar,
+		}, "x , y ;", `(List2 (List_Ident (Ident "x") (Ident "y")))`},
+	}
+	for _, tt := range tests {
+		g, err := NewFromFS(mapFS(tt.files), "main.peg", &ParserOptions{SkipEmptyNodes: true})
+		if err != nil {
+			t.Errorf("NewFromFS(%q) returns error %s, want success", tt.files, err)
+			continue
+		}
+		result, err := g.Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returns error %s, want success", tt.input, err)
+			continue
+		}
+		want, err := tree.Parse(tt.want)
+		if err != nil {
+			t.Errorf("error in test, invalid wanted tree %s: %s", tt.want, err)
+			continue
+		}
+		if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+			t.Errorf("Parse(%q) returns tree %s, want %s\ndiffs:\n%s",
+				tt.input, result.Tree, want, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestNewFromFSError(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- Ident\nIdent <- [A-Z]+\n",
+			"common.peg": commonGrammar,
+		}, "common.peg:1:0: rule Ident is already defined in main.peg:3:0, " +
+			"use 'override' to replace it"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- Ident\noverride Other <- [A-Z]+\n",
+			"common.peg": commonGrammar,
+		}, "main.peg:3:0: rule Other does not override any rule"},
+		{map[string]string{
+			"main.peg": "import \"a.peg\"\nimport \"b.peg\"\nA <- B\n",
+			"a.peg":    "override B <- \"a\"\n",
+			"b.peg":    "override B <- \"b\"\n",
+		}, "b.peg:1:0: rule B is already overridden in a.peg:1:0"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- List(Ident, Num)\n",
+			"common.peg": commonGrammar,
+		}, "main.peg:2:0: rule List expects 1 arguments, got 2"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\nA <- Ident\n",
+			"common.peg": "Ident <- ([a-z]\n",
+		}, "main.peg: import \"common.peg\": common.peg: could not parse grammar source"},
+		{map[string]string{
+			"main.peg": "import \"missing.peg\"\nA <- \"a\"\n",
+		}, "main.peg: import \"missing.peg\": open missing.peg"},
+		{map[string]string{
+			"main.peg":   "import \"common.peg\"\n%skip _\nA <- Ident\n",
+			"common.peg": "%skip Space\n" + commonGrammar + "Space <- \" \"*\n",
+		}, "common.peg: %skip Space conflicts with %skip _"},
+	}
+	for _, tt := range tests {
+		_, err := NewFromFS(mapFS(tt.files), "main.peg", nil)
+		if err == nil {
+			t.Errorf("NewFromFS(%q) returns success, want error %q", tt.files, tt.want)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("NewFromFS(%q) returns error %q, want %q", tt.files, err, tt.want)
+		}
+	}
+}
+
+func TestParseGrammarImport(t *testing.T) {
+	for _, source := range []string{
+		"import \"common.peg\"\nA <- Ident\n",
+		"A <- \"a\"\noverride B <- \"b\"\n",
+	} {
+		_, err := ParseGrammar(source)
+		if err == nil {
+			t.Errorf("ParseGrammar(%q) returns success, want error", source)
+		}
+	}
+}
+
+func TestLintFS(t *testing.T) {
+	fsys := mapFS(map[string]string{
+		"main.peg":   "import \"common.peg\"\nA <- Ident B\n",
+		"common.peg": "Ident <- [a-z]+\n\nUnused <- \"u\"\n",
+	})
+	g, err := ParseGrammarFS(fsys, "main.peg")
+	if err != nil {
+		t.Fatalf("ParseGrammarFS returns error %s, want success", err)
+	}
+	var got []string
+	for _, issue := range Lint(g) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"common.peg:3:0: rule Unused is unreachable from the start rule A (unreachable)",
+		"main.peg:2:11: undefined rule B (undefined)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint returns\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestOverrideRuleName(t *testing.T) {
+	// 'override' is only a marker when followed by a rule definition.
+	source := "A <- override\noverride <- \"o\"\n"
+	g, err := New(source, nil)
+	if err != nil {
+		t.Fatalf("New(%q) returns error %s, want success", source, err)
+	}
+	if _, err := g.Parse("o"); err != nil {
+		t.Errorf("Parse(%q) returns error %s, want success", "o", err)
+	}
+}
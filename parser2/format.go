@@ -0,0 +1,461 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/salikh/peg/parser"
+)
+
+// formatWidth is the width of the formatted lines, above which the choices
+// are wrapped with one alternative per line.
+const formatWidth = 80
+
+// Format returns the grammar source in the canonical layout. Every rule
+// takes one line, with the arrows <- of the adjacent rules aligned, unless
+// the rule is a choice longer than formatWidth, which is wrapped with one
+// alternative per line:
+//
+//	Expr <- Term
+//	      / Expr "+" Term
+//	Term <- [0-9]+
+//
+// The terms are separated by single spaces, the literals are written in
+// double quotes, and the levels of precedence tables are written on their
+// own lines. The # comments are kept: a comment at the end of a line stays
+// after the same rule, alternative or precedence level, and the other
+// comments are written on their own lines before the next rule or
+// alternative. At most one blank line is kept between the rules, and the
+// blank lines end the blocks of aligned rules. Format is idempotent.
+func Format(source string) (string, error) {
+	result, err := parse(source)
+	if err != nil {
+		return "", fmt.Errorf("could not parse grammar source: %s", err)
+	}
+	if err := checkLiterals(result.Tree, source); err != nil {
+		return "", err
+	}
+	f := &formatter{source: source, comments: scanComments(source)}
+	f.lineStarts = []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			f.lineStarts = append(f.lineStarts, i+1)
+		}
+	}
+	return f.format(result.Tree), nil
+}
+
+// comment is a # comment of the grammar source.
+type comment struct {
+	// pos and end are the byte positions of the comment and of the end
+	// of its line.
+	pos, end int
+	text     string
+	// blank is set if the comment follows a blank line.
+	blank bool
+}
+
+// segment is a part of a grammar item that is written on its own line
+// if the item is wrapped: an alternative of a choice, or the header or
+// a level of a precedence table.
+type segment struct {
+	start, end int
+	text       string
+	// leading are the comments written before the segment, and trailing
+	// is the comment at the end of its line.
+	leading  []*comment
+	trailing *comment
+}
+
+// item is a directive or a rule of the grammar.
+type item struct {
+	leading []*comment
+	// blank is set if the item follows a blank line.
+	blank bool
+	// lhs is the left-hand side of a rule, or empty for the directives.
+	lhs      string
+	prec     bool
+	segments []*segment
+}
+
+type formatter struct {
+	source   string
+	comments []*comment
+	// lineStarts are the byte positions of the beginnings of the lines.
+	lineStarts []int
+}
+
+// scanComments returns the # comments of the grammar source, skipping
+// the literals and char classes.
+func scanComments(source string) []*comment {
+	var comments []*comment
+	for i := 0; i < len(source); {
+		switch source[i] {
+		case '"', '\'', '`':
+			n := parser.ScanLiteral(source[i:])
+			if n < 0 {
+				return comments
+			}
+			i += n
+		case '[':
+			i += scanCharClass(source[i:])
+		case '#':
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source)
+			} else {
+				end += i
+			}
+			comments = append(comments, &comment{pos: i, end: end,
+				text: strings.TrimRight(source[i:end], " \t\r")})
+			i = end
+		default:
+			i++
+		}
+	}
+	return comments
+}
+
+// scanCharClass returns the length of the char class at the beginning of s,
+// including the nested char classes.
+func scanCharClass(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// line returns the 0-based line number of the byte position pos.
+func (f *formatter) line(pos int) int {
+	return sort.SearchInts(f.lineStarts, pos+1) - 1
+}
+
+// blankBetween reports whether there is a blank line between the byte
+// positions from and to, which are only separated by whitespace.
+func (f *formatter) blankBetween(from, to int) bool {
+	return from > 0 && strings.Count(f.source[from:to], "\n") >= 2
+}
+
+// takeComments returns the comments before the byte position to, setting
+// their blank flags relative to the byte position *last, which is advanced
+// to the end of the last comment.
+func (f *formatter) takeComments(to int, last *int) []*comment {
+	var r []*comment
+	for len(f.comments) > 0 && f.comments[0].pos < to {
+		c := f.comments[0]
+		f.comments = f.comments[1:]
+		c.blank = f.blankBetween(*last, c.pos)
+		*last = c.end
+		r = append(r, c)
+	}
+	return r
+}
+
+func nodeEnd(n *parser.Node) int {
+	return n.Pos + n.Len
+}
+
+func (f *formatter) format(tree *parser.Node) string {
+	var items []*item
+	last := 0
+	for _, n := range tree.Children {
+		it := &item{}
+		start := skipSpace(f.source, n.Pos)
+		it.leading = f.takeComments(start, &last)
+		it.blank = f.blankBetween(last, start)
+		f.convertItem(it, n)
+		// The comments inside the item are attached to the segments.
+		end := nodeEnd(n.Children[len(n.Children)-1])
+		for len(f.comments) > 0 && f.comments[0].pos < end {
+			c := f.comments[0]
+			f.comments = f.comments[1:]
+			j := 0
+			for j < len(it.segments)-1 && it.segments[j].end <= c.pos {
+				j++
+			}
+			if j > 0 && f.line(c.pos) == f.line(it.segments[j-1].end) {
+				it.segments[j-1].trailing = c
+				continue
+			}
+			it.segments[j].leading = append(it.segments[j].leading, c)
+		}
+		last = end
+		if len(f.comments) > 0 && f.line(f.comments[0].pos) == f.line(end) {
+			it.segments[len(it.segments)-1].trailing = f.comments[0]
+			last = f.comments[0].end
+			f.comments = f.comments[1:]
+		}
+		items = append(items, it)
+	}
+	footer := f.takeComments(len(f.source), &last)
+
+	var lines []string
+	emit := func(blank bool, line string) {
+		if blank && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, line)
+	}
+	emitComments := func(comments []*comment, indent string) {
+		for _, c := range comments {
+			emit(c.blank, indent+c.text)
+		}
+	}
+	pad := 0
+	for i, it := range items {
+		if i == 0 || it.lhs == "" || it.blank || hasBlank(it.leading) || items[i-1].lhs == "" {
+			// Start a new block of aligned rules.
+			pad = 0
+			for _, next := range items[i:] {
+				if next.lhs == "" || (next != it && (next.blank || hasBlank(next.leading))) {
+					break
+				}
+				if w := utf8.RuneCountInString(next.lhs); w > pad {
+					pad = w
+				}
+			}
+		}
+		emitComments(it.leading, "")
+		segs := it.segments
+		blank := it.blank
+		if len(segs[0].leading) > 0 {
+			// The comments inside the rule before the first alternative are
+			// written before the rule.
+			segs[0].leading[0].blank = blank
+			emitComments(segs[0].leading, "")
+			blank = false
+		}
+		if it.lhs == "" {
+			emit(blank, segs[0].text+trailing(segs[0]))
+			continue
+		}
+		head := it.lhs + strings.Repeat(" ", pad-utf8.RuneCountInString(it.lhs)) + " <- "
+		if it.prec {
+			emit(blank, head+segs[0].text+trailing(segs[0]))
+			for _, seg := range segs[1:] {
+				emitComments(seg.leading, "  ")
+				emit(false, "  "+seg.text+trailing(seg))
+			}
+			continue
+		}
+		var alts []string
+		wrap := false
+		for j, seg := range segs {
+			alts = append(alts, seg.text)
+			wrap = wrap || (j > 0 && len(seg.leading) > 0) || (j < len(segs)-1 && seg.trailing != nil)
+		}
+		line := head + strings.Join(alts, " / ")
+		if len(segs) == 1 || (!wrap && utf8.RuneCountInString(line) <= formatWidth) {
+			emit(blank, line+trailing(segs[len(segs)-1]))
+			continue
+		}
+		emit(blank, head+segs[0].text+trailing(segs[0]))
+		indent := strings.Repeat(" ", utf8.RuneCountInString(head)-3)
+		for _, seg := range segs[1:] {
+			emitComments(seg.leading, indent)
+			emit(false, indent+"/ "+seg.text+trailing(seg))
+		}
+	}
+	emitComments(footer, "")
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// hasBlank reports whether any of the comments follows a blank line.
+func hasBlank(comments []*comment) bool {
+	for _, c := range comments {
+		if c.blank {
+			return true
+		}
+	}
+	return false
+}
+
+func trailing(seg *segment) string {
+	if seg.trailing == nil {
+		return ""
+	}
+	return " " + seg.trailing.text
+}
+
+// convertItem sets the left-hand side and the segments of the item from
+// the syntax tree node of a directive or a rule.
+func (f *formatter) convertItem(it *item, n *parser.Node) {
+	start := skipSpace(f.source, n.Pos)
+	switch n.Label {
+	case "Import":
+		it.segments = []*segment{{start: start, end: nodeEnd(n),
+			text: "import " + formatLiteral(n.Children[0].Text)}}
+		return
+	case "Skip":
+		it.segments = []*segment{{start: start, end: nodeEnd(n),
+			text: "%skip " + n.Children[0].Text}}
+		return
+	}
+	var lhs []string
+	for _, c := range n.Children {
+		switch c.Label {
+		case "Override", "Token", "Marker":
+			lhs = append(lhs, c.Text)
+		case "Ident":
+			lhs = append(lhs, c.Text)
+		case "Params":
+			var params []string
+			for _, p := range c.Children {
+				params = append(params, p.Text)
+			}
+			lhs[len(lhs)-1] += "(" + strings.Join(params, ", ") + ")"
+		case "Prec":
+			it.prec = true
+			operand := c.Children[0]
+			it.segments = append(it.segments, &segment{start: skipSpace(f.source, c.Pos), end: nodeEnd(operand),
+				text: "%prec " + operand.Text})
+			for _, level := range c.Children[1:] {
+				text := "%" + level.Text
+				for _, op := range level.Children {
+					text += " " + formatPrecOp(op)
+				}
+				it.segments = append(it.segments, &segment{start: skipSpace(f.source, level.Pos), end: nodeEnd(level), text: text})
+			}
+		case "RHS":
+			for _, terms := range c.Children {
+				it.segments = append(it.segments, &segment{start: skipSpace(f.source, terms.Pos), end: nodeEnd(terms),
+					text: formatTerms(terms)})
+			}
+		}
+	}
+	it.lhs = strings.Join(lhs, " ")
+}
+
+func formatPrecOp(op *parser.Node) string {
+	r := ""
+	for _, c := range op.Children {
+		switch c.Label {
+		case "Label":
+			r += c.Text + ":"
+		case "Literal":
+			r += formatLiteral(c.Text)
+		}
+	}
+	return r
+}
+
+// formatLiteral returns the literal in double quotes.
+func formatLiteral(lit string) string {
+	// The literals are checked by Format before formatting.
+	val, _ := parser.UnquoteLiteral(lit)
+	return strconv.Quote(val)
+}
+
+func formatRHS(n *parser.Node) string {
+	var r []string
+	for _, terms := range n.Children {
+		r = append(r, formatTerms(terms))
+	}
+	return strings.Join(r, " / ")
+}
+
+// formatTerms returns the sequence of terms separated by spaces. The
+// repetitions and recovery labels are attached to the preceding terms.
+func formatTerms(n *parser.Node) string {
+	var r []string
+	for _, term := range n.Children {
+		s := formatTerm(term)
+		c := term.Children[0]
+		postfix := c.Label == "Repeat" || c.Label == "Recover" || (c.Label == "Special" && c.Text != ".")
+		if postfix && len(r) > 0 {
+			r[len(r)-1] += s
+			continue
+		}
+		r = append(r, s)
+	}
+	return strings.Join(r, " ")
+}
+
+func formatTerm(term *parser.Node) string {
+	c := term.Children[0]
+	var s string
+	switch c.Label {
+	case "Parens":
+		s = "(" + formatRHS(c.Children[0]) + ")"
+	case "SemPred":
+		s = "&{" + c.Children[0].Text + "}"
+	case "SemNegPred":
+		s = "!{" + c.Children[0].Text + "}"
+	case "NegPred":
+		s = "!" + formatTerm(c.Children[0])
+	case "Pred":
+		s = "&" + formatTerm(c.Children[0])
+	case "Capture":
+		if label := c.Children[0]; label.Label == "Label" {
+			s = "<" + label.Text + ": " + formatRHS(c.Children[1]) + ">"
+		} else {
+			s = "<" + formatRHS(label) + ">"
+		}
+	case "CharClass":
+		s = "[" + c.Text + "]"
+	case "Literal":
+		s = formatLiteral(c.Text)
+	case "Labeled":
+		s = c.Children[0].Text + ":" + formatCall(c.Children[1])
+	case "Call", "Ident":
+		s = formatCall(c)
+	case "BackRef":
+		s = "$" + c.Text
+	case "Repeat":
+		s = "{" + c.Text + "}"
+	case "Recover":
+		s = "^" + c.Children[0].Text
+	default:
+		// Special, Cut and Indent.
+		s = c.Text
+	}
+	if len(term.Children) > 1 {
+		// IgnoreCase.
+		s += term.Children[1].Text
+	}
+	return s
+}
+
+// formatCall returns the rule reference, which is an Ident node, or
+// a parameterized rule invocation, which is a Call node.
+func formatCall(n *parser.Node) string {
+	if n.Label == "Ident" {
+		return n.Text
+	}
+	var args []string
+	for _, arg := range n.Children[1:] {
+		args = append(args, formatCall(arg.Children[0]))
+	}
+	return n.Children[0].Text + "(" + strings.Join(args, ", ") + ")"
+}
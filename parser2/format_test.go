@@ -0,0 +1,139 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/salikh/peg/compat/runfiles"
+	"github.com/salikh/peg/tests"
+)
+
+func TestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		source, want string
+	}{
+		{"A<-B  C*", "A <- B C*\n"},
+		{"Ident <- 'a' `b\"` \"\\x41\" [a-z]i 'k'i", "Ident <- \"a\" \"b\\\"\" \"A\" [a-z]i \"k\"i\n"},
+		{`Word <- < [a-z] + > ( "," Word ) ? !. &{p}`, `Word <- <[a-z]+> ("," Word)? !. &{p}` + "\n"},
+		{"A <- B\nLong <- C\n\nX <- Y", "A    <- B\nLong <- C\n\nX <- Y\n"},
+		{"import 'a.peg'\n%skip _\n\n\n\nA <- x:B $x ^r\n%token _ <- ' '*",
+			"import \"a.peg\"\n%skip _\n\nA        <- x:B $x^r\n%token _ <- \" \"*\n"},
+		{"override inline A(x, y) <- List( x , y ) INDENT ~", "override inline A(x, y) <- List(x, y) INDENT ~\n"},
+		{`Expr <- %prec Num %left Add:"+" '-' %prefix "-"`, "Expr <- %prec Num\n  %left Add:\"+\" \"-\"\n  %prefix \"-\"\n"},
+		{
+			// The comments on their own lines are kept before the next rule,
+			// and the comments at the end of a line after the same rule.
+			"# Header.\n\n# Rules.\nA <- B # first\n# About C.\nC <- D\n\n\n# Footer.\n",
+			"# Header.\n\n# Rules.\nA <- B # first\n# About C.\nC <- D\n\n# Footer.\n",
+		},
+		{
+			// A comment after an alternative wraps the choice.
+			"A <- B # b\n / C\n # c\n / D",
+			"A <- B # b\n  / C\n  # c\n  / D\n",
+		},
+		{
+			// A comment inside the first alternative is moved before the rule.
+			"A <- B # b\n 'c'",
+			"# b\nA <- B \"c\"\n",
+		},
+		{
+			"Expression <- Identifier \"=\" Expression / Identifier \"(\" Arguments \")\" / Literal / Number",
+			"Expression <- Identifier \"=\" Expression\n" +
+				"           / Identifier \"(\" Arguments \")\"\n" +
+				"           / Literal\n" +
+				"           / Number\n",
+		},
+		{"# Only a comment.\nA <- '#' [#] # comment", "# Only a comment.\nA <- \"#\" [#] # comment\n"},
+	} {
+		got, err := Format(tt.source)
+		if err != nil {
+			t.Errorf("Format(%q) returns error %s, want success", tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q) returns\n%s---, want\n%s---", tt.source, got, tt.want)
+		}
+		if again, err := Format(got); err != nil || again != got {
+			t.Errorf("Format(%q) returns %q, %v, want the same source", got, again, err)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	for _, source := range []string{"A <- (", `A <- "\q"`} {
+		if got, err := Format(source); err == nil {
+			t.Errorf("Format(%q) returns %q, want error", source, got)
+		}
+	}
+}
+
+// checkFormat checks that formatting the grammar source is idempotent and
+// does not change the grammar.
+func checkFormat(t *testing.T, name, source string) {
+	formatted, err := Format(source)
+	if err != nil {
+		t.Errorf("%s: Format returns error %s, want success", name, err)
+		return
+	}
+	if again, err := Format(formatted); err != nil || again != formatted {
+		t.Errorf("%s: Format is not idempotent, got\n%s---, want\n%s---", name, again, formatted)
+	}
+	want, err := ParseGrammar(source)
+	if err != nil {
+		// Format does not check the semantics of the grammar.
+		return
+	}
+	got, err := ParseGrammar(formatted)
+	if err != nil {
+		t.Errorf("%s: ParseGrammar(%q) returns error %s, want success", name, formatted, err)
+		return
+	}
+	if got.String() != want.String() {
+		t.Errorf("%s: Format returns grammar\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestFormatTestdata(t *testing.T) {
+	dirname := runfiles.Path("github.com/salikh/peg/tests/testdata")
+	names, err := filepath.Glob(filepath.Join(dirname, "*.g"))
+	if err != nil || len(names) == 0 {
+		t.Fatalf("Cannot list testdata: %v", err)
+	}
+	for _, name := range names {
+		source, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Errorf("Error reading %q: %s", name, err)
+			continue
+		}
+		checkFormat(t, filepath.Base(name), string(source))
+	}
+}
+
+func TestFormatTests(t *testing.T) {
+	for i, test := range tests.Positive {
+		checkFormat(t, fmt.Sprintf("Positive[%d]", i), test.Grammar)
+	}
+	for _, suite := range [][]tests.TreeTest{tests.LeftRecursion, tests.Parameterized,
+		tests.IgnoreCase, tests.Labels, tests.Markers, tests.Cut, tests.Repeat, tests.CharClass,
+		tests.Recover, tests.Indent, tests.BackRef, tests.Skip, tests.Precedence, tests.ByteMode} {
+		for _, test := range suite {
+			checkFormat(t, test.Grammar, test.Grammar)
+		}
+	}
+}
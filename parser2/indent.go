@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import "fmt"
+
+// The indentation terms support the offside rule of Python-like languages:
+//
+//	Block <- INDENT Stmt (SAMEDENT Stmt)* DEDENT
+//
+// The parser keeps a stack of indentation widths, which initially holds
+// width 0. The indentation of a line is the width of the spaces and tabs at
+// the current position, with a tab advancing the width to the next multiple
+// of 8. INDENT matches the indentation that is wider than the top of the
+// stack and pushes its width. SAMEDENT matches the indentation that is as
+// wide as the top of the stack. DEDENT matches the empty input if the
+// indentation is narrower than the top of the stack, or at the end of the
+// input, and pops the stack.
+//
+// The stack is a part of the parser state that is restored when an
+// alternative fails, and of the memo key, since the same rule may match
+// differently at the same position with different stacks.
+
+// indentTerms lists the names of the indentation terms, which are reserved.
+var indentTerms = map[string]bool{"INDENT": true, "DEDENT": true, "SAMEDENT": true}
+
+// indentLevel is an element of the indentation stack. The stack is
+// immutable, so it is saved and restored by copying the pointer, and it is
+// interned by pushIndent, so that equal stacks are represented by the same
+// pointer. The nil stack holds the single width 0.
+type indentLevel struct {
+	width int
+	next  *indentLevel
+}
+
+func (l *indentLevel) top() int {
+	if l == nil {
+		return 0
+	}
+	return l.width
+}
+
+// pushIndent returns the interned stack with width pushed onto the current
+// stack.
+func (r *Result) pushIndent(width int) *indentLevel {
+	key := indentLevel{width, r.indent}
+	if l, ok := r.indents[key]; ok {
+		return l
+	}
+	if r.indents == nil {
+		r.indents = make(map[indentLevel]*indentLevel)
+	}
+	l := &key
+	r.indents[key] = l
+	return l
+}
+
+// indentation returns the width of the spaces and tabs at pos and their
+// length in bytes.
+func indentation(s string, pos int) (width, n int) {
+	for ; pos+n < len(s); n++ {
+		switch s[pos+n] {
+		case ' ':
+			width++
+		case '\t':
+			width += 8 - width%8
+		default:
+			return width, n
+		}
+	}
+	return width, n
+}
+
+// makeIndentHandler makes the handler of an indentation term.
+func makeIndentHandler(name string) handler {
+	return func(r *Result, pos int) (int, error) {
+		width, n := indentation(r.Source, pos)
+		top := r.indent.top()
+		switch {
+		case name == "INDENT" && width > top:
+			r.indent = r.pushIndent(width)
+			return n, nil
+		case name == "SAMEDENT" && width == top:
+			return n, nil
+		case name == "DEDENT" && r.indent != nil && (width < top || pos+n == len(r.Source)):
+			r.indent = r.indent.next
+			return 0, nil
+		}
+		r.expect(pos, name)
+		return 0, fmt.Errorf("expected %s, got indentation %d at level %d", name, width, top)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Left recursion is supported using the seed-growing technique. The rules
+// that can invoke themselves without consuming any input are grouped into
+// strongly connected components of the "first call" graph. In each component
+// one rule is chosen as a leader, so that every cycle of the component passes
+// through it. The leader is applied repeatedly at the same position with its
+// previous result memoized as a seed until the match stops growing. The other
+// rules of the component are not memoized at all, because their results
+// depend on the current seed of the leader.
+
+// computeNullable returns the set of rules that can match without consuming
+// any input.
+func (g *Grammar) computeNullable() map[string]bool {
+	nullable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, name := range g.RuleNames {
+			if nullable[name] {
+				continue
+			}
+			if rhsNullable(g.Rules[name].RHS, nullable) {
+				nullable[name] = true
+				changed = true
+			}
+		}
+	}
+	return nullable
+}
+
+// rhsNullable returns true if some choice of rhs can match empty input,
+// given the set of nullable rules.
+func rhsNullable(rhs *RHS, nullable map[string]bool) bool {
+	for _, terms := range rhs.Terms {
+		if termsNullable(terms, nullable) {
+			return true
+		}
+	}
+	return false
+}
+
+func termsNullable(terms []*Term, nullable map[string]bool) bool {
+	for _, term := range terms {
+		if !termNullable(term, nullable) {
+			return false
+		}
+	}
+	return true
+}
+
+func termNullable(term *Term, nullable map[string]bool) bool {
+	switch {
+	case term.Recover != "":
+		// The recovery rule is applied at the same position if the term fails.
+		t := *term
+		t.Recover = ""
+		return termNullable(&t, nullable) || nullable[term.Recover]
+	case term.Parens != nil:
+		return rhsNullable(term.Parens, nullable)
+	case term.NegPred != nil, term.Pred != nil, term.SemPred != "", term.SemNegPred != "", term.Cut:
+		return true
+	case term.Special != nil:
+		if term.Special.Rune == '+' || term.Special.Rune == '{' && term.Special.Min > 0 {
+			return termNullable(term.Special.Term, nullable)
+		}
+		return true
+	case term.Capture != nil:
+		return rhsNullable(term.Capture, nullable)
+	case term.BackRef != "":
+		// The captured text may be empty.
+		return true
+	case term.Indent != "":
+		// INDENT matches at least one space or tab.
+		return term.Indent != "INDENT"
+	case term.CharClass != nil:
+		return false
+	case term.Literal != "":
+		return false
+	case term.Ident != "":
+		return nullable[term.Ident]
+	}
+	return false
+}
+
+// firstCalls computes the "first call" graph of the grammar: for every rule
+// the set of rules that it may invoke at its own starting position. If
+// backward is true, the sequences are scanned from the end, as the backward
+// parser does.
+func (g *Grammar) firstCalls(nullable map[string]bool, backward bool) map[string]map[string]bool {
+	graph := make(map[string]map[string]bool)
+	for _, name := range g.RuleNames {
+		calls := make(map[string]bool)
+		rhsFirstCalls(g.Rules[name].RHS, nullable, backward, calls)
+		graph[name] = calls
+	}
+	return graph
+}
+
+func rhsFirstCalls(rhs *RHS, nullable map[string]bool, backward bool, calls map[string]bool) {
+	for _, terms := range rhs.Terms {
+		for i := range terms {
+			term := terms[i]
+			if backward {
+				term = terms[len(terms)-1-i]
+			}
+			termFirstCalls(term, nullable, backward, calls)
+			if !termNullable(term, nullable) {
+				break
+			}
+		}
+	}
+}
+
+func termFirstCalls(term *Term, nullable map[string]bool, backward bool, calls map[string]bool) {
+	if term.Recover != "" {
+		calls[term.Recover] = true
+	}
+	switch {
+	case term.Parens != nil:
+		rhsFirstCalls(term.Parens, nullable, backward, calls)
+	case term.NegPred != nil:
+		termFirstCalls(term.NegPred, nullable, backward, calls)
+	case term.Pred != nil:
+		termFirstCalls(term.Pred, nullable, backward, calls)
+	case term.Special != nil:
+		termFirstCalls(term.Special.Term, nullable, backward, calls)
+	case term.Capture != nil:
+		rhsFirstCalls(term.Capture, nullable, backward, calls)
+	case term.Ident != "":
+		calls[term.Ident] = true
+	}
+}
+
+// leftRecursion describes the role of a rule in left recursion.
+type leftRecursion struct {
+	// recursive is true if the rule participates in a left-recursive cycle.
+	recursive bool
+	// leader is true if the rule grows the seed for its cycle.
+	leader bool
+}
+
+// computeLeftRecursion finds left-recursive rules and assigns leaders
+// to each group of mutually left-recursive rules. It returns an error
+// if there is no rule that takes part in all cycles of a group.
+func (g *Grammar) computeLeftRecursion(backward bool) (map[string]leftRecursion, error) {
+	graph := g.firstCalls(g.computeNullable(), backward)
+	ret := make(map[string]leftRecursion)
+	for _, scc := range stronglyConnected(g.RuleNames, graph) {
+		if len(scc) == 1 {
+			name := scc[0]
+			if graph[name][name] {
+				ret[name] = leftRecursion{recursive: true, leader: true}
+			}
+			continue
+		}
+		leader := ""
+		for _, name := range scc {
+			if !hasCycle(scc, name, graph) {
+				leader = name
+				break
+			}
+		}
+		if leader == "" {
+			return nil, fmt.Errorf("left-recursive rules %s have no rule that "+
+				"takes part in all cycles", strings.Join(scc, ", "))
+		}
+		for _, name := range scc {
+			ret[name] = leftRecursion{recursive: true, leader: name == leader}
+		}
+	}
+	return ret, nil
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// using Tarjan's algorithm. The rules within each component are listed
+// in the grammar order.
+func stronglyConnected(names []string, graph map[string]map[string]bool) [][]string {
+	order := make(map[string]int)
+	for i, name := range names {
+		order[name] = i
+	}
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var ret [][]string
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, next := range sortedKeys(graph[name], order) {
+			if _, ok := index[next]; !ok {
+				visit(next)
+				if lowlink[next] < lowlink[name] {
+					lowlink[name] = lowlink[next]
+				}
+			} else if onStack[next] && index[next] < lowlink[name] {
+				lowlink[name] = index[next]
+			}
+		}
+		if lowlink[name] != index[name] {
+			return
+		}
+		var scc []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			scc = append(scc, last)
+			if last == name {
+				break
+			}
+		}
+		sort.Slice(scc, func(i, j int) bool { return order[scc[i]] < order[scc[j]] })
+		ret = append(ret, scc)
+	}
+	for _, name := range names {
+		if _, ok := index[name]; !ok {
+			visit(name)
+		}
+	}
+	return ret
+}
+
+// sortedKeys returns the rule names from the set in the grammar order.
+// Unknown rule names are skipped.
+func sortedKeys(set map[string]bool, order map[string]int) []string {
+	var r []string
+	for name := range set {
+		if _, ok := order[name]; ok {
+			r = append(r, name)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool { return order[r[i]] < order[r[j]] })
+	return r
+}
+
+// hasCycle checks whether the subgraph induced by the rules in scc
+// still has a cycle after removing the rule skip.
+func hasCycle(scc []string, skip string, graph map[string]map[string]bool) bool {
+	in := make(map[string]bool)
+	for _, name := range scc {
+		if name != skip {
+			in[name] = true
+		}
+	}
+	// 0: not visited, 1: in progress, 2: done.
+	state := make(map[string]int)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = 1
+		for next := range graph[name] {
+			if !in[next] {
+				continue
+			}
+			if state[next] == 1 {
+				return true
+			}
+			if state[next] == 0 && visit(next) {
+				return true
+			}
+		}
+		state[name] = 2
+		return false
+	}
+	for _, name := range scc {
+		if in[name] && state[name] == 0 && visit(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintKind is the category of a problem found by Lint.
+type LintKind string
+
+const (
+	// LintUndefined is a reference to a rule that is not defined.
+	LintUndefined LintKind = "undefined"
+	// LintUnreachable is a rule that cannot be reached from the start rule.
+	LintUnreachable LintKind = "unreachable"
+	// LintShadowed is a choice that can never match, because an earlier
+	// choice of the same ordered choice always matches first.
+	LintShadowed LintKind = "shadowed"
+	// LintNullableRepetition is a repetition (* or +) of an expression
+	// that can match empty input.
+	LintNullableRepetition LintKind = "nullable-repetition"
+	// LintLeftRecursion is a left-recursive rule.
+	LintLeftRecursion LintKind = "left-recursion"
+)
+
+// LintIssue is a problem found in a grammar by Lint.
+type LintIssue struct {
+	Kind LintKind
+	// Rule is the name of the rule where the problem was found.
+	Rule string
+	// File is the name of the file where the rule is defined, if the grammar
+	// was loaded with ParseGrammarFS.
+	File string
+	// Pos is the byte position of the problem in the grammar source.
+	Pos int
+	// Row is the line number of the problem in the grammar source. 1-based.
+	Row int
+	// Col is the column number of the problem in the grammar source. 0-based.
+	Col int
+	// Message is the human-readable description of the problem.
+	Message string
+}
+
+func (issue *LintIssue) String() string {
+	s := fmt.Sprintf("%d:%d: %s (%s)", issue.Row, issue.Col, issue.Message, issue.Kind)
+	if issue.File != "" {
+		return issue.File + ":" + s
+	}
+	return s
+}
+
+// Lint checks the grammar for common problems: references to undefined
+// rules, rules unreachable from the start rule, choices shadowed by earlier
+// choices, repetitions of expressions that can match empty input, and
+// left recursion. The grammar does not need to be valid for parsing, so it
+// can be obtained with ParseGrammar or ParseGrammarFS. The issues are sorted
+// by file and position.
+func Lint(g *Grammar) []*LintIssue {
+	l := &linter{g: g, nullable: g.computeNullable()}
+	l.checkUnreachable()
+	for _, name := range g.RuleNames {
+		rule := g.Rules[name]
+		l.rule = name
+		l.checkRHS(rule.RHS)
+		forEachTerm(rule.RHS, func(term *Term) {
+			l.checkTerm(term)
+			if term.Parens != nil {
+				l.checkRHS(term.Parens)
+			}
+			if term.Capture != nil {
+				l.checkRHS(term.Capture)
+			}
+		})
+	}
+	l.checkLeftRecursion()
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].File != l.issues[j].File {
+			return l.issues[i].File < l.issues[j].File
+		}
+		return l.issues[i].Pos < l.issues[j].Pos
+	})
+	return l.issues
+}
+
+type linter struct {
+	g        *Grammar
+	nullable map[string]bool
+	// rule is the name of the rule being checked.
+	rule   string
+	issues []*LintIssue
+}
+
+func (l *linter) report(kind LintKind, rule string, pos int, format string, args ...interface{}) {
+	source := l.g.Source
+	var file string
+	if r, ok := l.g.Rules[rule]; ok && r.File != "" {
+		file = r.File
+		source = l.g.Files[file]
+	}
+	pos = skipSpace(source, pos)
+	row, col := countRowCol(source[:pos], 1, 0)
+	l.issues = append(l.issues, &LintIssue{
+		Kind:    kind,
+		Rule:    rule,
+		File:    file,
+		Pos:     pos,
+		Row:     row,
+		Col:     col,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// skipSpace skips the whitespace and comments in the grammar source
+// starting from pos.
+func skipSpace(source string, pos int) int {
+	if pos > len(source) {
+		return len(source)
+	}
+	for pos < len(source) {
+		switch source[pos] {
+		case ' ', '\t', '\r', '\n':
+			pos++
+		case '#':
+			end := strings.IndexByte(source[pos:], '\n')
+			if end < 0 {
+				return len(source)
+			}
+			pos += end + 1
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// forEachTerm calls f for each term of rhs, including the nested terms.
+func forEachTerm(rhs *RHS, f func(*Term)) {
+	for _, terms := range rhs.Terms {
+		for _, term := range terms {
+			forEachSubterm(term, f)
+		}
+	}
+}
+
+func forEachSubterm(term *Term, f func(*Term)) {
+	f(term)
+	switch {
+	case term.Parens != nil:
+		forEachTerm(term.Parens, f)
+	case term.NegPred != nil:
+		forEachSubterm(term.NegPred, f)
+	case term.Pred != nil:
+		forEachSubterm(term.Pred, f)
+	case term.Special != nil:
+		forEachSubterm(term.Special.Term, f)
+	case term.Capture != nil:
+		forEachTerm(term.Capture, f)
+	}
+}
+
+func (l *linter) checkUnreachable() {
+	if len(l.g.RuleNames) == 0 {
+		return
+	}
+	start := l.g.RuleNames[0]
+	reached := map[string]bool{start: true}
+	queue := []string{start}
+	if _, ok := l.g.Rules[l.g.Skip]; ok && !reached[l.g.Skip] {
+		// The skip rule is referenced implicitly by the syntactic rules.
+		reached[l.g.Skip] = true
+		queue = append(queue, l.g.Skip)
+	}
+	for len(queue) > 0 {
+		rule := l.g.Rules[queue[0]]
+		queue = queue[1:]
+		forEachTerm(rule.RHS, func(term *Term) {
+			for _, name := range []string{term.Ident, term.Recover} {
+				if _, ok := l.g.Rules[name]; ok && !reached[name] {
+					reached[name] = true
+					queue = append(queue, name)
+				}
+			}
+		})
+	}
+	for _, name := range l.g.RuleNames {
+		if !reached[name] {
+			l.report(LintUnreachable, name, l.g.Rules[name].Pos,
+				"rule %s is unreachable from the start rule %s", name, start)
+		}
+	}
+}
+
+func (l *linter) checkTerm(term *Term) {
+	if term.Ident != "" {
+		if _, ok := l.g.Rules[term.Ident]; !ok {
+			l.report(LintUndefined, l.rule, term.Pos, "undefined rule %s", term.Ident)
+		}
+	}
+	if term.Recover != "" {
+		if _, ok := l.g.Rules[term.Recover]; !ok {
+			l.report(LintUndefined, l.rule, term.Pos, "undefined recovery rule %s", term.Recover)
+		}
+	}
+	if term.Special != nil && term.Special.Rune != '?' &&
+		(term.Special.Rune != '{' || term.Special.Max < 0) &&
+		termNullable(term.Special.Term, l.nullable) {
+		l.report(LintNullableRepetition, l.rule, term.Special.Term.Pos,
+			"repetition %s of an expression that can match empty input",
+			term.ShortString())
+	}
+}
+
+// checkRHS reports the choices of rhs that can never match.
+func (l *linter) checkRHS(rhs *RHS) {
+	for i, terms := range rhs.Terms {
+		prefix, _ := literalPrefix(terms)
+		for _, earlier := range rhs.Terms[:i] {
+			if l.alwaysSucceeds(earlier, make(map[string]bool)) {
+				l.report(LintShadowed, l.rule, terms[0].Pos,
+					"choice %s is shadowed by earlier choice %s that always matches",
+					groupToString(terms), groupToString(earlier))
+				break
+			}
+			if s, exact := literalPrefix(earlier); exact && strings.HasPrefix(prefix, s) {
+				l.report(LintShadowed, l.rule, terms[0].Pos,
+					"choice %s is shadowed by earlier choice %s that matches its prefix",
+					groupToString(terms), groupToString(earlier))
+				break
+			}
+		}
+	}
+}
+
+// literalPrefix returns the concatenation of the leading case-sensitive
+// literals of the sequence. The returned flag is true if the sequence
+// consists only of such literals.
+func literalPrefix(terms []*Term) (string, bool) {
+	var prefix string
+	for _, term := range terms {
+		if term.Literal == "" || term.IgnoreCase {
+			return prefix, false
+		}
+		prefix += term.Literal
+	}
+	return prefix, true
+}
+
+// alwaysSucceeds returns true if the sequence of terms matches any input.
+// The visiting set guards against the infinite recursion.
+func (l *linter) alwaysSucceeds(terms []*Term, visiting map[string]bool) bool {
+	for _, term := range terms {
+		if !l.termAlwaysSucceeds(term, visiting) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *linter) termAlwaysSucceeds(term *Term, visiting map[string]bool) bool {
+	switch {
+	case term.Recover != "":
+		t := *term
+		t.Recover = ""
+		return l.termAlwaysSucceeds(&t, visiting) ||
+			l.termAlwaysSucceeds(&Term{Ident: term.Recover}, visiting)
+	case term.Parens != nil:
+		return l.rhsAlwaysSucceeds(term.Parens, visiting)
+	case term.Pred != nil:
+		return l.termAlwaysSucceeds(term.Pred, visiting)
+	case term.Special != nil:
+		return term.Special.Rune != '+' && term.Special.Min == 0 ||
+			l.termAlwaysSucceeds(term.Special.Term, visiting)
+	case term.Capture != nil:
+		return l.rhsAlwaysSucceeds(term.Capture, visiting)
+	case term.Cut:
+		return true
+	case term.Ident != "":
+		rule, ok := l.g.Rules[term.Ident]
+		if !ok || visiting[term.Ident] {
+			return false
+		}
+		visiting[term.Ident] = true
+		defer delete(visiting, term.Ident)
+		return l.rhsAlwaysSucceeds(rule.RHS, visiting)
+	}
+	return false
+}
+
+func (l *linter) rhsAlwaysSucceeds(rhs *RHS, visiting map[string]bool) bool {
+	for _, terms := range rhs.Terms {
+		if l.alwaysSucceeds(terms, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) checkLeftRecursion() {
+	graph := l.g.firstCalls(l.nullable, false)
+	for _, scc := range stronglyConnected(l.g.RuleNames, graph) {
+		if len(scc) == 1 {
+			name := scc[0]
+			if graph[name][name] {
+				l.report(LintLeftRecursion, name, l.g.Rules[name].Pos,
+					"rule %s is left-recursive", name)
+			}
+			continue
+		}
+		supported := false
+		for _, name := range scc {
+			if !hasCycle(scc, name, graph) {
+				supported = true
+				break
+			}
+		}
+		suffix := ""
+		if !supported {
+			suffix = ", and no rule takes part in all cycles"
+		}
+		for _, name := range scc {
+			l.report(LintLeftRecursion, name, l.g.Rules[name].Pos,
+				"rule %s is left-recursive through rules %s%s",
+				name, strings.Join(scc, ", "), suffix)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/salikh/peg/tests"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		grammar string
+		want    []string
+	}{
+		{`A <- "a" B
+B <- "b"`, nil},
+		{`A <- "a" B C`, []string{
+			"1:9: undefined rule B (undefined)",
+			"1:11: undefined rule C (undefined)",
+		}},
+		{`A <- "a"
+# Comment.
+B <- "b"`, []string{
+			"3:0: rule B is unreachable from the start rule A (unreachable)",
+		}},
+		{`A <- "a" ";"^semi`, []string{
+			"1:9: undefined recovery rule semi (undefined)",
+		}},
+		{`A <- "a" ";"^semi
+semi <- [^;]*`, nil},
+		{`%skip _
+Sum <- "1" "+" "1"
+_ <- " "*`, nil},
+		{`A <- "a" / "ab"`, []string{
+			`1:11: choice "ab" is shadowed by earlier choice "a" that matches its prefix (shadowed)`,
+		}},
+		{`A <- ("x" "y" / "x" "y" "z" / "w")`, []string{
+			`1:16: choice "x" "y" "z" is shadowed by earlier choice "x" "y" that matches its prefix (shadowed)`,
+		}},
+		{`A <- "a" / B / "c"
+B <- "b"*`, []string{
+			`1:15: choice "c" is shadowed by earlier choice B that always matches (shadowed)`,
+		}},
+		{`A <- ("x"?)* "y"+`, []string{
+			`1:5: repetition ("x"?)* of an expression that can match empty input (nullable-repetition)`,
+		}},
+		{`A <- ("x"?){2,} ("x"?){3} "x"{0,}`, []string{
+			`1:5: repetition ("x"?){2,} of an expression that can match empty input (nullable-repetition)`,
+		}},
+		{`A <- B* "a"
+B <- "b"?`, []string{
+			`1:5: repetition B* of an expression that can match empty input (nullable-repetition)`,
+		}},
+		{`A <- A "+" "1" / "1"`, []string{
+			"1:0: rule A is left-recursive (left-recursion)",
+		}},
+		{`A <- B "x" / "y"
+B <- A "z"`, []string{
+			"1:0: rule A is left-recursive through rules A, B (left-recursion)",
+			"2:0: rule B is left-recursive through rules A, B (left-recursion)",
+		}},
+		{`A <- B "a" / "a"
+B <- A "b" / C "b" / "b"
+C <- D "c" / "c"
+D <- C "d" / B "d" / "d"`, []string{
+			"1:0: rule A is left-recursive through rules A, B, C, D, " +
+				"and no rule takes part in all cycles (left-recursion)",
+			"2:0: rule B is left-recursive through rules A, B, C, D, " +
+				"and no rule takes part in all cycles (left-recursion)",
+			"3:0: rule C is left-recursive through rules A, B, C, D, " +
+				"and no rule takes part in all cycles (left-recursion)",
+			"4:0: rule D is left-recursive through rules A, B, C, D, " +
+				"and no rule takes part in all cycles (left-recursion)",
+		}},
+	}
+	for _, tt := range tests {
+		g, err := ParseGrammar(tt.grammar)
+		if err != nil {
+			t.Errorf("ParseGrammar(%q) returns error %s, want success", tt.grammar, err)
+			continue
+		}
+		var got []string
+		for _, issue := range Lint(g) {
+			got = append(got, issue.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lint(%q) returns\n%q\nwant\n%q", tt.grammar, got, tt.want)
+		}
+	}
+}
+
+func TestLintPositive(t *testing.T) {
+	for _, tt := range tests.Positive {
+		g, err := New(tt.Grammar, nil)
+		if err != nil {
+			t.Errorf("New(%q) returns error %s, want success", tt.Grammar, err)
+			continue
+		}
+		for _, issue := range Lint(g) {
+			if issue.Kind == LintUndefined {
+				t.Errorf("Lint(%q) returns %s, want no undefined rules", tt.Grammar, issue)
+			}
+		}
+	}
+}
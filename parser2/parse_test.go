@@ -244,6 +244,110 @@ var semanticParseTests = []semanticParseTest{
 		`(Grammar
 	    (Rule text("A") (RHS (Choice (Term :CharClass("[:any:]")))))
 	    (Rule text("B") (RHS (Choice (Term :CharClass("[:any:]"))))))`},
+	{`A <- L(B)
+	L(X) <- X ("," X)*
+	B <- "b"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice (Term :Ident("L_B")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("L_B") (RHS (Choice
+			 (Term :Ident("B"))
+			 (Term :Special(Special
+			  (Term :Parens(RHS (Choice (Term :Literal(",")) (Term :Ident("B")))))
+			  :Rune("*")))))))`},
+	{`A <- P(B, C) P(C, B) P(B, C)
+	P(X, Y) <- X Y
+	B <- "b"
+	C <- "c"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Ident("P_B_C")) (Term :Ident("P_C_B")) (Term :Ident("P_B_C")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("C") (RHS (Choice (Term :Literal("c")))))
+	    (Rule text("P_B_C") (RHS (Choice (Term :Ident("B")) (Term :Ident("C")))))
+	    (Rule text("P_C_B") (RHS (Choice (Term :Ident("C")) (Term :Ident("B"))))))`},
+	{`A <- L(P(B,
+	  B))
+	L(X) <- X L(X)?
+	P(X, Y) <- X Y
+	B <- "b"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice (Term :Ident("L_P_B_B")))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("P_B_B") (RHS (Choice (Term :Ident("B")) (Term :Ident("B")))))
+	    (Rule text("L_P_B_B") (RHS (Choice
+			 (Term :Ident("P_B_B"))
+			 (Term :Special(Special (Term :Ident("L_P_B_B")) :Rune("?")))))))`},
+	{`A <- [0-9a-f]{4} B{1,3} B {2,}
+	B <- "b"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("0-9a-f")) :Rune("{") :Min("4") :Max("4")))
+			 (Term :Special(Special (Term :Ident("B")) :Rune("{") :Min("1") :Max("3")))
+			 (Term :Special(Special (Term :Ident("B")) :Rune("{") :Min("2"))))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b"))))))`},
+	{`A <- B (C)
+	B <- "b"
+	C <- "c"`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Ident("B"))
+			 (Term :Parens(RHS (Choice (Term :Ident("C"))))))))
+	    (Rule text("B") (RHS (Choice (Term :Literal("b")))))
+	    (Rule text("C") (RHS (Choice (Term :Literal("c"))))))`},
+	{`A <- "a" ";"^semi
+	semi <- [^;]*`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Literal("a"))
+			 (Term :Literal(";") :Recover("semi")))))
+	    (Rule text("semi") (RHS (Choice (Term :Special(Special (Term :CharClass("^;")) :Rune("*")))))))`},
+	{`A <- "a" &{isKeyword} !{ isTypeName }`,
+		`(Grammar
+	    (Rule text("A") (RHS (Choice
+			 (Term :Literal("a"))
+			 (Term :SemPred("isKeyword"))
+			 (Term :SemNegPred("isTypeName"))))))`},
+	{`Block <- INDENT Line (SAMEDENT Line)* DEDENT`,
+		`(Grammar
+	    (Rule text("Block") (RHS (Choice
+			 (Term :Indent("INDENT"))
+			 (Term :Ident("Line"))
+			 (Term :Special(Special (Term :Parens(RHS (Choice
+			   (Term :Indent("SAMEDENT"))
+			   (Term :Ident("Line"))))) :Rune("*")))
+			 (Term :Indent("DEDENT"))))))`},
+	{`Tag <- "<" <tag: [a-z]+> ">" "</" $tag ">"`,
+		`(Grammar
+	    (Rule text("Tag") (RHS (Choice
+			 (Term :Literal("<"))
+			 (Term :Capture(RHS (Choice (Term :Special(Special (Term :CharClass("a-z")) :Rune("+"))))) :Label("tag"))
+			 (Term :Literal(">"))
+			 (Term :Literal("</"))
+			 (Term :BackRef("tag"))
+			 (Term :Literal(">"))))))`},
+	{`%skip _
+	Sum <- Num "+" Num
+	%token Num <- [0-9]+
+	_ <- " "*`,
+		`(Grammar :Skip("_")
+	    (Rule text("Sum") (RHS (Choice
+			 (Term :Ident("Num")) (Term :Literal("+")) (Term :Ident("Num")))))
+	    (Rule text("Num") :Token (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("0-9")) :Rune("+"))))))
+	    (Rule text("_") (RHS (Choice
+			 (Term :Special(Special (Term :Literal(" ")) :Rune("*")))))))`},
+	{`Expr <- %prec Num
+	  %left Add:"+" "-"
+	  %prefix Neg:"-"
+	Num <- [0-9]+`,
+		`(Grammar
+	    (Rule text("Expr") (RHS (Choice
+			 (Term :Ident("Num") :Prec(Precedence
+			   (Level :Kind("left") (Op :Literal("+") :Label("Add")) (Op :Literal("-")))
+			   (Level :Kind("prefix") (Op :Literal("-") :Label("Neg"))))))))
+	    (Rule text("Num") (RHS (Choice
+			 (Term :Special(Special (Term :CharClass("0-9")) :Rune("+")))))))`},
 }
 
 func TestSemantic(t *testing.T) {
@@ -281,6 +385,24 @@ func TestSemantic(t *testing.T) {
 	}
 }
 
+func TestRepeatShortString(t *testing.T) {
+	for _, source := range []string{
+		`A <- "a"{3}`,
+		`A <- [0-9]{1,3}`,
+		`A <- ("a" "b"){2,}`,
+	} {
+		g, err := New(source, nil)
+		if err != nil {
+			t.Errorf("New(%q) returns error %s, want success", source, err)
+			continue
+		}
+		got := "A <- " + g.Rules["A"].RHS.ShortString()
+		if got != source {
+			t.Errorf("New(%q) has rule %q, want %q", source, got, source)
+		}
+	}
+}
+
 type invalidParseTest struct {
 	source      string
 	syntaxErr   string
@@ -294,12 +416,35 @@ var invalidParseTests = []invalidParseTest{
 		semanticErr: `Special character '\+' cannot be first in the rule`},
 	{source: `A <- ?`,
 		semanticErr: `Special character '\?' cannot be first in the rule`},
+	{source: `A <- {2}`,
+		semanticErr: `Special character '\{' cannot be first in the rule`},
+	{source: `A <- "a"{3,2}`,
+		semanticErr: `invalid repetition \{3,2\}: maximum is less than minimum`},
+	{source: `A <- "a"{,2}`, syntaxErr: `"\{,2\}"`},
 	{source: `A <- .`}, // No error.
 	{source: `A <- (`, syntaxErr: `'\('`},
 	{source: `A <- .  B <- .`, syntaxErr: `"<-`},
 	{source: `A <- .
 
 	/ [x] B <- .`, syntaxErr: `"<-`},
+	{source: `A <- L(B, B)
+	L(X) <- X`, semanticErr: `rule L expects 1 arguments, got 2`},
+	{source: `A <- L
+	L(X) <- X`, semanticErr: `rule L expects 1 arguments, got 0`},
+	{source: `A <- B(C)
+	B <- "b"`, semanticErr: `rule B does not have parameters`},
+	{source: `L(X) <- X
+	A <- L(A)`, semanticErr: `top rule L cannot have parameters`},
+	{source: `A <- L(B)
+	L(X) <- X
+	L_B <- "b"`, semanticErr: `instance L\(B\) conflicts with rule L_B`},
+	{source: `A <- L(P(B, C)) L_P(B, C)
+	L(X) <- X
+	L_P(X, Y) <- X Y
+	P(X, Y) <- X Y`, semanticErr: `instances L\(P_B_C\) and L_P\(B, C\) have the same name L_P_B_C`},
+	{source: `A <- L(B)
+	L(X) <- L(M(X))
+	M(X) <- X`, semanticErr: `too many instances`},
 }
 
 func TestParseError(t *testing.T) {
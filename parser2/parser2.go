@@ -24,6 +24,7 @@ package parser2
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -47,32 +48,118 @@ type ParserOptions struct {
 	// LongErrorMessage specifies whether to include the full content
 	// into error messages. By default just a few first characters are included.
 	LongErrorMessage bool
+	// Predicates defines the semantic predicates &{name} and !{name} used by
+	// the grammar. A predicate is called with the current position and the
+	// node of the rule being parsed, which holds the children parsed so far,
+	// and must not consume any input. Since the results of rules are
+	// memoized, a predicate should give the same answer for the same
+	// position and node. New returns an error if the grammar uses
+	// a predicate that is not defined.
+	Predicates map[string]func(r *Result, pos int, node *parser.Node) bool
+	// ByteMode specifies whether the input is parsed as a sequence of bytes
+	// rather than UTF-8 runes. In byte mode, the dot and the char classes
+	// match a single byte, which matches a char class if the class contains
+	// the rune with the same code, e.g. [\x80-\xff] matches the bytes with
+	// the high bit set. The input does not need to be valid UTF-8 in either
+	// mode, and Node.Pos and Node.Len are byte offsets in both modes.
+	ByteMode bool
 }
 
 // New parses a PEG grammar source into a Grammar object.
 // Optional ParserOptions specify the parser options.
 func New(source string, options *ParserOptions) (*Grammar, error) {
-	result, err := parse(source)
+	grammar, err := ParseGrammar(source)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse grammar source: %s", err)
+		return nil, err
 	}
-	grammar, err := convert(result.Tree)
+	err = grammar.compile(options)
 	if err != nil {
-		return nil, fmt.Errorf("internal error constructing semantic tree: %s", err)
+		return nil, err
 	}
+	return grammar, nil
+}
+
+// compile builds the parse handlers of the grammar.
+func (g *Grammar) compile(options *ParserOptions) error {
 	if options != nil {
-		grammar.ParserOptions = *options
+		g.ParserOptions = *options
+	}
+	if _, ok := g.Rules[g.Skip]; g.Skip != "" && !ok {
+		return fmt.Errorf("undefined skip rule: %s", g.Skip)
 	}
-	for _, rule := range grammar.Rules {
-		rule.handler, err = grammar.makeRHSHandler(rule.RHS)
+	var err error
+	for _, name := range g.RuleNames {
+		rule := g.Rules[name]
+		if err := checkBackRefs(rule); err != nil {
+			return g.ruleError(rule, err)
+		}
+		rhs := rule.RHS
+		if g.Skip != "" && !g.isLexical(rule) {
+			rhs = insertSkip(rhs, g.Skip)
+		}
+		rule.handler, err = g.makeRHSHandler(rhs)
 		if err != nil {
-			return nil, err
+			return g.ruleError(rule, err)
 		}
-		rule.backwardHandler, err = grammar.makeBackwardRHSHandler(rule.RHS)
+		rule.backwardHandler, err = g.makeBackwardRHSHandler(rhs)
 		if err != nil {
-			return nil, err
+			return g.ruleError(rule, err)
 		}
 	}
+	forward, err := g.computeLeftRecursion(false)
+	if err != nil {
+		return err
+	}
+	backward, err := g.computeLeftRecursion(true)
+	if err != nil {
+		return err
+	}
+	for name, rule := range g.Rules {
+		rule.leftRecursion = forward[name]
+		rule.backwardLeftRecursion = backward[name]
+	}
+	return nil
+}
+
+// ParseGrammar parses a PEG grammar source into a Grammar object without
+// checking rule references and building the parse handlers. The returned
+// grammar can be inspected, e.g. with Lint, but cannot be used for parsing.
+func ParseGrammar(source string) (*Grammar, error) {
+	grammar, err := parseFile(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(grammar.Imports) > 0 {
+		return nil, fmt.Errorf("import %q requires a file system, use NewFromFS",
+			grammar.Imports[0])
+	}
+	for _, name := range grammar.RuleNames {
+		if grammar.Rules[name].Override {
+			return nil, fmt.Errorf("rule %s does not override any rule", name)
+		}
+	}
+	err = grammar.expandParams()
+	if err != nil {
+		return nil, err
+	}
+	return grammar, nil
+}
+
+// parseFile parses one file of a PEG grammar without expanding
+// the parameterized rules.
+func parseFile(source string) (*Grammar, error) {
+	result, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse grammar source: %s", err)
+	}
+	if err := checkLiterals(result.Tree, source); err != nil {
+		return nil, err
+	}
+	grammar, err := convertFile(result.Tree)
+	if err != nil {
+		return nil, fmt.Errorf("internal error constructing semantic tree: %s", err)
+	}
+	grammar.Source = source
 	return grammar, nil
 }
 
@@ -84,8 +171,19 @@ type Grammar struct {
 	RuleNames []string
 	// Source is the source text of the PEG grammar.
 	Source string
+	// Imports is the list of files imported by the grammar source.
+	Imports []string
+	// Skip is the name of the rule set by the directive %skip, which is
+	// inserted between the terms of the syntactic rules, see skip.go.
+	Skip string
+	// Files maps the file names to their sources if the grammar
+	// was loaded from multiple files.
+	Files map[string]string
 	// ParserOptions specify the parser options.
 	ParserOptions
+	// backwardErr is set if the grammar uses terms that are not supported
+	// by the backward parser.
+	backwardErr error
 }
 
 // handler is the basic parse handler.
@@ -95,12 +193,38 @@ type handler func(r *Result, pos int) (int, error)
 type Rule struct {
 	// Ident is the name of the rule, defined in LHS.
 	Ident string
+	// File is the name of the file where the rule is defined,
+	// if the grammar was loaded with NewFromFS.
+	File string
+	// Pos is the byte position of the rule in the grammar source.
+	Pos int
+	// Override is true if the rule replaces the rule with the same name
+	// from an imported file.
+	Override bool
+	// Token is true if the rule is marked with %token as a lexical rule,
+	// which is exempt from the %skip directive.
+	Token bool
+	// Marker controls how the nodes of the rule are attached to the syntax
+	// tree: "inline" attaches the children of the node to the parent node
+	// instead of the node itself, "drop" never attaches the node, and "keep"
+	// always attaches the node, even if it is empty. By default, the nodes
+	// are attached according to ParserOptions.SkipEmptyNodes.
+	Marker string
+	// Params is the list of parameter names of a parameterized rule.
+	// Parameterized rules are expanded during the grammar construction
+	// and do not appear in Grammar.Rules.
+	Params []string
 	// RHS is the rule's right-hand side.
 	*RHS
 	// handler is the parse handler of this rule.
 	handler
 	// backwardHandler is the backward parse handler of this rule.
 	backwardHandler handler
+	// leftRecursion describes whether the rule is left-recursive.
+	leftRecursion
+	// backwardLeftRecursion describes whether the rule is left-recursive
+	// when parsed backward, i.e. whether it is right-recursive.
+	backwardLeftRecursion leftRecursion
 }
 
 // RHS is the right-hand side of one rule or the contents of parenthesized expression.
@@ -114,21 +238,66 @@ type RHS struct {
 // specially and are not mapped to Term one-to-one. Instead, *?+ combine with
 // the previous Term, and . is converted to a special CharClass.
 type Term struct {
+	// Pos is the byte position of the term in the grammar source.
+	Pos     int
 	Parens  *RHS
 	NegPred *Term
 	Pred    *Term
+	// SemPred and SemNegPred are set to the name of the semantic predicate
+	// for &{name} and !{name} respectively, see ParserOptions.Predicates.
+	SemPred    string
+	SemNegPred string
 	*Special
 	Capture *RHS
 	*charclass.CharClass
 	Literal string
-	Ident   string
+	// IgnoreCase specifies that Literal is matched case-insensitively.
+	// Case-insensitive char classes have CharClass.IgnoreCase set instead.
+	IgnoreCase bool
+	Ident      string
+	// Args are the arguments of a parameterized rule invocation Ident(Args).
+	// Each argument has an Ident and optionally Args. The invocations are
+	// replaced with references to the rule instances during the grammar
+	// construction.
+	Args []*Term
+	// Label is set for labeled rule references name:Ident, which store the
+	// node in TreeAnnotations, and for labeled captures <name: ...>, which
+	// store the text in Annotations instead of Text.
+	Label string
+	// Cut is set for the cut operator ~, which commits the parser to the
+	// current alternative of the innermost choice of the rule.
+	Cut bool
+	// Indent is set for the indentation terms INDENT, DEDENT and SAMEDENT
+	// to the term name, see indent.go.
+	Indent string
+	// BackRef is set for the back-references $name to the name of the
+	// labeled capture <name: ...> of the same rule, whose text it matches.
+	BackRef string
+	// Recover is set for the labeled failures term^label to the label, which
+	// is also the name of the recovery rule. If the term fails, the syntax
+	// error is recorded, and the recovery rule is applied at the same
+	// position, usually to skip the input up to a synchronization token.
+	// If the recovery rule matches, the parser continues as if the term
+	// matched the skipped input.
+	Recover string
+	// recoverLabel is set on the placeholder terms that hold the postfix
+	// recovery labels during the conversion.
+	recoverLabel string
+	// Prec is set for the rules defined by a precedence table %prec Ident,
+	// where Ident is the operand rule, see precedence.go. The term is the
+	// only term of the rule.
+	Prec *Precedence
 }
 
-// Special is a term with a option or repeat special modifer (*?+).
+// Special is a term with a option or repeat special modifer (*?+), or
+// with a bounded repetition {n}, {n,} or {n,m}.
 type Special struct {
 	*Term
-	// Rune is one of '*' '?' '+'.
+	// Rune is one of '*' '?' '+', or '{' for the bounded repetition.
 	Rune rune
+	// Min and Max are the bounds of the bounded repetition. Max is -1 if
+	// the repetition has no upper bound.
+	Min, Max int
 }
 
 func (g *Grammar) String() string {
@@ -136,6 +305,9 @@ func (g *Grammar) String() string {
 		return "(nil)"
 	}
 	r := []string{"(Grammar "}
+	if g.Skip != "" {
+		r = append(r, `:Skip(`, strconv.Quote(g.Skip), `) `)
+	}
 	for _, name := range g.RuleNames {
 		rule := g.Rules[name]
 		r = append(r, rule.String())
@@ -149,6 +321,9 @@ func (rule *Rule) String() string {
 		return "(nil)"
 	}
 	r := []string{`(Rule text("`, rule.Ident, `") `}
+	if rule.Token {
+		r = append(r, ":Token ")
+	}
 	r = append(r, rule.RHS.String())
 	r = append(r, ")")
 	return strings.Join(r, "")
@@ -181,6 +356,12 @@ func (t *Term) String() string {
 	if t.Pred != nil {
 		r = append(r, " :Pred", t.Pred.String())
 	}
+	if t.SemPred != "" {
+		r = append(r, ` :SemPred(`, strconv.Quote(t.SemPred), `)`)
+	}
+	if t.SemNegPred != "" {
+		r = append(r, ` :SemNegPred(`, strconv.Quote(t.SemNegPred), `)`)
+	}
 	if t.Capture != nil {
 		r = append(r, " :Capture", t.Capture.String())
 	}
@@ -190,12 +371,33 @@ func (t *Term) String() string {
 	if t.Literal != "" {
 		r = append(r, ` :Literal(`, strconv.Quote(t.Literal), `)`)
 	}
+	if t.IgnoreCase || t.CharClass != nil && t.CharClass.IgnoreCase {
+		r = append(r, ` :IgnoreCase`)
+	}
 	if t.Ident != "" {
 		r = append(r, ` :Ident(`, strconv.Quote(t.Ident), `)`)
 	}
+	if t.Prec != nil {
+		r = append(r, ` :Prec`, t.Prec.String())
+	}
+	if t.Label != "" {
+		r = append(r, ` :Label(`, strconv.Quote(t.Label), `)`)
+	}
+	if t.Cut {
+		r = append(r, ` :Cut`)
+	}
+	if t.Indent != "" {
+		r = append(r, ` :Indent(`, strconv.Quote(t.Indent), `)`)
+	}
+	if t.BackRef != "" {
+		r = append(r, ` :BackRef(`, strconv.Quote(t.BackRef), `)`)
+	}
 	if t.Special != nil {
 		r = append(r, ` :Special`, t.Special.String())
 	}
+	if t.Recover != "" {
+		r = append(r, ` :Recover(`, strconv.Quote(t.Recover), `)`)
+	}
 	r = append(r, ")")
 	return strings.Join(r, "")
 }
@@ -207,6 +409,12 @@ func (s *Special) String() string {
 		r = append(r, s.Term.String())
 	}
 	r = append(r, ` :Rune("`, q[1:len(q)-1], `")`)
+	if s.Rune == '{' {
+		r = append(r, ` :Min("`, strconv.Itoa(s.Min), `")`)
+		if s.Max >= 0 {
+			r = append(r, ` :Max("`, strconv.Itoa(s.Max), `")`)
+		}
+	}
 	r = append(r, ")")
 	return strings.Join(r, "")
 }
@@ -214,6 +422,20 @@ func (s *Special) String() string {
 // convert converts the syntax tree of a grammar into the semantic
 // grammar tree that is directly usable for parsing.
 func convert(n *parser.Node) (*Grammar, error) {
+	g, err := convertFile(n)
+	if err != nil {
+		return nil, err
+	}
+	err = g.expandParams()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// convertFile converts the syntax tree of a grammar file into the semantic
+// grammar tree without expanding the parameterized rules.
+func convertFile(n *parser.Node) (*Grammar, error) {
 	val, err := Construct(n, callback, &AccessorOptions{
 		ErrorOnUnusedChild: true,
 	})
@@ -228,6 +450,32 @@ func convert(n *parser.Node) (*Grammar, error) {
 	return g, nil
 }
 
+// unquote converts the quoted literal into
+// the actual string.
+func unquote(raw string) (string, error) {
+	return parser.UnquoteLiteral(raw)
+}
+
+// checkLiterals checks the literals of the syntax tree of the grammar
+// source, and returns the first error located in the source.
+func checkLiterals(n *parser.Node, source string) error {
+	if n.Label == "Literal" {
+		if _, err := unquote(n.Text); err != nil {
+			if lerr, ok := err.(*parser.LiteralError); ok {
+				// The literal is captured at the end of the node.
+				return lerr.Locate(source, n.Pos+n.Len-len(n.Text))
+			}
+			return err
+		}
+	}
+	for _, c := range n.Children {
+		if err := checkLiterals(c, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // The callback that is used to convert the syntax parse tree into
 // the semantic tree.
 func callback(label string, ca Accessor) (interface{}, error) {
@@ -247,15 +495,65 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			rules[rule.Ident] = rule
 			ruleNames = append(ruleNames, rule.Ident)
 		}
+		skip, _ := ca.GetString("Skip")
 		return &Grammar{
 			Rules:     rules,
 			RuleNames: ruleNames,
+			Imports:   ca.Get("Import", []string{}).([]string),
+			Skip:      skip,
 		}, nil
+	case "Import":
+		return unquote(ca.String("Literal"))
+	case "Skip":
+		return ca.String("Ident"), nil
 	case "Rule":
+		if name := ca.String("Ident"); indentTerms[name] {
+			return nil, fmt.Errorf("%s is a reserved name and cannot be defined as a rule", name)
+		}
+		marker, _ := ca.GetString("Marker")
+		var rhs *RHS
+		if ca.GetChild("Prec") != nil {
+			rhs = &RHS{Terms: [][]*Term{{ca.Get("Prec", &Term{}).(*Term)}}}
+		} else {
+			rhs = ca.Get("RHS", &RHS{}).(*RHS)
+		}
 		return &Rule{
+			Ident:    ca.String("Ident"),
+			Pos:      ca.Node().Pos,
+			Override: ca.GetChild("Override") != nil,
+			Token:    ca.GetChild("Token") != nil,
+			Marker:   marker,
+			Params:   ca.Get("Params", []string{}).([]string),
+			RHS:      rhs,
+		}, nil
+	case "Override", "Token":
+		return nil, nil
+	case "Marker":
+		return ca.Node().Text, nil
+	case "Params":
+		return ca.Get("Ident", []string{}).([]string), nil
+	case "Prec":
+		prec := &Precedence{Levels: ca.Get("PrecLevel", []*PrecLevel{}).([]*PrecLevel)}
+		if err := prec.check(); err != nil {
+			return nil, err
+		}
+		return &Term{
+			Pos:   ca.Node().Pos,
 			Ident: ca.String("Ident"),
-			RHS:   ca.Get("RHS", &RHS{}).(*RHS),
+			Prec:  prec,
+		}, nil
+	case "PrecLevel":
+		return &PrecLevel{
+			Kind: ca.Node().Text,
+			Ops:  ca.Get("PrecOp", []*PrecOp{}).([]*PrecOp),
 		}, nil
+	case "PrecOp":
+		literal, err := unquote(ca.String("Literal"))
+		if err != nil {
+			return nil, err
+		}
+		label, _ := ca.GetString("Label")
+		return &PrecOp{Label: label, Literal: literal}, nil
 	case "RHS":
 		return &RHS{ca.Get("Terms", [][]*Term{}).([][]*Term)}, nil
 	case "Terms":
@@ -276,9 +574,26 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			terms = append(terms[0:i-1], terms[i:]...)
 			i--
 		}
+		// Attach the postfix recovery labels ^label to the previous terms.
+		for i := 0; i < len(terms); i++ {
+			if terms[i].recoverLabel == "" {
+				continue
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("recovery label ^%s cannot be first in the rule",
+					terms[i].recoverLabel)
+			}
+			if terms[i-1].Recover != "" {
+				return nil, fmt.Errorf("term %s has more than one recovery label",
+					terms[i-1].ShortString())
+			}
+			terms[i-1].Recover = terms[i].recoverLabel
+			terms = append(terms[0:i], terms[i+1:]...)
+			i--
+		}
 		return terms, nil
 	case "Term":
-		term := &Term{}
+		term := &Term{Pos: ca.Node().Pos}
 		switch ca.Child(0) {
 		case "Parens":
 			term.Parens = ca.Get("Parens", &RHS{}).(*RHS)
@@ -286,25 +601,45 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			term.NegPred = ca.Get("NegPred", &Term{}).(*Term)
 		case "Pred":
 			term.Pred = ca.Get("Pred", &Term{}).(*Term)
+		case "SemPred":
+			term.SemPred = ca.String("SemPred")
+		case "SemNegPred":
+			term.SemNegPred = ca.String("SemNegPred")
 		case "Capture":
-			term.Capture = ca.Get("Capture", &RHS{}).(*RHS)
+			capture := ca.Get("Capture", &Term{}).(*Term)
+			term.Capture = capture.Capture
+			term.Label = capture.Label
 		case "CharClass":
 			term.CharClass = ca.Get("CharClass", &charclass.CharClass{}).(*charclass.CharClass)
+			term.CharClass.IgnoreCase = ca.GetChild("IgnoreCase") != nil
 		case "Literal":
-			raw := ca.String("Literal")
-			var unquoted string
-			if raw[0] == '"' {
-				var err error
-				unquoted, err = strconv.Unquote(raw)
-				if err != nil {
-					return nil, fmt.Errorf("error in strconv.Unquote(%q): %s", raw, err)
-				}
-			} else {
-				unquoted = raw[1 : len(raw)-1]
+			unquoted, err := unquote(ca.String("Literal"))
+			if err != nil {
+				return nil, err
 			}
 			term.Literal = unquoted
+			term.IgnoreCase = ca.GetChild("IgnoreCase") != nil
+		case "Labeled":
+			labeled := ca.Get("Labeled", &Term{}).(*Term)
+			term.Ident = labeled.Ident
+			term.Args = labeled.Args
+			term.Label = labeled.Label
+		case "Call":
+			call := ca.Get("Call", &Term{}).(*Term)
+			term.Ident = call.Ident
+			term.Args = call.Args
 		case "Ident":
 			term.Ident = ca.String("Ident")
+		case "Cut":
+			term.Cut = true
+		case "Indent":
+			term.Indent = ca.String("Indent")
+		case "BackRef":
+			term.BackRef = ca.String("BackRef")
+		case "Repeat":
+			term.Special = ca.Get("Repeat", &Special{}).(*Special)
+		case "Recover":
+			term.recoverLabel = ca.String("Recover")
 		case "Special":
 			special := ca.Get("Special", &Special{}).(*Special)
 			if special.Rune == '.' {
@@ -316,20 +651,54 @@ func callback(label string, ca Accessor) (interface{}, error) {
 			}
 		}
 		return term, nil
+	case "IgnoreCase", "Cut":
+		return nil, nil
 	case "Special":
 		c, _ := utf8.DecodeRuneInString(ca.Node().Text)
 		return &Special{Rune: c}, nil
+	case "Repeat":
+		return parseRepeat(ca.Node().Text)
+	case "Recover":
+		return ca.String("Ident"), nil
 	case "Parens":
 		return ca.GetTyped("RHS", &RHS{})
 	case "NegPred":
 		return ca.GetTyped("Term", &Term{})
 	case "Pred":
 		return ca.GetTyped("Term", &Term{})
+	case "SemPred", "SemNegPred":
+		return ca.String("Ident"), nil
 	case "Capture":
-		return ca.GetTyped("RHS", &RHS{})
+		label, _ := ca.GetString("Label")
+		return &Term{
+			Capture: ca.Get("RHS", &RHS{}).(*RHS),
+			Label:   label,
+		}, nil
+	case "Call":
+		return &Term{
+			Pos:   ca.Node().Pos,
+			Ident: ca.String("Ident"),
+			Args:  ca.Get("Arg", []*Term{}).([]*Term),
+		}, nil
+	case "Labeled":
+		term := &Term{Pos: ca.Node().Pos, Label: ca.String("Label")}
+		if call, err := ca.GetTyped("Call", &Term{}); err == nil {
+			term.Ident = call.(*Term).Ident
+			term.Args = call.(*Term).Args
+		} else {
+			term.Ident = ca.String("Ident")
+		}
+		return term, nil
+	case "Label":
+		return ca.Node().Text, nil
+	case "Arg":
+		if call, err := ca.GetTyped("Call", &Term{}); err == nil {
+			return call, nil
+		}
+		return &Term{Pos: ca.Node().Pos, Ident: ca.String("Ident")}, nil
 	case "Literal":
 		return ca.Node().Text, nil
-	case "Ident":
+	case "Ident", "Indent", "BackRef":
 		return ca.Node().Text, nil
 	case "CharClass":
 
@@ -357,86 +726,251 @@ type Result struct {
 	// Tree is the parsed syntax tree.
 	Tree *parser.Node
 	// Internal nodes.
-	memo      map[int]map[*Rule]*parser.Node
+	memo      map[int]map[memoKey]*memoEntry
 	nodeStack NodeStack
+	// indent is the indentation stack of the INDENT, DEDENT and SAMEDENT
+	// terms, and indents interns the stacks, see indent.go.
+	indent  *indentLevel
+	indents map[indentLevel]*indentLevel
 	// fyiError helps to identify the issues with grammar
 	fyiError error
+	// farthest is the farthest position where a failure was recorded,
+	// and expected is the set of expectations that failed at farthest.
+	farthest int
+	expected map[string]bool
+	// predicateLevel is the number of predicates being evaluated.
+	predicateLevel int
+	// cut is set when a cut is passed in the current alternative of the
+	// innermost choice, which then does not try the next alternatives. The
+	// rules and the predicates start with the flag cleared, so the cuts
+	// inside of them do not commit the choices outside.
+	cut bool
+	// points is the stack of the positions where the parser can resume
+	// after a failure: the starts of the choices and of the iterations of
+	// the repetitions. The choices committed by a cut have -1 instead.
+	points []int
+	// choice is the number of points up to the point of the innermost
+	// choice, or 0 if the innermost scope of the cuts is a rule or
+	// a predicate.
+	choice int
+	// growing is the number of left-recursive rules with seeds being grown.
+	growing int
+	// backward is set for backward parsing.
+	backward bool
+	// committed is the position before which (or after which, for backward
+	// parsing) the memo entries have been discarded.
+	committed int
 	// rowCol helps to avoid recomputing row/col information for the same
 	// locations. Maps position to row/col pair.
 	rowCol map[int]RowCol
+	// Errors is the list of syntax errors recovered by the labeled failures
+	// term^label, in the order of input positions. The parse succeeds
+	// despite the recovered errors, so Errors must be checked if the
+	// grammar has recovery rules.
+	Errors []*ParseError
 }
 
-// Parse parses the input string accoring to the PEG grammar.
-func (g *Grammar) Parse(input string) (*Result, error) {
-	if g == nil {
-		return nil, fmt.Errorf("nil grammar")
-	}
-	result := &Result{
+// newResult creates a fresh parser state for the input.
+func (g *Grammar) newResult(input string) *Result {
+	return &Result{
 		Grammar:   g,
 		Source:    input,
-		memo:      make(map[int]map[*Rule]*parser.Node),
+		memo:      make(map[int]map[memoKey]*memoEntry),
 		nodeStack: make(NodeStack, 0, 10),
 		rowCol:    make(map[int]RowCol),
 	}
-	if len(g.RuleNames) == 0 {
-		return nil, fmt.Errorf("invalid grammar without rules")
-	}
-	topRule := g.RuleNames[0]
-	top, ok := g.Rules[topRule]
-	if !ok {
-		return nil, fmt.Errorf("invalid grammar with missing top rule %s", topRule)
-	}
-	w, err := result.apply(top, 0)
-	if err != nil {
-		return result, err
-	}
-	if w == 0 && len(input) > 0 {
-		return result, fmt.Errorf("Grammar matched 0 characters: %s", result.fyiError)
-	}
-	if w != len(input) && !g.ParserOptions.IgnoreUnconsumedTail {
-		errContent := input[w:]
-		if !g.ParserOptions.LongErrorMessage && len(errContent) > 13 {
-			errContent = errContent[:10] + "..."
-		}
-		return result, fmt.Errorf("some characers remain unconsumed: %q"+
-			"\nPrevious error: %s",
-			errContent, result.fyiError)
-	}
-	if result.Tree == nil {
-		return nil, fmt.Errorf("internal error: no syntax tree. len(nodeStack) = %d",
-			len(result.nodeStack))
-	}
-	return result, nil
+}
+
+// memoKey identifies a memoized rule application at a position. The
+// indentation terms make the result of a rule depend on the indentation
+// stack, so the interned stack is a part of the key.
+type memoKey struct {
+	rule   *Rule
+	indent *indentLevel
+}
+
+// memoEntry is a memoized rule application: the node, which holds the error
+// if the rule failed, and the indentation stack after the rule matched.
+type memoEntry struct {
+	node   *parser.Node
+	indent *indentLevel
+}
+
+// Parse parses the input string accoring to the PEG grammar.
+// If the input does not match, the returned error is a *ParseError.
+func (g *Grammar) Parse(input string) (*Result, error) {
+	return g.ParseRule(input, "")
+}
+
+// ParseBytes is like Parse, but takes the input as a byte slice, which is
+// convenient for the binary input parsed with ParserOptions.ByteMode.
+func (g *Grammar) ParseBytes(input []byte) (*Result, error) {
+	return g.ParseRule(string(input), "")
 }
 
 func (r *Result) apply(ru *Rule, pos int) (int, error) {
 	//log.Infof("%d> applying rule %q at pos %d", r.Level, ru.rhs, pos)
 	memo, ok := r.memo[pos]
 	if !ok {
-		memo = make(map[*Rule]*parser.Node)
+		memo = make(map[memoKey]*memoEntry)
 		r.memo[pos] = memo
 	}
-	n := memo[ru]
-	if n != nil {
-		if n.Err != nil {
-			return n.Len, n.Err
+	key := memoKey{ru, r.indent}
+	if e := memo[key]; e != nil {
+		if e.node.Err != nil {
+			return e.node.Len, e.node.Err
 		}
-		r.Attach(n)
-		return n.Len, nil
+		r.indent = e.indent
+		r.Attach(e.node)
+		return e.node.Len, nil
+	}
+	scope := r.enter(pos, false)
+	if ru.leftRecursion.leader {
+		w, err := r.growSeed(ru, pos, memo, ru.handler)
+		r.leave(scope)
+		return w, err
 	}
-	n = &parser.Node{Label: ru.Ident, Pos: pos}
+	n := &parser.Node{Label: ru.Ident, Pos: pos}
 	r.nodeStack.Push(n)
 	w, hErr := ru.handler(r, pos)
+	r.leave(scope)
 	n = r.nodeStack.Pop()
 	n.Len = w
 	n.Err = hErr
-	memo[ru] = n
+	if hErr != nil {
+		r.expect(pos, ru.Ident)
+		r.indent = key.indent
+	}
+	if !ru.leftRecursion.recursive {
+		// The results of non-leader rules in a left-recursive cycle depend
+		// on the current seed, so they cannot be memoized.
+		memo[key] = &memoEntry{n, r.indent}
+	}
 	n.Len = w
 	log.V(6).Infof("attaching %s", n.Label)
 	r.Attach(n)
 	return w, hErr
 }
 
+// growSeed applies a leader of left-recursive rules at the same position
+// repeatedly, each time memoizing the previous result as a seed, until the
+// match stops growing. The first application fails on the recursive
+// invocation and thus matches one of non-recursive alternatives.
+func (r *Result) growSeed(ru *Rule, pos int, memo map[memoKey]*memoEntry, h handler) (int, error) {
+	key := memoKey{ru, r.indent}
+	seed := &memoEntry{&parser.Node{Label: ru.Ident, Pos: pos,
+		Err: fmt.Errorf("left recursion on rule %s", ru.Ident)}, key.indent}
+	memo[key] = seed
+	r.growing++
+	defer func() { r.growing-- }()
+	for {
+		n := &parser.Node{Label: ru.Ident, Pos: pos}
+		r.nodeStack.Push(n)
+		r.indent = key.indent
+		w, hErr := h(r, pos)
+		n = r.nodeStack.Pop()
+		n.Len = w
+		n.Err = hErr
+		if hErr != nil {
+			if seed.node.Err != nil {
+				// Not even a seed could be matched.
+				seed = &memoEntry{n, key.indent}
+				memo[key] = seed
+			}
+			break
+		}
+		if seed.node.Err == nil && w <= seed.node.Len {
+			break
+		}
+		seed = &memoEntry{n, r.indent}
+		memo[key] = seed
+	}
+	r.indent = seed.indent
+	log.V(6).Infof("attaching %s", seed.node.Label)
+	r.Attach(seed.node)
+	return seed.node.Len, seed.node.Err
+}
+
+// cutScope is the state of the cuts saved when a rule, a predicate or
+// a choice starts, and restored when it finishes.
+type cutScope struct {
+	cut    bool
+	choice int
+	points int
+}
+
+// enter starts a new scope of the cuts at pos. If choice is set, the scope
+// is a choice, and pos is a point where the parser resumes with the next
+// alternative until a cut commits the choice.
+func (r *Result) enter(pos int, choice bool) cutScope {
+	s := cutScope{r.cut, r.choice, len(r.points)}
+	r.cut = false
+	r.choice = 0
+	if choice {
+		r.points = append(r.points, pos)
+		r.choice = len(r.points)
+	}
+	return s
+}
+
+// leave restores the state of the cuts saved by enter.
+func (r *Result) leave(s cutScope) {
+	r.cut, r.choice, r.points = s.cut, s.choice, r.points[:s.points]
+}
+
+// push adds pos to the points where the parser resumes after a failure and
+// returns its index. The caller removes the point when it finishes.
+func (r *Result) push(pos int) int {
+	r.points = append(r.points, pos)
+	return len(r.points) - 1
+}
+
+// commit is called when the parser passes a cut at pos. The cut commits
+// the innermost choice, so the parser can only backtrack to the points of
+// the enclosing choices and repetitions. The memo entries behind the first
+// of them are discarded, and so are the expectations recorded beyond pos
+// by the abandoned alternatives, so that a failure after the cut is
+// reported after the cut. Inside of predicates and left-recursive rules
+// nothing is discarded.
+func (r *Result) commit(pos int) {
+	r.cut = true
+	if r.choice > 0 {
+		r.points[r.choice-1] = -1
+	}
+	if r.predicateLevel > 0 || r.growing > 0 {
+		return
+	}
+	bound := pos
+	for _, p := range r.points {
+		if p >= 0 {
+			bound = p
+			break
+		}
+	}
+	if r.backward {
+		for ; r.committed > bound; r.committed-- {
+			delete(r.memo, r.committed)
+		}
+	} else {
+		for ; r.committed < bound; r.committed++ {
+			delete(r.memo, r.committed)
+		}
+	}
+	if r.farthest > pos {
+		r.farthest = pos
+		r.expected = nil
+	}
+}
+
+// makeCutHandler returns the handler for the cut operator ~. It always
+// matches the empty string.
+func makeCutHandler() handler {
+	return func(r *Result, pos int) (int, error) {
+		r.commit(pos)
+		return 0, nil
+	}
+}
+
 func (r *Result) TopNode() *parser.Node {
 	last := len(r.nodeStack) - 1
 	if last < 0 {
@@ -445,7 +979,89 @@ func (r *Result) TopNode() *parser.Node {
 	return r.nodeStack[last]
 }
 
+// nodeState is the part of the top node that is modified by the
+// handlers and needs to be restored when a handler fails.
+type nodeState struct {
+	children        []*parser.Node
+	annotations     map[string]string
+	treeAnnotations map[string]*parser.Node
+	// indent is the indentation stack and cut is the cut flag, which are
+	// also restored on failure.
+	indent *indentLevel
+	cut    bool
+}
+
+func (r *Result) saveState() nodeState {
+	n := r.TopNode()
+	return nodeState{n.Children, n.Annotations, n.TreeAnnotations, r.indent, r.cut}
+}
+
+func (r *Result) restoreState(s nodeState) {
+	n := r.TopNode()
+	n.Children = s.children
+	n.Annotations = s.annotations
+	n.TreeAnnotations = s.treeAnnotations
+	r.indent = s.indent
+	r.cut = s.cut
+}
+
+// annotate sets a string annotation of the top node. The annotation maps
+// are copied on write, so that saved states are not affected.
+func (r *Result) annotate(key, value string) {
+	n := r.TopNode()
+	m := make(map[string]string, len(n.Annotations)+1)
+	for k, v := range n.Annotations {
+		m[k] = v
+	}
+	m[key] = value
+	n.Annotations = m
+}
+
+// annotateTree moves the last child of the top node, if it was attached
+// after the state s was saved, into the tree annotation key.
+func (r *Result) annotateTree(s nodeState, key string) {
+	n := r.TopNode()
+	last := len(n.Children) - 1
+	if last < len(s.children) {
+		// The rule did not produce a node.
+		return
+	}
+	r.setTreeAnnotation(key, n.Children[last])
+	n.Children = n.Children[:last]
+}
+
+// setTreeAnnotation sets a tree annotation of the top node, copying the
+// annotation map on write.
+func (r *Result) setTreeAnnotation(key string, value *parser.Node) {
+	n := r.TopNode()
+	m := make(map[string]*parser.Node, len(n.TreeAnnotations)+1)
+	for k, v := range n.TreeAnnotations {
+		m[k] = v
+	}
+	m[key] = value
+	n.TreeAnnotations = m
+}
+
+// Attach attaches the node n to the top node, or makes it the root of the
+// syntax tree if there is no top node. The marker of the rule that produced
+// n takes precedence over ParserOptions.SkipEmptyNodes.
 func (r *Result) Attach(n *parser.Node) {
+	if rule := r.Grammar.Rules[n.Label]; rule != nil && len(r.nodeStack) > 0 {
+		switch rule.Marker {
+		case "drop":
+			log.V(6).Infof("dropping %s", n.Label)
+			r.keepErrors(n)
+			return
+		case "inline":
+			log.V(6).Infof("inlining %s", n.Label)
+			r.inline(n)
+			return
+		case "keep":
+			last := len(r.nodeStack) - 1
+			r.nodeStack[last].Children = append(r.nodeStack[last].Children, n)
+			return
+		}
+	}
 	if r.Grammar.ParserOptions.SkipEmptyNodes &&
 		(n.Text == "" && len(n.Children) == 0 && len(n.Annotations) == 0 &&
 			len(n.TreeAnnotations) == 0 && len(r.nodeStack) > 0) {
@@ -465,6 +1081,20 @@ func (r *Result) Attach(n *parser.Node) {
 	r.nodeStack[last].Children = append(r.nodeStack[last].Children, n)
 }
 
+// inline attaches the children of n to the top node, and merges the
+// annotations of n into the annotations of the top node. The text captured
+// by n is discarded.
+func (r *Result) inline(n *parser.Node) {
+	top := r.TopNode()
+	top.Children = append(top.Children, n.Children...)
+	for k, v := range n.Annotations {
+		r.annotate(k, v)
+	}
+	for k, v := range n.TreeAnnotations {
+		r.setTreeAnnotation(k, v)
+	}
+}
+
 type rhsError struct {
 	rhs      *RHS
 	details  map[string]error
@@ -495,30 +1125,94 @@ func (rhs *RHS) ShortString() string {
 }
 
 func (term *Term) ShortString() string {
+	if term.Recover != "" {
+		t := *term
+		t.Recover = ""
+		return t.ShortString() + "^" + term.Recover
+	}
 	if term.Parens != nil {
 		return "(" + term.Parens.ShortString() + ")"
 	} else if term.NegPred != nil {
 		return "!" + term.NegPred.ShortString()
 	} else if term.Pred != nil {
 		return "&" + term.Pred.ShortString()
+	} else if term.SemPred != "" {
+		return "&{" + term.SemPred + "}"
+	} else if term.SemNegPred != "" {
+		return "!{" + term.SemNegPred + "}"
 	} else if term.Special != nil {
 		return term.Special.ShortString()
+	} else if term.Capture != nil && term.Label != "" {
+		return "<" + term.Label + ": " + term.Capture.ShortString() + ">"
 	} else if term.Capture != nil {
 		return "<" + term.Capture.ShortString() + ">"
 	} else if term.CharClass != nil {
+		if term.CharClass.IgnoreCase {
+			return "[" + term.CharClass.String() + "]i"
+		}
 		return "[" + term.CharClass.String() + "]"
 	} else if term.Literal != "" {
+		if term.IgnoreCase {
+			return strconv.Quote(term.Literal) + "i"
+		}
 		return strconv.Quote(term.Literal)
+	} else if term.Prec != nil {
+		return "%prec " + term.Ident + " " + term.Prec.ShortString()
+	} else if term.Ident != "" && term.Label != "" {
+		return term.Label + ":" + term.Ident
 	} else if term.Ident != "" {
 		return term.Ident
+	} else if term.Cut {
+		return "~"
+	} else if term.Indent != "" {
+		return term.Indent
+	} else if term.BackRef != "" {
+		return "$" + term.BackRef
 	}
 	return "<nil term>"
 }
 
 func (special *Special) ShortString() string {
+	if special.Rune == '{' {
+		return special.Term.ShortString() + special.bounds()
+	}
 	return fmt.Sprintf("%s%c", special.Term.ShortString(), special.Rune)
 }
 
+// bounds returns the source representation of the bounded repetition.
+func (special *Special) bounds() string {
+	switch {
+	case special.Max < 0:
+		return fmt.Sprintf("{%d,}", special.Min)
+	case special.Min == special.Max:
+		return fmt.Sprintf("{%d}", special.Min)
+	}
+	return fmt.Sprintf("{%d,%d}", special.Min, special.Max)
+}
+
+// parseRepeat parses the bounds n, n, or n,m of a bounded repetition.
+func parseRepeat(text string) (*Special, error) {
+	minText, maxText, comma := strings.Cut(text, ",")
+	min, err := strconv.Atoi(minText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repetition {%s}: %s", text, err)
+	}
+	max := min
+	switch {
+	case comma && maxText == "":
+		max = -1
+	case comma:
+		max, err = strconv.Atoi(maxText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repetition {%s}: %s", text, err)
+		}
+		if max < min {
+			return nil, fmt.Errorf("invalid repetition {%s}: maximum is less than minimum", text)
+		}
+	}
+	return &Special{Rune: '{', Min: min, Max: max}, nil
+}
+
 func groupToString(terms []*Term) string {
 	r := make([]string, 0, len(terms))
 	for _, term := range terms {
@@ -541,19 +1235,23 @@ func (g *Grammar) makeRHSHandler(rhs *RHS) (handler, error) {
 		hh = append(hh, h)
 	}
 	return func(r *Result, pos int) (int, error) {
-		save := r.TopNode().Children
+		scope := r.enter(pos, true)
+		save := r.saveState()
 		w, err := hh[0](r, pos)
 		if err == nil {
+			r.leave(scope)
 			return w, nil
 		}
 		errMap := map[string]error{groupToString(choices[0]): err}
-		for i := 1; err != nil && i < len(hh); i++ {
-			r.TopNode().Children = save
+		// The next alternative is not tried if the failed one passed a cut.
+		for i := 1; err != nil && i < len(hh) && !r.cut; i++ {
+			r.restoreState(save)
 			w, err = hh[i](r, pos)
 			if err != nil {
 				errMap[groupToString(choices[i])] = err
 			}
 		}
+		r.leave(scope)
 		if err != nil {
 			return w,
 				&rhsError{rhs: rhs, details: errMap, fyiError: r.fyiError}
@@ -589,22 +1287,42 @@ func (g *Grammar) makeGroupHandler(terms []*Term) (handler, error) {
 
 func (g *Grammar) makeTermHandler(term *Term) (handler, error) {
 	switch {
+	case term.Recover != "":
+		return g.makeRecoverHandler(term, g.makeTermHandler)
 	case term.Parens != nil:
 		return g.makeRHSHandler(term.Parens)
 	case term.NegPred != nil:
 		return g.makePredicateHandler(term.NegPred, false)
 	case term.Pred != nil:
 		return g.makePredicateHandler(term.Pred, true)
+	case term.SemPred != "" || term.SemNegPred != "":
+		return g.makeSemPredHandler(term)
 	case term.Special != nil:
 		return g.makeSpecialHandler(term.Special)
 	case term.Capture != nil:
-		return g.makeCaptureHandler(term.Capture)
+		return g.makeCaptureHandler(term.Capture, term.Label)
 	case term.CharClass != nil:
 		return g.makeCharClassHandler(term.CharClass)
+	case term.Literal != "" && term.IgnoreCase:
+		return g.makeLiteralFoldHandler(term.Literal)
 	case term.Literal != "":
 		return g.makeLiteralHandler(term.Literal)
+	case term.Prec != nil:
+		return g.makePrecedenceHandler(term)
+	case term.Ident != "" && term.Label != "":
+		h, err := g.makeRuleHandler(term.Ident)
+		if err != nil {
+			return nil, err
+		}
+		return makeLabeledHandler(h, term.Label), nil
 	case term.Ident != "":
 		return g.makeRuleHandler(term.Ident)
+	case term.Cut:
+		return makeCutHandler(), nil
+	case term.Indent != "":
+		return makeIndentHandler(term.Indent), nil
+	case term.BackRef != "":
+		return makeBackRefHandler(term.BackRef), nil
 	default:
 		log.Exitf("makeTermHandler NYI: %v", term)
 	}
@@ -616,7 +1334,7 @@ func (r *Result) computeRowCol(pos int) (row, col int) {
 	if ok {
 		return rowcol.Row, rowcol.Col
 	}
-	row, col = countRowCol(r.Source, 1, 0)
+	row, col = countRowCol(r.Source[:pos], 1, 0)
 	r.rowCol[pos] = RowCol{row, col}
 	return row, col
 }
@@ -630,11 +1348,13 @@ func (r *Result) parserErrorf(pos int, format string, args ...interface{}) error
 func (g *Grammar) makeLiteralHandler(literal string) (handler, error) {
 	return func(r *Result, pos int) (int, error) {
 		if len(r.Source)-pos < len(literal) {
+			r.expect(pos, strconv.Quote(literal))
 			return 0, fmt.Errorf("expecting %q, got %q",
 				literal, r.Source[pos:])
 		}
 		next := r.Source[pos : pos+len(literal)]
 		if next != literal {
+			r.expect(pos, strconv.Quote(literal))
 			return 0, fmt.Errorf("Expecting literal %q, got %q", literal, next)
 		}
 		// parse successful
@@ -642,42 +1362,77 @@ func (g *Grammar) makeLiteralHandler(literal string) (handler, error) {
 	}, nil
 }
 
+// makeLiteralFoldHandler makes the handler of a case-insensitive literal.
+// The matched input may have a different length in bytes than the literal,
+// e.g. "k"i matches the Kelvin sign U+212A.
+func (g *Grammar) makeLiteralFoldHandler(literal string) (handler, error) {
+	expected := strconv.Quote(literal) + "i"
+	return func(r *Result, pos int) (int, error) {
+		w := foldPrefix(r.Source[pos:], literal)
+		if w < 0 {
+			r.expect(pos, expected)
+			return 0, fmt.Errorf("expecting %s, got %q", expected, r.Source[pos:])
+		}
+		return w, nil
+	}, nil
+}
+
+// foldPrefix returns the length in bytes of the prefix of s that is equal
+// to literal under Unicode simple case folding, or -1 if there is none.
+func foldPrefix(s, literal string) int {
+	n := 0
+	for _, lc := range literal {
+		c, w := utf8.DecodeRuneInString(s[n:])
+		if w == 0 || !equalFold(c, lc) {
+			return -1
+		}
+		n += w
+	}
+	return n
+}
+
+// foldSuffix returns the length in bytes of the suffix of s that is equal
+// to literal under Unicode simple case folding, or -1 if there is none.
+func foldSuffix(s, literal string) int {
+	n := 0
+	for i := len(literal); i > 0; {
+		lc, lw := utf8.DecodeLastRuneInString(literal[:i])
+		c, w := utf8.DecodeLastRuneInString(s[:len(s)-n])
+		if w == 0 || !equalFold(c, lc) {
+			return -1
+		}
+		i -= lw
+		n += w
+	}
+	return n
+}
+
+// equalFold reports whether the runes a and b are equal under Unicode
+// simple case folding.
+func equalFold(a, b rune) bool {
+	for f := unicode.SimpleFold(a); a != b; f = unicode.SimpleFold(f) {
+		if f == a {
+			return false
+		}
+		if f == b {
+			return true
+		}
+	}
+	return true
+}
+
 func (g *Grammar) makeCharClassHandler(cc *charclass.CharClass) (handler, error) {
+	expected := describeCharClass(cc)
+	decode := g.decodeChar()
 	if cc.Special != "" {
 		return func(r *Result, pos int) (int, error) {
-			c, w := utf8.DecodeRuneInString(r.Source[pos:])
+			c, w := decode(r.Source[pos:])
 			if w == 0 {
+				r.expect(pos, expected)
 				return 0, fmt.Errorf("expecting char, got EOF")
 			}
-			var match bool
-			switch cc.Special {
-			case "[:any:]":
-				match = true
-			case "[:alnum:]":
-				match = unicode.IsLetter(c) || unicode.IsDigit(c)
-			case "IsLetter":
-				match = unicode.IsLetter(c)
-			case "IsNumber":
-				match = unicode.IsNumber(c)
-			case "IsSpace":
-				match = unicode.IsSpace(c)
-			case "IsLower":
-				match = unicode.IsLower(c)
-			case "IsUpper":
-				match = unicode.IsUpper(c)
-			case "IsPunct":
-				match = unicode.IsPunct(c)
-			case "IsPrint":
-				match = unicode.IsPrint(c)
-			case "IsGraphic":
-				match = unicode.IsGraphic(c)
-			case "IsControl":
-				match = unicode.IsControl(c)
-			}
-			if cc.Negated {
-				match = !match
-			}
-			if !match {
+			if !cc.Matches(c) {
+				r.expect(pos, expected)
 				return 0, fmt.Errorf("character %q does not match class %q", c, cc)
 			}
 			return w, nil
@@ -685,31 +1440,188 @@ func (g *Grammar) makeCharClassHandler(cc *charclass.CharClass) (handler, error)
 	}
 	// Regular map case.
 	return func(r *Result, pos int) (int, error) {
-		c, w := utf8.DecodeRuneInString(r.Source[pos:])
+		c, w := decode(r.Source[pos:])
 		if w == 0 {
+			r.expect(pos, expected)
 			return 0, fmt.Errorf("expecting char, got EOF")
 		}
 		if c == utf8.RuneError {
+			r.expect(pos, expected)
 			return 0, fmt.Errorf("expecting utf-8 char, got RuneError")
 		}
-		match := false
-		if cc.Map != nil {
-			match = cc.Map[c]
+		if !cc.Matches(c) {
+			r.expect(pos, expected)
+			return 0, fmt.Errorf("character %q does not match class %q", c, cc)
+		}
+		return w, nil
+	}, nil
+}
+
+// decodeChar returns the function that decodes the first char of the input,
+// which is a UTF-8 rune, or a byte in byte mode.
+func (g *Grammar) decodeChar() func(string) (rune, int) {
+	if !g.ByteMode {
+		return utf8.DecodeRuneInString
+	}
+	return func(s string) (rune, int) {
+		if s == "" {
+			return utf8.RuneError, 0
 		}
-		if cc.RangeTable != nil {
-			match = match || unicode.Is(cc.RangeTable, c)
+		return rune(s[0]), 1
+	}
+}
+
+// decodeLastChar is like decodeChar, but decodes the last char of the input.
+func (g *Grammar) decodeLastChar() func(string) (rune, int) {
+	if !g.ByteMode {
+		return utf8.DecodeLastRuneInString
+	}
+	return func(s string) (rune, int) {
+		if s == "" {
+			return utf8.RuneError, 0
 		}
-		if cc.Negated {
-			match = !match
+		return rune(s[len(s)-1]), 1
+	}
+}
+
+// makeSemPredHandler makes the handler of a semantic predicate &{name} or
+// !{name}. The same handler serves both forward and backward parsing.
+func (g *Grammar) makeSemPredHandler(term *Term) (handler, error) {
+	name, negative := term.SemPred, false
+	if term.SemNegPred != "" {
+		name, negative = term.SemNegPred, true
+	}
+	pred, ok := g.Predicates[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined predicate: %s", name)
+	}
+	expected := term.ShortString()
+	return func(r *Result, pos int) (int, error) {
+		if pred(r, pos, r.TopNode()) == negative {
+			r.expect(pos, expected)
+			return 0, fmt.Errorf("semantic predicate %s failed", expected)
 		}
-		if !match {
-			return 0, fmt.Errorf("character %q does not match class %q", c, cc)
+		return 0, nil
+	}, nil
+}
+
+// makeRecoverHandler makes the handler of a labeled failure term^label.
+// The handler of the term itself is made by makeTerm, so that the same code
+// serves both forward and backward parsing.
+func (g *Grammar) makeRecoverHandler(term *Term, makeTerm func(*Term) (handler, error)) (handler, error) {
+	rule, ok := g.Rules[term.Recover]
+	if !ok {
+		return nil, fmt.Errorf("unknown recovery rule: %s", term.Recover)
+	}
+	t := *term
+	t.Recover = ""
+	h, err := makeTerm(&t)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *Result, pos int) (int, error) {
+		save := r.saveState()
+		k := r.push(pos)
+		w, err := h(r, pos)
+		r.points = r.points[:k]
+		if err == nil {
+			return w, nil
 		}
-		return w, nil
+		r.restoreState(save)
+		return r.recover(rule, pos, w, err)
 	}, nil
 }
 
-func (g *Grammar) makeCaptureHandler(rhs *RHS) (handler, error) {
+// recover is called when a labeled failure term fails at pos with w, err.
+// It applies the recovery rule at pos, and if the rule matches, attaches an
+// Error node with the skipped input to the top node. The Error node holds
+// the syntax error in Err and the label in the "label" annotation, and its
+// children are the nodes produced by the recovery rule. The expectations
+// recorded so far are reported by the syntax error, so they are discarded.
+// If the recovery rule fails, the original failure is returned.
+func (r *Result) recover(rule *Rule, pos, w int, err error) (int, error) {
+	perr := r.parseError(pos)
+	perr.Label = rule.Ident
+	n := &parser.Node{Label: "Error", Pos: pos, Err: perr,
+		Annotations: map[string]string{"label": rule.Ident}}
+	r.nodeStack.Push(n)
+	var rw int
+	var rErr error
+	if r.backward {
+		rw, rErr = r.backwardApply(rule, pos)
+	} else {
+		rw, rErr = r.apply(rule, pos)
+	}
+	r.nodeStack.Pop()
+	if rErr != nil {
+		return w, err
+	}
+	n.Len = rw
+	if r.backward {
+		n.Text = r.Source[pos-rw : pos]
+	} else {
+		n.Text = r.Source[pos : pos+rw]
+	}
+	r.farthest = pos
+	r.expected = nil
+	top := r.TopNode()
+	top.Children = append(top.Children, n)
+	return rw, nil
+}
+
+// collectErrors returns the syntax errors held by the Error nodes of the
+// tree n in the order of input positions.
+func collectErrors(n *parser.Node) []*ParseError {
+	var errs []*ParseError
+	var walk func(n *parser.Node)
+	walk = func(n *parser.Node) {
+		if perr, ok := n.Err.(*ParseError); ok && n.Label == "Error" {
+			errs = append(errs, perr)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+		for _, c := range n.TreeAnnotations {
+			walk(c)
+		}
+	}
+	walk(n)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pos < errs[j].Pos })
+	return errs
+}
+
+// keepErrors attaches the Error nodes from the subtree of the dropped node n
+// to the top node, so that the recovered syntax errors are not lost.
+func (r *Result) keepErrors(n *parser.Node) {
+	for _, c := range n.Children {
+		if _, ok := c.Err.(*ParseError); ok && c.Label == "Error" {
+			top := r.TopNode()
+			top.Children = append(top.Children, c)
+			continue
+		}
+		r.keepErrors(c)
+	}
+}
+
+// makeLabeledHandler wraps the handler of a rule reference so that the node
+// produced by the rule is stored in the tree annotation label of the parent
+// node instead of its children.
+func makeLabeledHandler(h handler, label string) handler {
+	return func(r *Result, pos int) (int, error) {
+		save := r.saveState()
+		w, err := h(r, pos)
+		if err != nil {
+			return w, err
+		}
+		r.annotateTree(save, label)
+		return w, nil
+	}
+}
+
+// makeCaptureHandler makes the handler of a capture. The captured text
+// is stored in the annotation label of the top node if label is set, and
+// in Text otherwise.
+func (g *Grammar) makeCaptureHandler(rhs *RHS, label string) (handler, error) {
 	h, err := g.makeRHSHandler(rhs)
 	if err != nil {
 		return nil, err
@@ -724,11 +1636,57 @@ func (g *Grammar) makeCaptureHandler(rhs *RHS) (handler, error) {
 			return 0, fmt.Errorf("internal error, " +
 				"cannot handle capture without a top node")
 		}
+		if label != "" {
+			r.annotate(label, r.Source[pos:pos+w])
+			return w, nil
+		}
 		n.Text = r.Source[pos : pos+w]
 		return w, nil
 	}, nil
 }
 
+// checkBackRefs checks that every back-reference $name of the rule refers
+// to a labeled capture <name: ...> of the same rule. Since the captured text
+// is stored in the node of the rule being parsed, the back-references never
+// see the captures of other rules, and the memoized results of a rule do not
+// depend on the context it is applied in.
+func checkBackRefs(rule *Rule) error {
+	labels := make(map[string]bool)
+	forEachTerm(rule.RHS, func(term *Term) {
+		if term.Capture != nil && term.Label != "" {
+			labels[term.Label] = true
+		}
+	})
+	var err error
+	forEachTerm(rule.RHS, func(term *Term) {
+		if term.BackRef != "" && !labels[term.BackRef] && err == nil {
+			err = fmt.Errorf("back-reference $%s does not match a capture <%s: ...> in rule %s",
+				term.BackRef, term.BackRef, rule.Ident)
+		}
+	})
+	return err
+}
+
+// makeBackRefHandler makes the handler of a back-reference $label, which
+// matches the text stored by the labeled capture <label: ...> in the top
+// node. The annotations are restored on backtracking, so the back-reference
+// sees the last capture on the current parse path. It fails if the capture
+// has not matched yet.
+func makeBackRefHandler(label string) handler {
+	return func(r *Result, pos int) (int, error) {
+		text, ok := r.TopNode().Annotations[label]
+		if !ok {
+			r.expect(pos, "$"+label)
+			return 0, fmt.Errorf("back-reference $%s before capture", label)
+		}
+		if !strings.HasPrefix(r.Source[pos:], text) {
+			r.expect(pos, strconv.Quote(text))
+			return 0, fmt.Errorf("expecting $%s = %q, got %q", label, text, r.Source[pos:])
+		}
+		return len(text), nil
+	}
+}
+
 func (g *Grammar) makeSpecialHandler(special *Special) (handler, error) {
 	h, err := g.makeTermHandler(special.Term)
 	if err != nil {
@@ -741,6 +1699,8 @@ func (g *Grammar) makeSpecialHandler(special *Special) (handler, error) {
 		return g.makeQuestionHandler(h)
 	case '+':
 		return g.makePlusHandler(h)
+	case '{':
+		return g.makeRepeatHandler(h, special.Min, special.Max)
 	default:
 		log.Exitf("invalid special: %q", special.Rune)
 	}
@@ -751,16 +1711,19 @@ func (g *Grammar) makeStarHandler(h handler) (handler, error) {
 	return func(r *Result, pos int) (int, error) {
 		ww := 0
 		// We want to get the longest match
-		save := r.TopNode().Children
+		save := r.saveState()
+		k := r.push(pos)
 		var w int
 		var err error
 		for w, err = h(r, pos); err == nil && w > 0; w, err = h(r, pos+ww) {
 			ww += w
 			// Update the saved nodes in case of success
-			save = r.TopNode().Children
+			save = r.saveState()
+			r.points[k] = pos + ww
 		}
+		r.points = r.points[:k]
 		// Reset the nodes appended by the last unsuccessful match.
-		r.TopNode().Children = save
+		r.restoreState(save)
 		// Store the error just as FYI.
 		if ww == 0 && err != nil {
 			log.V(4).Infof("StarHandler error: %s", err)
@@ -778,22 +1741,63 @@ func (g *Grammar) makePlusHandler(h handler) (handler, error) {
 			return ww, err
 		}
 		// We want to get the longest match
-		save := r.TopNode().Children
-		for w, err := h(r, pos+ww); err == nil && w > 0; w, err = h(r, pos+ww) {
+		save := r.saveState()
+		k := r.push(pos + ww)
+		var w int
+		for w, err = h(r, pos+ww); err == nil && w > 0; w, err = h(r, pos+ww) {
 			ww += w
 			// Update the saved nodes in case of success
-			save = r.TopNode().Children
+			save = r.saveState()
+			r.points[k] = pos + ww
 		}
+		r.points = r.points[:k]
 		// Reset the nodes appended by the last unsuccessful match.
-		r.TopNode().Children = save
+		r.restoreState(save)
+		return ww, nil
+	}, nil
+}
+
+// makeRepeatHandler makes the handler of the bounded repetition {min,max}.
+// If max is negative, the number of repetitions is not bounded.
+func (g *Grammar) makeRepeatHandler(h handler, min, max int) (handler, error) {
+	return func(r *Result, pos int) (int, error) {
+		ww := 0
+		save := r.saveState()
+		k := r.push(pos)
+		for n := 0; max < 0 || n < max; n++ {
+			w, err := h(r, pos+ww)
+			if err != nil && n < min {
+				r.points = r.points[:k]
+				return ww + w, err
+			}
+			if err != nil {
+				break
+			}
+			ww += w
+			// Update the saved nodes in case of success
+			save = r.saveState()
+			r.points[k] = pos + ww
+			if w == 0 {
+				// The remaining repetitions would match empty input as well.
+				break
+			}
+		}
+		r.points = r.points[:k]
+		// Reset the nodes appended by the last unsuccessful match.
+		r.restoreState(save)
 		return ww, nil
 	}, nil
 }
 
 func (g *Grammar) makeQuestionHandler(h handler) (handler, error) {
 	return func(r *Result, pos int) (int, error) {
+		save := r.saveState()
+		k := r.push(pos)
 		w, err := h(r, pos)
+		r.points = r.points[:k]
 		if err != nil {
+			// Reset the nodes appended by the unsuccessful match.
+			r.restoreState(save)
 			// Question option always matches, in worst case it's zero length
 			return 0, nil
 		}
@@ -807,7 +1811,12 @@ func (g *Grammar) makePredicateHandler(term *Term, positive bool) (handler, erro
 		return nil, err
 	}
 	return func(r *Result, pos int) (int, error) {
+		r.predicateLevel++
+		scope, indent := r.enter(pos, false), r.indent
 		_, err := h(r, pos)
+		r.leave(scope)
+		r.indent = indent
+		r.predicateLevel--
 		if positive == (err == nil) {
 			return 0, nil
 		}
@@ -871,13 +1880,7 @@ func (r *Result) computeContent(n *parser.Node, pos, row, col int) (int, int) {
 }
 
 func (g *Grammar) ParseBackward(input string) (*Result, error) {
-	result := &Result{
-		Grammar:   g,
-		Source:    input,
-		memo:      make(map[int]map[*Rule]*parser.Node),
-		nodeStack: make(NodeStack, 0, 10),
-		rowCol:    make(map[int]RowCol),
-	}
+	result := g.newResult(input)
 	if len(g.RuleNames) == 0 {
 		return nil, fmt.Errorf("invalid grammar without rules")
 	}
@@ -886,6 +1889,14 @@ func (g *Grammar) ParseBackward(input string) (*Result, error) {
 	if !ok {
 		return nil, fmt.Errorf("invalid grammar with missing top rule %s", topRule)
 	}
+	if top.backwardHandler == nil {
+		return nil, fmt.Errorf("grammar is not compiled, use New")
+	}
+	if g.backwardErr != nil {
+		return nil, g.backwardErr
+	}
+	result.backward = true
+	result.committed = len(input)
 	// TODO(salikh): check whether backwardApply requires anything special, or if apply() can be shared.
 	w, err := result.backwardApply(top, len(input))
 	if err != nil {
@@ -901,6 +1912,7 @@ func (g *Grammar) ParseBackward(input string) (*Result, error) {
 			len(result.nodeStack))
 	}
 	reverse(result.Tree)
+	result.Errors = collectErrors(result.Tree)
 	return result, nil
 }
 
@@ -908,6 +1920,9 @@ func reverse(n *parser.Node) {
 	log.V(5).Infof("reversing %s %#v", n.Label, n)
 	// Make pos to point at the start of the matched content.
 	n.Pos -= n.Len
+	for _, ch := range n.TreeAnnotations {
+		reverse(ch)
+	}
 	if len(n.Children) == 0 {
 		return
 	}
@@ -935,12 +1950,14 @@ func (g *Grammar) makeBackwardRHSHandler(rhs *RHS) (handler, error) {
 		hh = append(hh, h)
 	}
 	return func(r *Result, pos int) (int, error) {
-		save := r.TopNode().Children
+		scope := r.enter(pos, true)
+		save := r.saveState()
 		w, err := hh[0](r, pos)
-		for i := 1; err != nil && i < len(hh); i++ {
-			r.TopNode().Children = save
+		for i := 1; err != nil && i < len(hh) && !r.cut; i++ {
+			r.restoreState(save)
 			w, err = hh[i](r, pos)
 		}
+		r.leave(scope)
 		// TODO(salikh): Collect errors from all branches to make
 		// the error message more user-friendly.
 		return w, err
@@ -975,22 +1992,51 @@ func (g *Grammar) makeBackwardGroupHandler(terms []*Term) (handler, error) {
 
 func (g *Grammar) makeBackwardTermHandler(term *Term) (handler, error) {
 	switch {
+	case term.Recover != "":
+		return g.makeRecoverHandler(term, g.makeBackwardTermHandler)
 	case term.Parens != nil:
 		return g.makeBackwardRHSHandler(term.Parens)
 	case term.NegPred != nil:
 		return g.makeBackwardPredicateHandler(term.NegPred, false)
 	case term.Pred != nil:
 		return g.makeBackwardPredicateHandler(term.Pred, true)
+	case term.SemPred != "" || term.SemNegPred != "":
+		return g.makeSemPredHandler(term)
 	case term.Special != nil:
 		return g.makeBackwardSpecialHandler(term.Special)
 	case term.Capture != nil:
-		return g.makeBackwardCaptureHandler(term.Capture)
+		return g.makeBackwardCaptureHandler(term.Capture, term.Label)
 	case term.CharClass != nil:
 		return g.makeBackwardCharClassHandler(term.CharClass)
+	case term.Literal != "" && term.IgnoreCase:
+		return g.makeBackwardLiteralFoldHandler(term.Literal)
 	case term.Literal != "":
 		return g.makeBackwardLiteralHandler(term.Literal)
+	case term.Prec != nil:
+		g.backwardErr = fmt.Errorf("precedence table of rule %s is not supported by the backward parser", term.Ident)
+		return func(r *Result, pos int) (int, error) {
+			return 0, g.backwardErr
+		}, nil
+	case term.Ident != "" && term.Label != "":
+		h, err := g.makeBackwardRuleHandler(term.Ident)
+		if err != nil {
+			return nil, err
+		}
+		return makeLabeledHandler(h, term.Label), nil
 	case term.Ident != "":
 		return g.makeBackwardRuleHandler(term.Ident)
+	case term.Cut:
+		return makeCutHandler(), nil
+	case term.Indent != "":
+		g.backwardErr = fmt.Errorf("%s is not supported by the backward parser", term.Indent)
+		return func(r *Result, pos int) (int, error) {
+			return 0, g.backwardErr
+		}, nil
+	case term.BackRef != "":
+		g.backwardErr = fmt.Errorf("back-reference $%s is not supported by the backward parser", term.BackRef)
+		return func(r *Result, pos int) (int, error) {
+			return 0, g.backwardErr
+		}, nil
 	default:
 		log.Exitf("makeBackwardTermHandler NYI: %v", term)
 	}
@@ -1015,45 +2061,28 @@ func (g *Grammar) makeBackwardLiteralHandler(literal string) (handler, error) {
 	}, nil
 }
 
+func (g *Grammar) makeBackwardLiteralFoldHandler(literal string) (handler, error) {
+	return func(r *Result, pos int) (int, error) {
+		w := foldSuffix(r.Source[:pos], literal)
+		if w < 0 {
+			return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[:pos])
+		}
+		return w, nil
+	}, nil
+}
+
 func (g *Grammar) makeBackwardCharClassHandler(cc *charclass.CharClass) (handler, error) {
+	decode := g.decodeLastChar()
 	if cc.Special != "" {
 		return func(r *Result, pos int) (int, error) {
-			c, w := utf8.DecodeLastRuneInString(r.Source[:pos])
+			c, w := decode(r.Source[:pos])
 			if w == 0 {
 				return 0, fmt.Errorf("expecting char, got EOF")
 			}
 			if c == utf8.RuneError {
 				return 0, fmt.Errorf("expecting utf-8 char, got RuneError")
 			}
-			var match bool
-			switch cc.Special {
-			case "[:any:]":
-				match = true
-			case "[:alnum:]":
-				match = unicode.IsLetter(c) || unicode.IsDigit(c)
-			case "IsLetter":
-				match = unicode.IsLetter(c)
-			case "IsNumber":
-				match = unicode.IsNumber(c)
-			case "IsSpace":
-				match = unicode.IsSpace(c)
-			case "IsLower":
-				match = unicode.IsLower(c)
-			case "IsUpper":
-				match = unicode.IsUpper(c)
-			case "IsPunct":
-				match = unicode.IsPunct(c)
-			case "IsPrint":
-				match = unicode.IsPrint(c)
-			case "IsGraphic":
-				match = unicode.IsGraphic(c)
-			case "IsControl":
-				match = unicode.IsControl(c)
-			}
-			if cc.Negated {
-				match = !match
-			}
-			if !match {
+			if !cc.Matches(c) {
 				return 0, fmt.Errorf("character %q does not match class %q", c, cc)
 			}
 			return w, nil
@@ -1061,31 +2090,21 @@ func (g *Grammar) makeBackwardCharClassHandler(cc *charclass.CharClass) (handler
 	}
 	// Regular map case.
 	return func(r *Result, pos int) (int, error) {
-		c, w := utf8.DecodeLastRuneInString(r.Source[:pos])
+		c, w := decode(r.Source[:pos])
 		if w == 0 {
 			return 0, fmt.Errorf("expecting char, got EOF")
 		}
 		if c == utf8.RuneError {
 			return 0, fmt.Errorf("expecting utf-8 char, got RuneError")
 		}
-		match := false
-		if cc.Map != nil {
-			match = cc.Map[c]
-		}
-		if cc.RangeTable != nil {
-			match = match || unicode.Is(cc.RangeTable, c)
-		}
-		if cc.Negated {
-			match = !match
-		}
-		if !match {
+		if !cc.Matches(c) {
 			return 0, fmt.Errorf("character %q does not match class %q", c, cc)
 		}
 		return w, nil
 	}, nil
 }
 
-func (g *Grammar) makeBackwardCaptureHandler(rhs *RHS) (handler, error) {
+func (g *Grammar) makeBackwardCaptureHandler(rhs *RHS, label string) (handler, error) {
 	h, err := g.makeBackwardRHSHandler(rhs)
 	if err != nil {
 		return nil, err
@@ -1100,6 +2119,10 @@ func (g *Grammar) makeBackwardCaptureHandler(rhs *RHS) (handler, error) {
 			return 0, fmt.Errorf("internal error, " +
 				"cannot handle capture without a top node")
 		}
+		if label != "" {
+			r.annotate(label, r.Source[pos-w:pos])
+			return w, nil
+		}
 		n.Text = r.Source[pos-w : pos]
 		return w, nil
 	}, nil
@@ -1117,6 +2140,8 @@ func (g *Grammar) makeBackwardSpecialHandler(special *Special) (handler, error)
 		return g.makeBackwardQuestionHandler(h)
 	case '+':
 		return g.makeBackwardPlusHandler(h)
+	case '{':
+		return g.makeBackwardRepeatHandler(h, special.Min, special.Max)
 	default:
 		log.Exitf("invalid special: %q", special.Rune)
 	}
@@ -1127,14 +2152,19 @@ func (g *Grammar) makeBackwardStarHandler(h handler) (handler, error) {
 	return func(r *Result, pos int) (int, error) {
 		ww := 0
 		// We want to get the longest match
-		save := r.TopNode().Children
-		for w, err := h(r, pos); err == nil && w > 0; w, err = h(r, pos-ww) {
+		save := r.saveState()
+		k := r.push(pos)
+		var w int
+		var err error
+		for w, err = h(r, pos); err == nil && w > 0; w, err = h(r, pos-ww) {
 			ww += w
 			// Update the saved nodes in case of success
-			save = r.TopNode().Children
+			save = r.saveState()
+			r.points[k] = pos - ww
 		}
+		r.points = r.points[:k]
 		// Reset the nodes appended by the last unsuccessful match.
-		r.TopNode().Children = save
+		r.restoreState(save)
 		// Star repetition always matches, in worst case it's zero length
 		return ww, nil
 	}, nil
@@ -1147,22 +2177,61 @@ func (g *Grammar) makeBackwardPlusHandler(h handler) (handler, error) {
 			return ww, err
 		}
 		// We want to get the longest match
-		save := r.TopNode().Children
-		for w, err := h(r, pos-ww); err == nil && w > 0; w, err = h(r, pos-ww) {
+		save := r.saveState()
+		k := r.push(pos - ww)
+		var w int
+		for w, err = h(r, pos-ww); err == nil && w > 0; w, err = h(r, pos-ww) {
+			ww += w
+			// Update the saved nodes in case of success
+			save = r.saveState()
+			r.points[k] = pos - ww
+		}
+		r.points = r.points[:k]
+		// Reset the nodes appended by the last unsuccessful match.
+		r.restoreState(save)
+		return ww, nil
+	}, nil
+}
+
+func (g *Grammar) makeBackwardRepeatHandler(h handler, min, max int) (handler, error) {
+	return func(r *Result, pos int) (int, error) {
+		ww := 0
+		save := r.saveState()
+		k := r.push(pos)
+		for n := 0; max < 0 || n < max; n++ {
+			w, err := h(r, pos-ww)
+			if err != nil && n < min {
+				r.points = r.points[:k]
+				return ww + w, err
+			}
+			if err != nil {
+				break
+			}
 			ww += w
 			// Update the saved nodes in case of success
-			save = r.TopNode().Children
+			save = r.saveState()
+			r.points[k] = pos - ww
+			if w == 0 {
+				// The remaining repetitions would match empty input as well.
+				break
+			}
 		}
+		r.points = r.points[:k]
 		// Reset the nodes appended by the last unsuccessful match.
-		r.TopNode().Children = save
+		r.restoreState(save)
 		return ww, nil
 	}, nil
 }
 
 func (g *Grammar) makeBackwardQuestionHandler(h handler) (handler, error) {
 	return func(r *Result, pos int) (int, error) {
+		save := r.saveState()
+		k := r.push(pos)
 		w, err := h(r, pos)
+		r.points = r.points[:k]
 		if err != nil {
+			// Reset the nodes appended by the unsuccessful match.
+			r.restoreState(save)
 			// Question option always matches, in worst case it's zero length
 			return 0, nil
 		}
@@ -1177,7 +2246,11 @@ func (g *Grammar) makeBackwardPredicateHandler(term *Term, positive bool) (handl
 		return nil, err
 	}
 	return func(r *Result, pos int) (int, error) {
+		r.predicateLevel++
+		scope := r.enter(pos, false)
 		_, err := h(r, pos)
+		r.leave(scope)
+		r.predicateLevel--
 		if positive == (err == nil) {
 			return 0, nil
 		}
@@ -1202,25 +2275,36 @@ func (r *Result) backwardApply(ru *Rule, pos int) (int, error) {
 	log.V(5).Infof("backwardApply(%s, %d)  {%s}", ru.Ident, pos, r.Source[0:pos])
 	memo, ok := r.memo[pos]
 	if !ok {
-		memo = make(map[*Rule]*parser.Node)
+		memo = make(map[memoKey]*memoEntry)
 		r.memo[pos] = memo
 	}
-	n := memo[ru]
-	if n != nil {
-		if n.Err != nil {
-			return n.Len, n.Err
+	// The backward parser does not support the indentation terms, so the
+	// indentation stack is always empty.
+	key := memoKey{ru, nil}
+	if e := memo[key]; e != nil {
+		if e.node.Err != nil {
+			return e.node.Len, e.node.Err
 		}
 		// Since nodes are attached in backward direction, the trees will be reversed.
-		r.Attach(n)
-		return n.Len, nil
+		r.Attach(e.node)
+		return e.node.Len, nil
 	}
-	n = &parser.Node{Label: ru.Ident, Pos: pos}
+	scope := r.enter(pos, false)
+	if ru.backwardLeftRecursion.leader {
+		w, err := r.growSeed(ru, pos, memo, ru.backwardHandler)
+		r.leave(scope)
+		return w, err
+	}
+	n := &parser.Node{Label: ru.Ident, Pos: pos}
 	r.nodeStack.Push(n)
 	w, hErr := ru.backwardHandler(r, pos)
+	r.leave(scope)
 	n = r.nodeStack.Pop()
 	n.Len = w
 	n.Err = hErr
-	memo[ru] = n
+	if !ru.backwardLeftRecursion.recursive {
+		memo[key] = &memoEntry{n, nil}
+	}
 	n.Len = w
 	log.V(6).Infof("attaching %s", n.Label)
 	r.Attach(n)
@@ -1229,17 +2313,12 @@ func (r *Result) backwardApply(ru *Rule, pos int) (int, error) {
 
 // ParseRule parses the input starting with a specified rule.
 // If the ruleName is empty, uses the top rule.
+// If the input does not match, the returned error is a *ParseError.
 func (g *Grammar) ParseRule(input, ruleName string) (*Result, error) {
 	if g == nil {
 		return nil, fmt.Errorf("nil grammar")
 	}
-	result := &Result{
-		Grammar:   g,
-		Source:    input,
-		memo:      make(map[int]map[*Rule]*parser.Node),
-		nodeStack: make(NodeStack, 0, 10),
-		rowCol:    make(map[int]RowCol),
-	}
+	result := g.newResult(input)
 	if len(g.RuleNames) == 0 {
 		return nil, fmt.Errorf("invalid grammar without rules")
 	}
@@ -1250,27 +2329,22 @@ func (g *Grammar) ParseRule(input, ruleName string) (*Result, error) {
 	if !ok {
 		return nil, fmt.Errorf("missing rule %s", ruleName)
 	}
+	if rule.handler == nil {
+		return nil, fmt.Errorf("grammar is not compiled, use New")
+	}
 	w, err := result.apply(rule, 0)
 	if err != nil {
-		return result, err
-	}
-	if w == 0 && len(input) > 0 {
-		return result, fmt.Errorf("grammar matched 0 characters: %s", result.fyiError)
+		return result, result.parseError(0)
 	}
-	if w != len(input) && !g.ParserOptions.IgnoreUnconsumedTail {
-		errContent := input[w:]
-		if !g.ParserOptions.LongErrorMessage {
-			num := 10
-			if len(errContent) > num {
-				errContent = errContent[:num] + "..."
-			}
-		}
-		return result, fmt.Errorf("some characers remain unconsumed: %q"+
-			"\nPrevious error: %s", errContent, result.fyiError)
+	if (w == 0 && len(input) > 0) ||
+		(w != len(input) && !g.ParserOptions.IgnoreUnconsumedTail) {
+		result.expect(w, "end of input")
+		return result, result.parseError(w)
 	}
 	if result.Tree == nil {
 		return nil, fmt.Errorf("internal error: no syntax tree. len(nodeStack) = %d",
 			len(result.nodeStack))
 	}
+	result.Errors = collectErrors(result.Tree)
 	return result, nil
 }
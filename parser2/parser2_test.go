@@ -19,11 +19,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/salikh/peg/compat/runfiles"
+	"github.com/salikh/peg/parser"
 	"github.com/salikh/peg/tests"
+	"github.com/salikh/peg/tree"
 )
 
 func TestInvalidGrammars(t *testing.T) {
@@ -204,3 +208,561 @@ func TestParserCapture(t *testing.T) {
 		testParserCapture(t, test)
 	}
 }
+
+func testParserTree(t *testing.T, test tests.TreeTest) {
+	testParserTreeOptions(t, test, &ParserOptions{SkipEmptyNodes: true})
+}
+
+func testParserTreeOptions(t *testing.T, test tests.TreeTest, options *ParserOptions) {
+	g, err := New(test.Grammar, options)
+	if err != nil {
+		t.Errorf("New(%q) returns error %q, want success", test.Grammar, err)
+		return
+	}
+	for _, tt := range test.Outcomes {
+		t.Run(tt.Input, func(t *testing.T) {
+			result, err := g.Parse(tt.Input)
+			if tt.Tree == "" {
+				if err == nil {
+					t.Errorf("New(%q).Parse(%q) returns success with tree %s, want error",
+						test.Grammar, tt.Input, result.Tree)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("New(%q).Parse(%q) returns error %s, want success",
+					test.Grammar, tt.Input, err)
+				return
+			}
+			want, err := tree.Parse(tt.Tree)
+			if err != nil {
+				t.Errorf("error in test, invalid wanted tree %s: %s", tt.Tree, err)
+				return
+			}
+			diffs := tree.Diff(result.Tree, want)
+			if len(diffs) > 0 {
+				t.Errorf("New(%q).Parse(%q) returns tree\n%s\n---, want\n%s\n---\ndiffs:\n%s",
+					test.Grammar, tt.Input, result.Tree, want, strings.Join(diffs, "\n"))
+			}
+		})
+	}
+}
+
+func TestLeftRecursion(t *testing.T) {
+	for _, test := range tests.LeftRecursion {
+		testParserTree(t, test)
+	}
+}
+
+func TestParameterized(t *testing.T) {
+	for _, test := range tests.Parameterized {
+		testParserTree(t, test)
+	}
+}
+
+func TestIgnoreCase(t *testing.T) {
+	for _, test := range tests.IgnoreCase {
+		testParserTree(t, test)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	for _, test := range tests.Labels {
+		testParserTree(t, test)
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	for _, test := range tests.Markers {
+		testParserTree(t, test)
+	}
+}
+
+func TestCut(t *testing.T) {
+	for _, test := range tests.Cut {
+		testParserTree(t, test)
+	}
+}
+
+func TestCutDiscardsMemo(t *testing.T) {
+	g, err := New(`List <- ( Item ~ )*
+Item <- < [a-z] > ','`, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := strings.Repeat("a,", 100)
+	result, err := g.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) returns error %s, want success", input, err)
+	}
+	if len(result.memo) > 2 {
+		t.Errorf("Parse(%q) keeps %d memo entries, want at most 2", input, len(result.memo))
+	}
+	// The backward parser matches the sequence from the end, so the cut
+	// goes first.
+	g, err = New(`List <- ( ~ Item )*
+Item <- < [a-z] > ','`, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	result, err = g.ParseBackward(input)
+	if err != nil {
+		t.Fatalf("ParseBackward(%q) returns error %s, want success", input, err)
+	}
+	if len(result.memo) > 2 {
+		t.Errorf("ParseBackward(%q) keeps %d memo entries, want at most 2", input, len(result.memo))
+	}
+	// The cut inside of Items does not commit the choice of List, which
+	// can still backtrack to the start, so the memo entries are kept.
+	g, err = New(`List <- Items 'x' / Items 'y'
+Items <- ( Item ~ )*
+Item <- < [a-z] > ','`, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input = "a,b,y"
+	result, err = g.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) returns error %s, want success", input, err)
+	}
+	if result.memo[2] == nil {
+		t.Errorf("Parse(%q) discards the memo entries at 2, want them kept", input)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	for _, test := range tests.Repeat {
+		testParserTree(t, test)
+	}
+}
+
+func TestBackwardRepeat(t *testing.T) {
+	g, err := New(`A <- Item{1,2} Tail
+Item <- < [a-z] >
+Tail <- < [a-z]{2} >`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	for _, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"abc", `(A (Item "a") (Tail "bc"))`},
+		{"abcd", `(A (Item "a") (Item "b") (Tail "cd"))`},
+		{"ab", ""},
+		{"abcde", ""},
+	} {
+		result, err := g.ParseBackward(tt.input)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseBackward(%q) returns success with tree %s, want error", tt.input, result.Tree)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBackward(%q) returns error %s, want success", tt.input, err)
+			continue
+		}
+		want, err := tree.Parse(tt.want)
+		if err != nil {
+			t.Fatalf("error in test, invalid wanted tree %s: %s", tt.want, err)
+		}
+		if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+			t.Errorf("ParseBackward(%q) returns tree %s, want %s\ndiffs:\n%s",
+				tt.input, result.Tree, want, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestCharClass(t *testing.T) {
+	for _, test := range tests.CharClass {
+		testParserTree(t, test)
+	}
+}
+
+func TestBackwardCharClass(t *testing.T) {
+	g, err := New(`Word <- < [[:alpha:]--[aeiou]]+ >`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	for _, input := range []string{"xyz", "ямб"} {
+		result, err := g.ParseBackward(input)
+		if err != nil {
+			t.Errorf("ParseBackward(%q) returns error %s, want success", input, err)
+			continue
+		}
+		if result.Tree.Text != input {
+			t.Errorf("ParseBackward(%q) returns tree %s, want text %q", input, result.Tree, input)
+		}
+	}
+	if result, err := g.ParseBackward("xa"); err == nil {
+		t.Errorf("ParseBackward(%q) returns success with tree %s, want error", "xa", result.Tree)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	for _, test := range tests.Recover {
+		testParserTree(t, test)
+	}
+}
+
+func TestRecoverErrors(t *testing.T) {
+	g, err := New(`Stmts <- Stmt*
+Stmt <- Ident _ '=' _ Num ';'^semi _
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >
+semi <- ( ![\n] . )*
+_ <- [ \n]*`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := "a=1 x\nb=2;\nc=3\n"
+	result, err := g.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) returns error %s, want success", input, err)
+	}
+	want := []string{
+		"1:3: semi: expected \";\" or [0-9], got \" x\\nb=2;\\nc=3\\n\"\na=1 x\n   ^",
+		"3:3: semi: expected \";\" or [0-9], got \"\\n\"\nc=3\n   ^",
+	}
+	var got []string
+	for _, e := range result.Errors {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(%q) returns errors %q, want %q", input, got, want)
+	}
+}
+
+func TestBackwardRecover(t *testing.T) {
+	g, err := New(`List <- '[' Item ( ',' Item )* ']'^close
+Item <- < [a-z]+ >
+close <- ';'`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := "[a,b;"
+	result, err := g.ParseBackward(input)
+	if err != nil {
+		t.Fatalf("ParseBackward(%q) returns error %s, want success", input, err)
+	}
+	want, err := tree.Parse(`(List (Item "a") (Item "b") (Error ";" :label("close")))`)
+	if err != nil {
+		t.Fatalf("error in test, invalid wanted tree: %s", err)
+	}
+	if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+		t.Errorf("ParseBackward(%q) returns tree %s, want %s\ndiffs:\n%s",
+			input, result.Tree, want, strings.Join(diffs, "\n"))
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Label != "close" {
+		t.Errorf("ParseBackward(%q) returns errors %v, want one error with label close", input, result.Errors)
+	}
+}
+
+// typeNames is the grammar of a C-like language where the statement
+// "x y;" declares y of type x, and "y;" uses y, so the parse depends on
+// whether x is a type name.
+const typeNames = `Stmts <- Stmt*
+Stmt <- ( Decl / Use ) ";"
+Decl <- TypeName " " Var
+Use <- Var
+TypeName <- Ident &{isTypeName}
+Var <- Ident !{ isTypeName }
+Ident <- < [a-z_]+ >`
+
+func isTypeName(r *Result, pos int, node *parser.Node) bool {
+	last := len(node.Children) - 1
+	return last >= 0 && (node.Children[last].Text == "size_t" || node.Children[last].Text == "file")
+}
+
+func TestSemanticPredicates(t *testing.T) {
+	options := &ParserOptions{
+		SkipEmptyNodes: true,
+		Predicates: map[string]func(r *Result, pos int, node *parser.Node) bool{
+			"isTypeName": isTypeName,
+		},
+	}
+	g, err := New(typeNames, options)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"size_t n;x;", `(Stmts
+			(Stmt (Decl (TypeName (Ident "size_t")) (Var (Ident "n"))))
+			(Stmt (Use (Var (Ident "x")))))`, ""},
+		{"x y;", "", `expected ";" or &{isTypeName} or [_a-z]`},
+		{"file;", "", `expected !{isTypeName}`},
+		{"file size_t;", "", `expected !{isTypeName}`},
+	}
+	for _, tt := range tests {
+		result, err := g.Parse(tt.input)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) returns error %v, want error containing %q", tt.input, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) returns error %s, want success", tt.input, err)
+			continue
+		}
+		want, err := tree.Parse(tt.want)
+		if err != nil {
+			t.Fatalf("error in test, invalid wanted tree %s: %s", tt.want, err)
+		}
+		if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+			t.Errorf("Parse(%q) returns tree %s, want %s\ndiffs:\n%s",
+				tt.input, result.Tree, want, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestUndefinedPredicate(t *testing.T) {
+	_, err := New(typeNames, &ParserOptions{
+		Predicates: map[string]func(r *Result, pos int, node *parser.Node) bool{
+			"isType": isTypeName,
+		},
+	})
+	if want := "undefined predicate: isTypeName"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	for _, test := range tests.Indent {
+		testParserTree(t, test)
+	}
+}
+
+func TestIndentReserved(t *testing.T) {
+	want := "INDENT is a reserved name and cannot be defined as a rule"
+	if _, err := New("A <- INDENT\nINDENT <- ' '", nil); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestBackwardIndent(t *testing.T) {
+	g, err := New(tests.Indent[0].Grammar, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	want := "INDENT is not supported by the backward parser"
+	if _, err := g.ParseBackward("a\n"); err == nil || err.Error() != want {
+		t.Errorf("ParseBackward returns error %v, want %q", err, want)
+	}
+}
+
+func TestBackRef(t *testing.T) {
+	for _, test := range tests.BackRef {
+		testParserTree(t, test)
+	}
+}
+
+func TestBackwardBackRef(t *testing.T) {
+	g, err := New(tests.BackRef[0].Grammar, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	want := "back-reference $tag is not supported by the backward parser"
+	if _, err := g.ParseBackward("<<A\nA\nA"); err == nil || err.Error() != want {
+		t.Errorf("ParseBackward returns error %v, want %q", err, want)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	for _, test := range tests.Skip {
+		testParserTree(t, test)
+	}
+}
+
+func TestInsertSkip(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"A B C", "A _ B _ C"},
+		{"A / B C", "A / B _ C"},
+		{"A* B", "(A (_ A)*)? _ B"},
+		{"A+", "(A (_ A)*)"},
+		{"A? !B &(C D)", "A? _ !B _ &(C _ D)"},
+		{"<A B>^r", "<A _ B>^r"},
+		{"A{3}", "(A (_ A){2})"},
+		{"A{0,3}", "(A (_ A){0,2})?"},
+		{"A{2,}", "(A (_ A){1,})"},
+		{"A{1}", "A{1}"},
+	}
+	for _, tt := range tests {
+		g, err := ParseGrammar("X <- " + tt.source + "\nr <- .")
+		if err != nil {
+			t.Errorf("ParseGrammar(%q) returns error %s, want success", tt.source, err)
+			continue
+		}
+		if got := insertSkip(g.Rules["X"].RHS, "_").ShortString(); got != tt.want {
+			t.Errorf("insertSkip(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	for _, test := range tests.Precedence {
+		testParserTree(t, test)
+	}
+}
+
+func TestPrecedenceErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"E <- %prec N %left \"+\" \"+\"\nN <- [0-9]", `operator "+" is defined more than once`},
+		{"E <- %prec N %left \"-\" %postfix \"-\"\nN <- [0-9]", `operator "-" is defined more than once`},
+		{"E <- %prec N %left \"\"\nN <- [0-9]", "empty operator in precedence table"},
+		{"E <- %prec N %left \"+\"", "unknown rule: N"},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.source, nil); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%q) returns error %v, want error containing %q", tt.source, err, tt.want)
+		}
+	}
+}
+
+func TestBackwardPrecedence(t *testing.T) {
+	g, err := New(tests.Precedence[0].Grammar, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	want := "precedence table of rule Operand is not supported by the backward parser"
+	if _, err := g.ParseBackward("1+2"); err == nil || err.Error() != want {
+		t.Errorf("ParseBackward returns error %v, want %q", err, want)
+	}
+}
+
+func TestByteMode(t *testing.T) {
+	for _, test := range tests.ByteMode {
+		testParserTreeOptions(t, test, &ParserOptions{SkipEmptyNodes: true, ByteMode: true})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	g, err := New(`Data <- < [\x00-\xff]{4} >`, &ParserOptions{ByteMode: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := []byte{0xde, 0xad, 0xbe, 0xef}
+	result, err := g.ParseBytes(input)
+	if err != nil {
+		t.Fatalf("ParseBytes(%q) returns error %s, want success", input, err)
+	}
+	if result.Tree.Text != string(input) || result.Tree.Len != len(input) {
+		t.Errorf("ParseBytes(%q) returns tree %s of length %d, want text %q", input, result.Tree, result.Tree.Len, input)
+	}
+	if result, err := g.ParseBytes(input[:3]); err == nil {
+		t.Errorf("ParseBytes(%q) returns success with tree %s, want error", input[:3], result.Tree)
+	}
+}
+
+func TestBackwardByteMode(t *testing.T) {
+	g, err := New(`Tail <- < [\x80-\xff]+ >`, &ParserOptions{SkipEmptyNodes: true, ByteMode: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := "\xff\xc3\xa9"
+	result, err := g.ParseBackward(input)
+	if err != nil {
+		t.Fatalf("ParseBackward(%q) returns error %s, want success", input, err)
+	}
+	if result.Tree.Text != input {
+		t.Errorf("ParseBackward(%q) returns tree %s, want text %q", input, result.Tree, input)
+	}
+}
+
+func TestLiteralErrorLocation(t *testing.T) {
+	want := `2:7: invalid escape sequence \q in literal "x\qy"`
+	if _, err := New("A <- 'a' B\nB <- \"x\\qy\"", nil); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("New returns error %v, want error containing %q", err, want)
+	}
+}
+
+func TestBackwardLabels(t *testing.T) {
+	g, err := New(`Pair <- key:Word '=' <value: [0-9]+ >
+Word <- < [a-z] ( ',' [a-z] )* >`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	input := "a,b=12"
+	result, err := g.ParseBackward(input)
+	if err != nil {
+		t.Fatalf("ParseBackward(%q) returns error %s, want success", input, err)
+	}
+	want, err := tree.Parse(`(Pair :value("12") :key(Word "a,b"))`)
+	if err != nil {
+		t.Fatalf("error in test, invalid wanted tree: %s", err)
+	}
+	if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+		t.Errorf("ParseBackward(%q) returns tree %s, want %s\ndiffs:\n%s",
+			input, result.Tree, want, strings.Join(diffs, "\n"))
+	}
+	if key := result.Tree.TreeAnnotations["key"]; key.Pos != 0 || key.Len != 3 {
+		t.Errorf("ParseBackward(%q) returns key at pos(%d,%d), want pos(0,3)", input, key.Pos, key.Len)
+	}
+}
+
+func TestBackwardIgnoreCase(t *testing.T) {
+	g, err := New(`A <- "ok"i [s]i`, nil)
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	for _, input := range []string{"oks", "OKS", "oKs", "OKſ"} {
+		if _, err := g.ParseBackward(input); err != nil {
+			t.Errorf("ParseBackward(%q) returns error %s, want success", input, err)
+		}
+	}
+	for _, input := range []string{"ok", "oxs", "xoks"} {
+		if _, err := g.ParseBackward(input); err == nil {
+			t.Errorf("ParseBackward(%q) returns success, want error", input)
+		}
+	}
+}
+
+func TestBackwardRightRecursion(t *testing.T) {
+	// Right recursion becomes left recursion when parsing backward.
+	g, err := New(`List <- Item ',' List / Item
+Item <- < [a-z] >`, &ParserOptions{SkipEmptyNodes: true})
+	if err != nil {
+		t.Fatalf("New returns error %s, want success", err)
+	}
+	for _, tt := range []struct {
+		input string
+		want  string
+	}{
+		{"a", `(List (Item "a"))`},
+		{"a,b", `(List (Item "a") (List (Item "b")))`},
+		{"a,b,c", `(List (Item "a") (List (Item "b") (List (Item "c"))))`},
+		{"a,", ""},
+	} {
+		result, err := g.ParseBackward(tt.input)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseBackward(%q) returns success, want error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBackward(%q) returns error %s, want success", tt.input, err)
+			continue
+		}
+		want, err := tree.Parse(tt.want)
+		if err != nil {
+			t.Errorf("error in test, invalid wanted tree %s: %s", tt.want, err)
+			continue
+		}
+		if diffs := tree.Diff(result.Tree, want); len(diffs) > 0 {
+			t.Errorf("ParseBackward(%q) returns tree %s, want %s\ndiffs:\n%s",
+				tt.input, result.Tree, want, strings.Join(diffs, "\n"))
+		}
+	}
+}
@@ -15,21 +15,44 @@
 package // DO NOT EDIT. AUTOGENERATED
 // Source grammar:
 /*
-Grammar <- Rule+ _
+Grammar <- Import* Skip? Rule+ _
 
-Rule <- _ Ident _ '<' '-' RHS EndOfLine?
-RHS <- Terms ( _ '/' Terms ) *
+Import <- _ 'import' [ \t]+ Literal EndOfLine?
+Skip <- _ '%skip' [ \t]+ Ident EndOfLine?
+
+Rule <- _ Override? Token? Marker? Ident Params? _ '<' '-' ( Prec / RHS ) EndOfLine?
+Params <- '(' _ Ident ( _ ',' _ Ident )* _ ')'
+Override <- < 'override' > [ \t]+ !'<'
+Token <- < '%token' > [ \t]+
+Marker <- < ( 'inline' / 'drop' / 'keep' ) > [ \t]+ !'<'
+Prec <- _ '%prec' [ \t]+ Ident PrecLevel+
+PrecLevel <- _ '%' < ( 'left' / 'right' / 'prefix' / 'postfix' ) > PrecOp+
+PrecOp <- [ \t]+ Label? Literal
+RHS <- Terms ( _ '/' _ Terms ) *
 Terms <- Term+
-Term <- Parens / NegPred / Pred / Capture / CharClass / Literal / Ident / Special
+Term <- Parens / SemPred / SemNegPred / NegPred / Pred / Capture / CharClass IgnoreCase? / Literal IgnoreCase? / Labeled / Call / Indent / BackRef / Ident / Cut / Repeat / Recover / Special
 Special <- _ < [*?.+] >
+Cut <- _ < '~' >
+Repeat <- _ '{' < [0-9]+ ( ',' [0-9]* )? > '}'
+Recover <- _ '^' Ident
 Parens <- _ '(' RHS _ ')'
+SemPred <- _ '&{' Ident [ \t]* '}'
+SemNegPred <- _ '!{' Ident [ \t]* '}'
 NegPred <- _ '!' Term
 Pred <- _ '&' Term
-Capture <- _ '<' RHS _ '>'
+Capture <- _ '<' Label? RHS _ '>'
+Call <- Ident '(' Arg ( _ ',' Arg )* _ ')'
+Arg <- _ ( Call / Ident )
+Labeled <- Label ( Call / Ident )
+Label <- [ \t]* < [a-zA-Z_][a-zA-Z0-9_]* > ':'
 
-Literal <- _ < '"' ( !'"' . ) * '"' > / _ < "'" ( !"'" . )* "'" >
+Literal <- _ < '"' ( [\\] . / !'"' . )* '"' > / _ < "'" ( !"'" . )* "'" > / _ < '`' ( !'`' . )* '`' >
+Indent <- [ \t]* < ( 'INDENT' / 'DEDENT' / 'SAMEDENT' ) > ![a-zA-Z0-9_]
+BackRef <- _ '$' < [a-zA-Z_][a-zA-Z0-9_]* >
 Ident <- [ \t]* < [a-zA-Z_][a-zA-Z0-9_]* >
-CharClass <- _ '[' < ('[:' [a-z]+ ':]' / ( !']' . )* ) > ']'
+CharClass <- _ '[' < ClassItem* > ']'
+ClassItem <- '[' ClassItem* ']' / [\\] . / !']' .
+IgnoreCase <- < 'i' > ![a-zA-Z0-9_]
 
 EndOfLine <- [ \t]* ( "\r\n" / "\r" / "\n")
 _ <- ( [ \t\r\n] / '#' ( !"\n" .)* "\n"? )*
@@ -54,6 +77,17 @@ result struct {
 	Level  int
 	Tree   *parser.Node
 	NodeStack
+	// cut is set when a cut is passed in the current alternative of the
+	// innermost choice, and predicates is the number of predicates being
+	// evaluated.
+	cut        bool
+	predicates int
+	// points is the stack of the positions where the parser can resume
+	// after a failure, and choice is the number of points up to the point
+	// of the innermost choice, or 0 if there is no choice in the current
+	// rule or predicate.
+	points []int
+	choice int
 }
 
 func (s *NodeStack) Push(n *parser.Node) {
@@ -90,6 +124,48 @@ func (r *result) Attach(n *parser.Node) {
 	}
 	r.NodeStack[last].Children = append(r.NodeStack[last].Children, n)
 }
+
+// state is the part of the top node that is modified by the handlers and
+// needs to be restored when a handler fails.
+type state struct {
+	children        []*parser.Node
+	annotations     map[string]string
+	treeAnnotations map[string]*parser.Node
+	cut             bool
+}
+
+func (r *result) saveState() state {
+	n := r.TopNode()
+	return state{n.Children, n.Annotations, n.TreeAnnotations, r.cut}
+}
+
+func (r *result) restoreState(s state) {
+	n := r.TopNode()
+	n.Children = s.children
+	n.Annotations = s.annotations
+	n.TreeAnnotations = s.treeAnnotations
+	r.cut = s.cut
+}
+
+func (r *result) enter(pos int, choice bool) cutScope {
+	s := cutScope{r.cut, r.choice, len(r.points)}
+	r.cut = false
+	r.choice = 0
+	if choice {
+		r.points = append(r.points, pos)
+		r.choice = len(r.points)
+	}
+	return s
+}
+
+func (r *result) leave(s cutScope) {
+	r.cut, r.choice, r.points = s.cut, s.choice, r.points[:s.points]
+}
+
+func (r *result) push(pos int) int {
+	r.points = append(r.points, pos)
+	return len(r.points) - 1
+}
 func CaptureStartHandler(r *result, pos int) (int, error) {
 	if r.TopNode() == nil {
 		return 0, fmt.Errorf("internal error, cannot start capture without a node")
@@ -125,7 +201,9 @@ func apply(r *result, pos int, h func(r *result, pos int) (int, error), hi int)
 	}
 	n = &parser.Node{Label: labels[hi]}
 	r.NodeStack.Push(n)
+	scope := r.enter(pos, false)
 	w, err := h(r, pos)
+	r.leave(scope)
 	if err != nil {
 		n := r.NodeStack.Pop()
 		n.Len = w
@@ -140,144 +218,104 @@ func apply(r *result, pos int, h func(r *result, pos int) (int, error), hi int)
 	r.Attach(n)
 	return w, nil
 }
-func Term_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, ParensHandler, 6)
+func Grammar_1_1_star(r *result, pos int) (int, error) {
+	return apply(r, pos, ImportHandler, 1)
 }
-func Term_1(r *result, pos int) (int, error) {
+func Grammar_1_1(r *result, pos int) (int, error) {
 	ww := 0
-	var w int
-	var err error
-	w, err = Term_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Grammar_1_1_star(r, pos); err == nil && w > 0; w, err = Grammar_1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Term_2_1(r *result, pos int) (int, error) {
-	return apply(r, pos, NegPredHandler, 7)
+func Grammar_1_2_question(r *result, pos int) (int, error) {
+	return apply(r, pos, SkipHandler, 2)
 }
-func Term_2(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_2_1(r, pos+ww)
-	ww += w
+func Grammar_1_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Grammar_1_2_question(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
-		return ww, err
+		r.restoreState(save)
+		return 0, nil
 	}
-	return ww, nil
+	return w, nil
 }
-func Term_3_1(r *result, pos int) (int, error) {
-	return apply(r, pos, PredHandler, 8)
+func Grammar_1_3_plus(r *result, pos int) (int, error) {
+	return apply(r, pos, RuleHandler, 3)
 }
-func Term_3(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_3_1(r, pos+ww)
-	ww += w
+func Grammar_1_3(r *result, pos int) (int, error) {
+	w, err := Grammar_1_3_plus(r, pos)
 	if err != nil {
-		return ww, err
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Grammar_1_3_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Grammar_1_3_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Term_4_1(r *result, pos int) (int, error) {
-	return apply(r, pos, CaptureHandler, 9)
+func Grammar_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func Term_4(r *result, pos int) (int, error) {
+func Grammar_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Term_4_1(r, pos+ww)
+	w, err = Grammar_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func Term_5_1(r *result, pos int) (int, error) {
-	return apply(r, pos, CharClassHandler, 12)
-}
-func Term_5(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_5_1(r, pos+ww)
+	w, err = Grammar_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func Term_6_1(r *result, pos int) (int, error) {
-	return apply(r, pos, LiteralHandler, 10)
-}
-func Term_6(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_6_1(r, pos+ww)
+	w, err = Grammar_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func Term_7_1(r *result, pos int) (int, error) {
-	return apply(r, pos, IdentHandler, 11)
-}
-func Term_7(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_7_1(r, pos+ww)
+	w, err = Grammar_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Term_8_1(r *result, pos int) (int, error) {
-	return apply(r, pos, SpecialHandler, 5)
+func GrammarHandler(r *result, pos int) (int, error) {
+	w, err := Grammar_1(r, pos)
+	return w, err
 }
-func Term_8(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Term_8_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	return ww, nil
+func Import_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func TermHandler(r *result, pos int) (int, error) {
-	w, err := Term_1(r, pos)
-	if err != nil {
-		w, err = Term_2(r, pos)
-	}
-	if err != nil {
-		w, err = Term_3(r, pos)
-	}
-	if err != nil {
-		w, err = Term_4(r, pos)
-	}
-	if err != nil {
-		w, err = Term_5(r, pos)
-	}
-	if err != nil {
-		w, err = Term_6(r, pos)
-	}
-	if err != nil {
-		w, err = Term_7(r, pos)
+func Import_1_2(r *result, pos int) (int, error) {
+	const literal = "import"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
-	if err != nil {
-		w, err = Term_8(r, pos)
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
 	}
-	return w, err
+	return len(literal), nil
 }
-func Ident_1_1_star(r *result, pos int) (int, error) {
+func Import_1_3_plus(r *result, pos int) (int, error) {
 	var charClassMap = map[rune]bool{' ': true, '\t': true}
 	c, w := utf8.DecodeRuneInString(r.Source[pos:])
 	if w == 0 {
@@ -287,161 +325,84 @@ func Ident_1_1_star(r *result, pos int) (int, error) {
 		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
 	}
 	if !charClassMap[c] {
-		return 0, fmt.Errorf("character %q does not match class [\t ]", c)
-	}
-	return w, nil
-}
-func Ident_1_1(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := Ident_1_1_star(r, pos); err == nil && w > 0; w, err = Ident_1_1_star(r, pos+ww) {
-		ww += w
-	}
-	return ww, nil
-}
-func Ident_1_2_capture_1_1(r *result, pos int) (int, error) {
-	var charClassMap = map[rune]bool{'_': true}
-	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if w == 0 {
-		return 0, fmt.Errorf("expecting char, got EOF")
-	}
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
-	}
-	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
-		return 0, fmt.Errorf("character %q does not match class [_A-Za-z]", c)
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
 	}
 	return w, nil
 }
-func Ident_1_2_capture_1_2_star(r *result, pos int) (int, error) {
-	var charClassMap = map[rune]bool{'_': true}
-	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if w == 0 {
-		return 0, fmt.Errorf("expecting char, got EOF")
-	}
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
-	}
-	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
-		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+func Import_1_3(r *result, pos int) (int, error) {
+	w, err := Import_1_3_plus(r, pos)
+	if err != nil {
+		return 0, err
 	}
-	return w, nil
-}
-func Ident_1_2_capture_1_2(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := Ident_1_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Ident_1_2_capture_1_2_star(r, pos+ww) {
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Import_1_3_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Import_1_3_plus(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Ident_1_2_capture_1(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Ident_1_2_capture_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = Ident_1_2_capture_1_2(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	return ww, nil
+func Import_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, LiteralHandler, 28)
 }
-func Ident_1_2_capture(r *result, pos int) (int, error) {
-	w, err := Ident_1_2_capture_1(r, pos)
-	return w, err
+func Import_1_5_question(r *result, pos int) (int, error) {
+	return apply(r, pos, EndOfLineHandler, 35)
 }
-func Ident_1_2(r *result, pos int) (int, error) {
-	w, err := Ident_1_2_capture(r, pos)
+func Import_1_5(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Import_1_5_question(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
-		return w, err
+		r.restoreState(save)
+		return 0, nil
 	}
-	r.TopNode().Start = pos
-	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func Ident_1(r *result, pos int) (int, error) {
+func Import_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Ident_1_1(r, pos+ww)
+	w, err = Import_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Ident_1_2(r, pos+ww)
+	w, err = Import_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func IdentHandler(r *result, pos int) (int, error) {
-	w, err := Ident_1(r, pos)
-	return w, err
-}
-func Pred_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Pred_1_2(r *result, pos int) (int, error) {
-	const literal = "&"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
-	}
-	return len(literal), nil
-}
-func Pred_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, TermHandler, 4)
-}
-func Pred_1(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = Pred_1_1(r, pos+ww)
+	w, err = Import_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Pred_1_2(r, pos+ww)
+	w, err = Import_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Pred_1_3(r, pos+ww)
+	w, err = Import_1_5(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func PredHandler(r *result, pos int) (int, error) {
-	w, err := Pred_1(r, pos)
+func ImportHandler(r *result, pos int) (int, error) {
+	w, err := Import_1(r, pos)
 	return w, err
 }
-func CharClass_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func CharClass_1_2(r *result, pos int) (int, error) {
-	const literal = "["
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
-	}
-	return len(literal), nil
+func Skip_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func CharClass_1_3_capture_1_1_paren_1_1(r *result, pos int) (int, error) {
-	const literal = "[:"
+func Skip_1_2(r *result, pos int) (int, error) {
+	const literal = "%skip"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -451,8 +412,8 @@ func CharClass_1_3_capture_1_1_paren_1_1(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func CharClass_1_3_capture_1_1_paren_1_2_plus(r *result, pos int) (int, error) {
-	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+func Skip_1_3_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
 	c, w := utf8.DecodeRuneInString(r.Source[pos:])
 	if w == 0 {
 		return 0, fmt.Errorf("expecting char, got EOF")
@@ -460,223 +421,287 @@ func CharClass_1_3_capture_1_1_paren_1_2_plus(r *result, pos int) (int, error) {
 	if c == utf8.RuneError {
 		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
 	}
-	if !unicode.Is(rangeTable, c) {
-		return 0, fmt.Errorf("character %q does not match class [a-z]", c)
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
 	}
 	return w, nil
 }
-func CharClass_1_3_capture_1_1_paren_1_2(r *result, pos int) (int, error) {
-	w, err := CharClass_1_3_capture_1_1_paren_1_2_plus(r, pos)
+func Skip_1_3(r *result, pos int) (int, error) {
+	w, err := Skip_1_3_plus(r, pos)
 	if err != nil {
 		return 0, err
 	}
 	ww := w
-	for w, err = CharClass_1_3_capture_1_1_paren_1_2_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = CharClass_1_3_capture_1_1_paren_1_2_plus(r, pos+ww) {
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Skip_1_3_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Skip_1_3_plus(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func CharClass_1_3_capture_1_1_paren_1_3(r *result, pos int) (int, error) {
-	const literal = ":]"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+func Skip_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Skip_1_5_question(r *result, pos int) (int, error) {
+	return apply(r, pos, EndOfLineHandler, 35)
+}
+func Skip_1_5(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Skip_1_5_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
 	}
-	return len(literal), nil
+	return w, nil
 }
-func CharClass_1_3_capture_1_1_paren_1(r *result, pos int) (int, error) {
+func Skip_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = CharClass_1_3_capture_1_1_paren_1_1(r, pos+ww)
+	w, err = Skip_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = CharClass_1_3_capture_1_1_paren_1_2(r, pos+ww)
+	w, err = Skip_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = CharClass_1_3_capture_1_1_paren_1_3(r, pos+ww)
+	w, err = Skip_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_1_neg(r *result, pos int) (int, error) {
-	const literal = "]"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	w, err = Skip_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	return len(literal), nil
+	w, err = Skip_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
 }
-func CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_1(r *result, pos int) (int, error) {
-	const negative = true
-	_, err := CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_1_neg(r, pos)
-	if negative == (err != nil) {
+func SkipHandler(r *result, pos int) (int, error) {
+	w, err := Skip_1(r, pos)
+	return w, err
+}
+func Rule_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Rule_1_2_question(r *result, pos int) (int, error) {
+	return apply(r, pos, OverrideHandler, 5)
+}
+func Rule_1_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Rule_1_2_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
 		return 0, nil
 	}
-	if err == nil {
-		return 0, fmt.Errorf("negative predicate matched")
-	}
-	return 0, err
+	return w, nil
 }
-func CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_2(r *result, pos int) (int, error) {
-	if pos == len(r.Source) {
-		return 0, fmt.Errorf("expected character, got EOF")
-	}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+func Rule_1_3_question(r *result, pos int) (int, error) {
+	return apply(r, pos, TokenHandler, 6)
+}
+func Rule_1_3(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Rule_1_3_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
 	}
 	return w, nil
 }
-func CharClass_1_3_capture_1_1_paren_2_1_star_paren_1(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_1(r, pos+ww)
-	ww += w
+func Rule_1_4_question(r *result, pos int) (int, error) {
+	return apply(r, pos, MarkerHandler, 7)
+}
+func Rule_1_4(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Rule_1_4_question(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
-		return ww, err
+		r.restoreState(save)
+		return 0, nil
 	}
-	w, err = CharClass_1_3_capture_1_1_paren_2_1_star_paren_1_2(r, pos+ww)
-	ww += w
+	return w, nil
+}
+func Rule_1_5(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Rule_1_6_question(r *result, pos int) (int, error) {
+	return apply(r, pos, ParamsHandler, 4)
+}
+func Rule_1_6(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Rule_1_6_question(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
-		return ww, err
+		r.restoreState(save)
+		return 0, nil
 	}
-	return ww, nil
+	return w, nil
 }
-func CharClass_1_3_capture_1_1_paren_2_1_star(r *result, pos int) (int, error) {
-	w, err := CharClass_1_3_capture_1_1_paren_2_1_star_paren_1(r, pos)
-	return w, err
+func Rule_1_7(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func CharClass_1_3_capture_1_1_paren_2_1(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := CharClass_1_3_capture_1_1_paren_2_1_star(r, pos); err == nil && w > 0; w, err = CharClass_1_3_capture_1_1_paren_2_1_star(r, pos+ww) {
-		ww += w
+func Rule_1_8(r *result, pos int) (int, error) {
+	const literal = "<"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
-	return ww, nil
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Rule_1_9(r *result, pos int) (int, error) {
+	const literal = "-"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
 }
-func CharClass_1_3_capture_1_1_paren_2(r *result, pos int) (int, error) {
+func Rule_1_10_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, PrecHandler, 8)
+}
+func Rule_1_10_paren_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = CharClass_1_3_capture_1_1_paren_2_1(r, pos+ww)
+	w, err = Rule_1_10_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func CharClass_1_3_capture_1_1(r *result, pos int) (int, error) {
-	w, err := CharClass_1_3_capture_1_1_paren_1(r, pos)
-	if err != nil {
-		w, err = CharClass_1_3_capture_1_1_paren_2(r, pos)
-	}
-	return w, err
+func Rule_1_10_paren_2_1(r *result, pos int) (int, error) {
+	return apply(r, pos, RHSHandler, 11)
 }
-func CharClass_1_3_capture_1(r *result, pos int) (int, error) {
+func Rule_1_10_paren_2(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = CharClass_1_3_capture_1_1(r, pos+ww)
+	w, err = Rule_1_10_paren_2_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func CharClass_1_3_capture(r *result, pos int) (int, error) {
-	w, err := CharClass_1_3_capture_1(r, pos)
+func Rule_1_10(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Rule_1_10_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Rule_1_10_paren_2(r, pos)
+	}
+	r.leave(scope)
 	return w, err
 }
-func CharClass_1_3(r *result, pos int) (int, error) {
-	w, err := CharClass_1_3_capture(r, pos)
+func Rule_1_11_question(r *result, pos int) (int, error) {
+	return apply(r, pos, EndOfLineHandler, 35)
+}
+func Rule_1_11(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Rule_1_11_question(r, pos)
+	r.points = r.points[:k]
 	if err != nil {
-		return w, err
+		r.restoreState(save)
+		return 0, nil
 	}
-	r.TopNode().Start = pos
-	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func CharClass_1_4(r *result, pos int) (int, error) {
-	const literal = "]"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
-	}
-	return len(literal), nil
-}
-func CharClass_1(r *result, pos int) (int, error) {
+func Rule_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = CharClass_1_1(r, pos+ww)
+	w, err = Rule_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = CharClass_1_2(r, pos+ww)
+	w, err = Rule_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = CharClass_1_3(r, pos+ww)
+	w, err = Rule_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = CharClass_1_4(r, pos+ww)
+	w, err = Rule_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func CharClassHandler(r *result, pos int) (int, error) {
-	w, err := CharClass_1(r, pos)
-	return w, err
-}
-func __1_1_star_paren_1_1(r *result, pos int) (int, error) {
-	var charClassMap = map[rune]bool{' ': true, '\t': true, '\r': true, '\n': true}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if w == 0 {
-		return 0, fmt.Errorf("expecting char, got EOF")
+	w, err = Rule_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	w, err = Rule_1_6(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	if !charClassMap[c] {
-		return 0, fmt.Errorf("character %q does not match class [\t\n\r ]", c)
+	w, err = Rule_1_7(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	return w, nil
-}
-func __1_1_star_paren_1(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = __1_1_star_paren_1_1(r, pos+ww)
+	w, err = Rule_1_8(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Rule_1_9(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Rule_1_10(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Rule_1_11(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func __1_1_star_paren_2_1(r *result, pos int) (int, error) {
-	const literal = "#"
+func RuleHandler(r *result, pos int) (int, error) {
+	w, err := Rule_1(r, pos)
+	return w, err
+}
+func Params_1_1(r *result, pos int) (int, error) {
+	const literal = "("
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -686,8 +711,17 @@ func __1_1_star_paren_2_1(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func __1_1_star_paren_2_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
-	const literal = "\n"
+func Params_1_2(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Params_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Params_1_4_star_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Params_1_4_star_paren_1_2(r *result, pos int) (int, error) {
+	const literal = ","
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -697,56 +731,60 @@ func __1_1_star_paren_2_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func __1_1_star_paren_2_2_star_paren_1_1(r *result, pos int) (int, error) {
-	const negative = true
-	_, err := __1_1_star_paren_2_2_star_paren_1_1_neg(r, pos)
-	if negative == (err != nil) {
-		return 0, nil
-	}
-	if err == nil {
-		return 0, fmt.Errorf("negative predicate matched")
-	}
-	return 0, err
+func Params_1_4_star_paren_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func __1_1_star_paren_2_2_star_paren_1_2(r *result, pos int) (int, error) {
-	if pos == len(r.Source) {
-		return 0, fmt.Errorf("expected character, got EOF")
-	}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
-	}
-	return w, nil
+func Params_1_4_star_paren_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
 }
-func __1_1_star_paren_2_2_star_paren_1(r *result, pos int) (int, error) {
+func Params_1_4_star_paren_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = __1_1_star_paren_2_2_star_paren_1_1(r, pos+ww)
+	w, err = Params_1_4_star_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = __1_1_star_paren_2_2_star_paren_1_2(r, pos+ww)
+	w, err = Params_1_4_star_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Params_1_4_star_paren_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Params_1_4_star_paren_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func __1_1_star_paren_2_2_star(r *result, pos int) (int, error) {
-	w, err := __1_1_star_paren_2_2_star_paren_1(r, pos)
+func Params_1_4_star(r *result, pos int) (int, error) {
+	w, err := Params_1_4_star_paren_1(r, pos)
 	return w, err
 }
-func __1_1_star_paren_2_2(r *result, pos int) (int, error) {
+func Params_1_4(r *result, pos int) (int, error) {
 	ww := 0
-	for w, err := __1_1_star_paren_2_2_star(r, pos); err == nil && w > 0; w, err = __1_1_star_paren_2_2_star(r, pos+ww) {
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Params_1_4_star(r, pos); err == nil && w > 0; w, err = Params_1_4_star(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func __1_1_star_paren_2_3_question(r *result, pos int) (int, error) {
-	const literal = "\n"
+func Params_1_5(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Params_1_6(r *result, pos int) (int, error) {
+	const literal = ")"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -756,126 +794,191 @@ func __1_1_star_paren_2_3_question(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func __1_1_star_paren_2_3(r *result, pos int) (int, error) {
-	w, err := __1_1_star_paren_2_3_question(r, pos)
-	if err != nil {
-		return 0, nil
-	}
-	return w, nil
-}
-func __1_1_star_paren_2(r *result, pos int) (int, error) {
+func Params_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = __1_1_star_paren_2_1(r, pos+ww)
+	w, err = Params_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = __1_1_star_paren_2_2(r, pos+ww)
+	w, err = Params_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = __1_1_star_paren_2_3(r, pos+ww)
+	w, err = Params_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	return ww, nil
-}
-func __1_1_star(r *result, pos int) (int, error) {
-	w, err := __1_1_star_paren_1(r, pos)
+	w, err = Params_1_4(r, pos+ww)
+	ww += w
 	if err != nil {
-		w, err = __1_1_star_paren_2(r, pos)
+		return ww, err
+	}
+	w, err = Params_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Params_1_6(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
+	return ww, nil
+}
+func ParamsHandler(r *result, pos int) (int, error) {
+	w, err := Params_1(r, pos)
 	return w, err
 }
-func __1_1(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := __1_1_star(r, pos); err == nil && w > 0; w, err = __1_1_star(r, pos+ww) {
-		ww += w
+func Override_1_1_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "override"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
-	return ww, nil
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
 }
-func __1(r *result, pos int) (int, error) {
+func Override_1_1_capture_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = __1_1(r, pos+ww)
+	w, err = Override_1_1_capture_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func _Handler(r *result, pos int) (int, error) {
-	w, err := __1(r, pos)
+func Override_1_1_capture(r *result, pos int) (int, error) {
+	w, err := Override_1_1_capture_1(r, pos)
 	return w, err
 }
-func Terms_1_1_plus(r *result, pos int) (int, error) {
-	return apply(r, pos, TermHandler, 4)
+func Override_1_1(r *result, pos int) (int, error) {
+	w, err := Override_1_1_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
 }
-func Terms_1_1(r *result, pos int) (int, error) {
-	w, err := Terms_1_1_plus(r, pos)
+func Override_1_2_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Override_1_2(r *result, pos int) (int, error) {
+	w, err := Override_1_2_plus(r, pos)
 	if err != nil {
 		return 0, err
 	}
 	ww := w
-	for w, err = Terms_1_1_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Terms_1_1_plus(r, pos+ww) {
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Override_1_2_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Override_1_2_plus(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Terms_1(r *result, pos int) (int, error) {
+func Override_1_3_neg(r *result, pos int) (int, error) {
+	const literal = "<"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Override_1_3(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Override_1_3_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Override_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Terms_1_1(r, pos+ww)
+	w, err = Override_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Override_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Override_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func TermsHandler(r *result, pos int) (int, error) {
-	w, err := Terms_1(r, pos)
+func OverrideHandler(r *result, pos int) (int, error) {
+	w, err := Override_1(r, pos)
 	return w, err
 }
-func Special_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Special_1_2_capture_1_1(r *result, pos int) (int, error) {
-	var charClassMap = map[rune]bool{'*': true, '?': true, '.': true, '+': true}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if w == 0 {
-		return 0, fmt.Errorf("expecting char, got EOF")
-	}
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+func Token_1_1_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "%token"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
-	if !charClassMap[c] {
-		return 0, fmt.Errorf("character %q does not match class [*+.?]", c)
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
 	}
-	return w, nil
+	return len(literal), nil
 }
-func Special_1_2_capture_1(r *result, pos int) (int, error) {
+func Token_1_1_capture_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Special_1_2_capture_1_1(r, pos+ww)
+	w, err = Token_1_1_capture_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Special_1_2_capture(r *result, pos int) (int, error) {
-	w, err := Special_1_2_capture_1(r, pos)
+func Token_1_1_capture(r *result, pos int) (int, error) {
+	w, err := Token_1_1_capture_1(r, pos)
 	return w, err
 }
-func Special_1_2(r *result, pos int) (int, error) {
-	w, err := Special_1_2_capture(r, pos)
+func Token_1_1(r *result, pos int) (int, error) {
+	w, err := Token_1_1_capture(r, pos)
 	if err != nil {
 		return w, err
 	}
@@ -883,31 +986,59 @@ func Special_1_2(r *result, pos int) (int, error) {
 	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func Special_1(r *result, pos int) (int, error) {
+func Token_1_2_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Token_1_2(r *result, pos int) (int, error) {
+	w, err := Token_1_2_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Token_1_2_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Token_1_2_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Token_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Special_1_1(r, pos+ww)
+	w, err = Token_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Special_1_2(r, pos+ww)
+	w, err = Token_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func SpecialHandler(r *result, pos int) (int, error) {
-	w, err := Special_1(r, pos)
+func TokenHandler(r *result, pos int) (int, error) {
+	w, err := Token_1(r, pos)
 	return w, err
 }
-func NegPred_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func NegPred_1_2(r *result, pos int) (int, error) {
-	const literal = "!"
+func Marker_1_1_capture_1_1_paren_1_1(r *result, pos int) (int, error) {
+	const literal = "inline"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -917,39 +1048,19 @@ func NegPred_1_2(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func NegPred_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, TermHandler, 4)
-}
-func NegPred_1(r *result, pos int) (int, error) {
+func Marker_1_1_capture_1_1_paren_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = NegPred_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = NegPred_1_2(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = NegPred_1_3(r, pos+ww)
+	w, err = Marker_1_1_capture_1_1_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func NegPredHandler(r *result, pos int) (int, error) {
-	w, err := NegPred_1(r, pos)
-	return w, err
-}
-func Capture_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Capture_1_2(r *result, pos int) (int, error) {
-	const literal = "<"
+func Marker_1_1_capture_1_1_paren_2_1(r *result, pos int) (int, error) {
+	const literal = "drop"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -959,14 +1070,19 @@ func Capture_1_2(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Capture_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, RHSHandler, 2)
-}
-func Capture_1_4(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
+func Marker_1_1_capture_1_1_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Marker_1_1_capture_1_1_paren_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
 }
-func Capture_1_5(r *result, pos int) (int, error) {
-	const literal = ">"
+func Marker_1_1_capture_1_1_paren_3_1(r *result, pos int) (int, error) {
+	const literal = "keep"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -976,89 +1092,143 @@ func Capture_1_5(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Capture_1(r *result, pos int) (int, error) {
+func Marker_1_1_capture_1_1_paren_3(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Capture_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = Capture_1_2(r, pos+ww)
+	w, err = Marker_1_1_capture_1_1_paren_3_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Capture_1_3(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+	return ww, nil
+}
+func Marker_1_1_capture_1_1(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Marker_1_1_capture_1_1_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Marker_1_1_capture_1_1_paren_2(r, pos)
 	}
-	w, err = Capture_1_4(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Marker_1_1_capture_1_1_paren_3(r, pos)
 	}
-	w, err = Capture_1_5(r, pos+ww)
+	r.leave(scope)
+	return w, err
+}
+func Marker_1_1_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Marker_1_1_capture_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func CaptureHandler(r *result, pos int) (int, error) {
-	w, err := Capture_1(r, pos)
+func Marker_1_1_capture(r *result, pos int) (int, error) {
+	w, err := Marker_1_1_capture_1(r, pos)
 	return w, err
 }
-func Grammar_1_1_plus(r *result, pos int) (int, error) {
-	return apply(r, pos, RuleHandler, 1)
+func Marker_1_1(r *result, pos int) (int, error) {
+	w, err := Marker_1_1_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
 }
-func Grammar_1_1(r *result, pos int) (int, error) {
-	w, err := Grammar_1_1_plus(r, pos)
+func Marker_1_2_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Marker_1_2(r *result, pos int) (int, error) {
+	w, err := Marker_1_2_plus(r, pos)
 	if err != nil {
 		return 0, err
 	}
 	ww := w
-	for w, err = Grammar_1_1_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Grammar_1_1_plus(r, pos+ww) {
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Marker_1_2_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Marker_1_2_plus(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Grammar_1_2(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
+func Marker_1_3_neg(r *result, pos int) (int, error) {
+	const literal = "<"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
 }
-func Grammar_1(r *result, pos int) (int, error) {
+func Marker_1_3(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Marker_1_3_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Marker_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Grammar_1_1(r, pos+ww)
+	w, err = Marker_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Grammar_1_2(r, pos+ww)
+	w, err = Marker_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Marker_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func GrammarHandler(r *result, pos int) (int, error) {
-	w, err := Grammar_1(r, pos)
+func MarkerHandler(r *result, pos int) (int, error) {
+	w, err := Marker_1(r, pos)
 	return w, err
 }
-func Rule_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Rule_1_2(r *result, pos int) (int, error) {
-	return apply(r, pos, IdentHandler, 11)
-}
-func Rule_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
+func Prec_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
 }
-func Rule_1_4(r *result, pos int) (int, error) {
-	const literal = "<"
+func Prec_1_2(r *result, pos int) (int, error) {
+	const literal = "%prec"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1068,80 +1238,155 @@ func Rule_1_4(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Rule_1_5(r *result, pos int) (int, error) {
-	const literal = "-"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+func Prec_1_3_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
 	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
 	}
-	return len(literal), nil
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
 }
-func Rule_1_6(r *result, pos int) (int, error) {
-	return apply(r, pos, RHSHandler, 2)
+func Prec_1_3(r *result, pos int) (int, error) {
+	w, err := Prec_1_3_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Prec_1_3_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Prec_1_3_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
 }
-func Rule_1_7_question(r *result, pos int) (int, error) {
-	return apply(r, pos, EndOfLineHandler, 13)
+func Prec_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
 }
-func Rule_1_7(r *result, pos int) (int, error) {
-	w, err := Rule_1_7_question(r, pos)
+func Prec_1_5_plus(r *result, pos int) (int, error) {
+	return apply(r, pos, PrecLevelHandler, 9)
+}
+func Prec_1_5(r *result, pos int) (int, error) {
+	w, err := Prec_1_5_plus(r, pos)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
-	return w, nil
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Prec_1_5_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Prec_1_5_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
 }
-func Rule_1(r *result, pos int) (int, error) {
+func Prec_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Rule_1_1(r, pos+ww)
+	w, err = Prec_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_2(r, pos+ww)
+	w, err = Prec_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_3(r, pos+ww)
+	w, err = Prec_1_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_4(r, pos+ww)
+	w, err = Prec_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_5(r, pos+ww)
+	w, err = Prec_1_5(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_6(r, pos+ww)
+	return ww, nil
+}
+func PrecHandler(r *result, pos int) (int, error) {
+	w, err := Prec_1(r, pos)
+	return w, err
+}
+func PrecLevel_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func PrecLevel_1_2(r *result, pos int) (int, error) {
+	const literal = "%"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func PrecLevel_1_3_capture_1_1_paren_1_1(r *result, pos int) (int, error) {
+	const literal = "left"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func PrecLevel_1_3_capture_1_1_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecLevel_1_3_capture_1_1_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Rule_1_7(r, pos+ww)
+	return ww, nil
+}
+func PrecLevel_1_3_capture_1_1_paren_2_1(r *result, pos int) (int, error) {
+	const literal = "right"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func PrecLevel_1_3_capture_1_1_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecLevel_1_3_capture_1_1_paren_2_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func RuleHandler(r *result, pos int) (int, error) {
-	w, err := Rule_1(r, pos)
-	return w, err
-}
-func Literal_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Literal_1_2_capture_1_1(r *result, pos int) (int, error) {
-	const literal = "\""
+func PrecLevel_1_3_capture_1_1_paren_3_1(r *result, pos int) (int, error) {
+	const literal = "prefix"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1151,8 +1396,19 @@ func Literal_1_2_capture_1_1(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Literal_1_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
-	const literal = "\""
+func PrecLevel_1_3_capture_1_1_paren_3(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecLevel_1_3_capture_1_1_paren_3_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func PrecLevel_1_3_capture_1_1_paren_4_1(r *result, pos int) (int, error) {
+	const literal = "postfix"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1162,56 +1418,191 @@ func Literal_1_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error)
 	}
 	return len(literal), nil
 }
-func Literal_1_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
-	const negative = true
-	_, err := Literal_1_2_capture_1_2_star_paren_1_1_neg(r, pos)
-	if negative == (err != nil) {
-		return 0, nil
+func PrecLevel_1_3_capture_1_1_paren_4(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecLevel_1_3_capture_1_1_paren_4_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	if err == nil {
-		return 0, fmt.Errorf("negative predicate matched")
+	return ww, nil
+}
+func PrecLevel_1_3_capture_1_1(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := PrecLevel_1_3_capture_1_1_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = PrecLevel_1_3_capture_1_1_paren_2(r, pos)
 	}
-	return 0, err
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = PrecLevel_1_3_capture_1_1_paren_3(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = PrecLevel_1_3_capture_1_1_paren_4(r, pos)
+	}
+	r.leave(scope)
+	return w, err
 }
-func Literal_1_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
-	if pos == len(r.Source) {
-		return 0, fmt.Errorf("expected character, got EOF")
+func PrecLevel_1_3_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecLevel_1_3_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
 	}
-	c, w := utf8.DecodeRuneInString(r.Source[pos:])
-	if c == utf8.RuneError {
-		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	return ww, nil
+}
+func PrecLevel_1_3_capture(r *result, pos int) (int, error) {
+	w, err := PrecLevel_1_3_capture_1(r, pos)
+	return w, err
+}
+func PrecLevel_1_3(r *result, pos int) (int, error) {
+	w, err := PrecLevel_1_3_capture(r, pos)
+	if err != nil {
+		return w, err
 	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func Literal_1_2_capture_1_2_star_paren_1(r *result, pos int) (int, error) {
+func PrecLevel_1_4_plus(r *result, pos int) (int, error) {
+	return apply(r, pos, PrecOpHandler, 10)
+}
+func PrecLevel_1_4(r *result, pos int) (int, error) {
+	w, err := PrecLevel_1_4_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = PrecLevel_1_4_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = PrecLevel_1_4_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func PrecLevel_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_1_2_capture_1_2_star_paren_1_1(r, pos+ww)
+	w, err = PrecLevel_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_1_2_capture_1_2_star_paren_1_2(r, pos+ww)
+	w, err = PrecLevel_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = PrecLevel_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = PrecLevel_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Literal_1_2_capture_1_2_star(r *result, pos int) (int, error) {
-	w, err := Literal_1_2_capture_1_2_star_paren_1(r, pos)
+func PrecLevelHandler(r *result, pos int) (int, error) {
+	w, err := PrecLevel_1(r, pos)
 	return w, err
 }
-func Literal_1_2_capture_1_2(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := Literal_1_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Literal_1_2_capture_1_2_star(r, pos+ww) {
+func PrecOp_1_1_plus(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func PrecOp_1_1(r *result, pos int) (int, error) {
+	w, err := PrecOp_1_1_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = PrecOp_1_1_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = PrecOp_1_1_plus(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
-func Literal_1_2_capture_1_3(r *result, pos int) (int, error) {
-	const literal = "\""
+func PrecOp_1_2_question(r *result, pos int) (int, error) {
+	return apply(r, pos, LabelHandler, 27)
+}
+func PrecOp_1_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := PrecOp_1_2_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
+	}
+	return w, nil
+}
+func PrecOp_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, LiteralHandler, 28)
+}
+func PrecOp_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = PrecOp_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = PrecOp_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = PrecOp_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func PrecOpHandler(r *result, pos int) (int, error) {
+	w, err := PrecOp_1(r, pos)
+	return w, err
+}
+func RHS_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, TermsHandler, 12)
+}
+func RHS_1_2_star_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func RHS_1_2_star_paren_1_2(r *result, pos int) (int, error) {
+	const literal = "/"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1221,72 +1612,2709 @@ func Literal_1_2_capture_1_3(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Literal_1_2_capture_1(r *result, pos int) (int, error) {
+func RHS_1_2_star_paren_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func RHS_1_2_star_paren_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, TermsHandler, 12)
+}
+func RHS_1_2_star_paren_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_1_2_capture_1_1(r, pos+ww)
+	w, err = RHS_1_2_star_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_1_2_capture_1_2(r, pos+ww)
+	w, err = RHS_1_2_star_paren_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_1_2_capture_1_3(r, pos+ww)
+	w, err = RHS_1_2_star_paren_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = RHS_1_2_star_paren_1_4(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Literal_1_2_capture(r *result, pos int) (int, error) {
-	w, err := Literal_1_2_capture_1(r, pos)
+func RHS_1_2_star(r *result, pos int) (int, error) {
+	w, err := RHS_1_2_star_paren_1(r, pos)
+	return w, err
+}
+func RHS_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := RHS_1_2_star(r, pos); err == nil && w > 0; w, err = RHS_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func RHS_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = RHS_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = RHS_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func RHSHandler(r *result, pos int) (int, error) {
+	w, err := RHS_1(r, pos)
+	return w, err
+}
+func Terms_1_1_plus(r *result, pos int) (int, error) {
+	return apply(r, pos, TermHandler, 13)
+}
+func Terms_1_1(r *result, pos int) (int, error) {
+	w, err := Terms_1_1_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Terms_1_1_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Terms_1_1_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Terms_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Terms_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func TermsHandler(r *result, pos int) (int, error) {
+	w, err := Terms_1(r, pos)
 	return w, err
 }
-func Literal_1_2(r *result, pos int) (int, error) {
-	w, err := Literal_1_2_capture(r, pos)
-	if err != nil {
-		return w, err
+func Term_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, ParensHandler, 18)
+}
+func Term_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_2_1(r *result, pos int) (int, error) {
+	return apply(r, pos, SemPredHandler, 19)
+}
+func Term_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_3_1(r *result, pos int) (int, error) {
+	return apply(r, pos, SemNegPredHandler, 20)
+}
+func Term_3(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_3_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_4_1(r *result, pos int) (int, error) {
+	return apply(r, pos, NegPredHandler, 21)
+}
+func Term_4(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_4_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_5_1(r *result, pos int) (int, error) {
+	return apply(r, pos, PredHandler, 22)
+}
+func Term_5(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_5_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_6_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CaptureHandler, 23)
+}
+func Term_6(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_6_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_7_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CharClassHandler, 32)
+}
+func Term_7_2_question(r *result, pos int) (int, error) {
+	return apply(r, pos, IgnoreCaseHandler, 34)
+}
+func Term_7_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Term_7_2_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
+	}
+	return w, nil
+}
+func Term_7(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_7_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Term_7_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_8_1(r *result, pos int) (int, error) {
+	return apply(r, pos, LiteralHandler, 28)
+}
+func Term_8_2_question(r *result, pos int) (int, error) {
+	return apply(r, pos, IgnoreCaseHandler, 34)
+}
+func Term_8_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Term_8_2_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
+	}
+	return w, nil
+}
+func Term_8(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_8_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Term_8_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_9_1(r *result, pos int) (int, error) {
+	return apply(r, pos, LabeledHandler, 26)
+}
+func Term_9(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_9_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_10_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CallHandler, 24)
+}
+func Term_10(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_10_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_11_1(r *result, pos int) (int, error) {
+	return apply(r, pos, IndentHandler, 29)
+}
+func Term_11(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_11_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_12_1(r *result, pos int) (int, error) {
+	return apply(r, pos, BackRefHandler, 30)
+}
+func Term_12(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_12_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_13_1(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Term_13(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_13_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_14_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CutHandler, 15)
+}
+func Term_14(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_14_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_15_1(r *result, pos int) (int, error) {
+	return apply(r, pos, RepeatHandler, 16)
+}
+func Term_15(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_15_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_16_1(r *result, pos int) (int, error) {
+	return apply(r, pos, RecoverHandler, 17)
+}
+func Term_16(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_16_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Term_17_1(r *result, pos int) (int, error) {
+	return apply(r, pos, SpecialHandler, 14)
+}
+func Term_17(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Term_17_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func TermHandler(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Term_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_2(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_3(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_4(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_5(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_6(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_7(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_8(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_9(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_10(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_11(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_12(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_13(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_14(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_15(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_16(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Term_17(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Special_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Special_1_2_capture_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'*': true, '?': true, '.': true, '+': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [*+.?]", c)
+	}
+	return w, nil
+}
+func Special_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Special_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Special_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Special_1_2_capture_1(r, pos)
+	return w, err
+}
+func Special_1_2(r *result, pos int) (int, error) {
+	w, err := Special_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Special_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Special_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Special_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func SpecialHandler(r *result, pos int) (int, error) {
+	w, err := Special_1(r, pos)
+	return w, err
+}
+func Cut_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Cut_1_2_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "~"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Cut_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Cut_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Cut_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Cut_1_2_capture_1(r, pos)
+	return w, err
+}
+func Cut_1_2(r *result, pos int) (int, error) {
+	w, err := Cut_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Cut_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Cut_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Cut_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func CutHandler(r *result, pos int) (int, error) {
+	w, err := Cut_1(r, pos)
+	return w, err
+}
+func Repeat_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Repeat_1_2(r *result, pos int) (int, error) {
+	const literal = "{"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Repeat_1_3_capture_1_1_plus(r *result, pos int) (int, error) {
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !unicode.Is(rangeTable, c) {
+		return 0, fmt.Errorf("character %q does not match class [0-9]", c)
+	}
+	return w, nil
+}
+func Repeat_1_3_capture_1_1(r *result, pos int) (int, error) {
+	w, err := Repeat_1_3_capture_1_1_plus(r, pos)
+	if err != nil {
+		return 0, err
+	}
+	ww := w
+	save := r.saveState()
+	k := r.push(pos + ww)
+	for w, err = Repeat_1_3_capture_1_1_plus(r, pos+ww); err == nil && w > 0 && pos+ww < len(r.Source); w, err = Repeat_1_3_capture_1_1_plus(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Repeat_1_3_capture_1_2_question_paren_1_1(r *result, pos int) (int, error) {
+	const literal = ","
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Repeat_1_3_capture_1_2_question_paren_1_2_star(r *result, pos int) (int, error) {
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !unicode.Is(rangeTable, c) {
+		return 0, fmt.Errorf("character %q does not match class [0-9]", c)
+	}
+	return w, nil
+}
+func Repeat_1_3_capture_1_2_question_paren_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Repeat_1_3_capture_1_2_question_paren_1_2_star(r, pos); err == nil && w > 0; w, err = Repeat_1_3_capture_1_2_question_paren_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Repeat_1_3_capture_1_2_question_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Repeat_1_3_capture_1_2_question_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Repeat_1_3_capture_1_2_question_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Repeat_1_3_capture_1_2_question(r *result, pos int) (int, error) {
+	w, err := Repeat_1_3_capture_1_2_question_paren_1(r, pos)
+	return w, err
+}
+func Repeat_1_3_capture_1_2(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Repeat_1_3_capture_1_2_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
+	}
+	return w, nil
+}
+func Repeat_1_3_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Repeat_1_3_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Repeat_1_3_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Repeat_1_3_capture(r *result, pos int) (int, error) {
+	w, err := Repeat_1_3_capture_1(r, pos)
+	return w, err
+}
+func Repeat_1_3(r *result, pos int) (int, error) {
+	w, err := Repeat_1_3_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Repeat_1_4(r *result, pos int) (int, error) {
+	const literal = "}"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Repeat_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Repeat_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Repeat_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Repeat_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Repeat_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func RepeatHandler(r *result, pos int) (int, error) {
+	w, err := Repeat_1(r, pos)
+	return w, err
+}
+func Recover_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Recover_1_2(r *result, pos int) (int, error) {
+	const literal = "^"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Recover_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Recover_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Recover_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Recover_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Recover_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func RecoverHandler(r *result, pos int) (int, error) {
+	w, err := Recover_1(r, pos)
+	return w, err
+}
+func Parens_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Parens_1_2(r *result, pos int) (int, error) {
+	const literal = "("
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Parens_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, RHSHandler, 11)
+}
+func Parens_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Parens_1_5(r *result, pos int) (int, error) {
+	const literal = ")"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Parens_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Parens_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Parens_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Parens_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Parens_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Parens_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func ParensHandler(r *result, pos int) (int, error) {
+	w, err := Parens_1(r, pos)
+	return w, err
+}
+func SemPred_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func SemPred_1_2(r *result, pos int) (int, error) {
+	const literal = "&{"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func SemPred_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func SemPred_1_4_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func SemPred_1_4(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := SemPred_1_4_star(r, pos); err == nil && w > 0; w, err = SemPred_1_4_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func SemPred_1_5(r *result, pos int) (int, error) {
+	const literal = "}"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func SemPred_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = SemPred_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemPred_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemPred_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemPred_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemPred_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func SemPredHandler(r *result, pos int) (int, error) {
+	w, err := SemPred_1(r, pos)
+	return w, err
+}
+func SemNegPred_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func SemNegPred_1_2(r *result, pos int) (int, error) {
+	const literal = "!{"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func SemNegPred_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func SemNegPred_1_4_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func SemNegPred_1_4(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := SemNegPred_1_4_star(r, pos); err == nil && w > 0; w, err = SemNegPred_1_4_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func SemNegPred_1_5(r *result, pos int) (int, error) {
+	const literal = "}"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func SemNegPred_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = SemNegPred_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemNegPred_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemNegPred_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemNegPred_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = SemNegPred_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func SemNegPredHandler(r *result, pos int) (int, error) {
+	w, err := SemNegPred_1(r, pos)
+	return w, err
+}
+func NegPred_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func NegPred_1_2(r *result, pos int) (int, error) {
+	const literal = "!"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func NegPred_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, TermHandler, 13)
+}
+func NegPred_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = NegPred_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = NegPred_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = NegPred_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func NegPredHandler(r *result, pos int) (int, error) {
+	w, err := NegPred_1(r, pos)
+	return w, err
+}
+func Pred_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Pred_1_2(r *result, pos int) (int, error) {
+	const literal = "&"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Pred_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, TermHandler, 13)
+}
+func Pred_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Pred_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Pred_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Pred_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func PredHandler(r *result, pos int) (int, error) {
+	w, err := Pred_1(r, pos)
+	return w, err
+}
+func Capture_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Capture_1_2(r *result, pos int) (int, error) {
+	const literal = "<"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Capture_1_3_question(r *result, pos int) (int, error) {
+	return apply(r, pos, LabelHandler, 27)
+}
+func Capture_1_3(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := Capture_1_3_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
+	}
+	return w, nil
+}
+func Capture_1_4(r *result, pos int) (int, error) {
+	return apply(r, pos, RHSHandler, 11)
+}
+func Capture_1_5(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Capture_1_6(r *result, pos int) (int, error) {
+	const literal = ">"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Capture_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Capture_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Capture_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Capture_1_6(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func CaptureHandler(r *result, pos int) (int, error) {
+	w, err := Capture_1(r, pos)
+	return w, err
+}
+func Call_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Call_1_2(r *result, pos int) (int, error) {
+	const literal = "("
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Call_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, ArgHandler, 25)
+}
+func Call_1_4_star_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Call_1_4_star_paren_1_2(r *result, pos int) (int, error) {
+	const literal = ","
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Call_1_4_star_paren_1_3(r *result, pos int) (int, error) {
+	return apply(r, pos, ArgHandler, 25)
+}
+func Call_1_4_star_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Call_1_4_star_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_4_star_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_4_star_paren_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Call_1_4_star(r *result, pos int) (int, error) {
+	w, err := Call_1_4_star_paren_1(r, pos)
+	return w, err
+}
+func Call_1_4(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Call_1_4_star(r, pos); err == nil && w > 0; w, err = Call_1_4_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Call_1_5(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Call_1_6(r *result, pos int) (int, error) {
+	const literal = ")"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Call_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Call_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_5(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Call_1_6(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func CallHandler(r *result, pos int) (int, error) {
+	w, err := Call_1(r, pos)
+	return w, err
+}
+func Arg_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Arg_1_2_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CallHandler, 24)
+}
+func Arg_1_2_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Arg_1_2_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Arg_1_2_paren_2_1(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Arg_1_2_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Arg_1_2_paren_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Arg_1_2(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Arg_1_2_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Arg_1_2_paren_2(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Arg_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Arg_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Arg_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func ArgHandler(r *result, pos int) (int, error) {
+	w, err := Arg_1(r, pos)
+	return w, err
+}
+func Labeled_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, LabelHandler, 27)
+}
+func Labeled_1_2_paren_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, CallHandler, 24)
+}
+func Labeled_1_2_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Labeled_1_2_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Labeled_1_2_paren_2_1(r *result, pos int) (int, error) {
+	return apply(r, pos, IdentHandler, 31)
+}
+func Labeled_1_2_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Labeled_1_2_paren_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Labeled_1_2(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Labeled_1_2_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Labeled_1_2_paren_2(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Labeled_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Labeled_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Labeled_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func LabeledHandler(r *result, pos int) (int, error) {
+	w, err := Labeled_1(r, pos)
+	return w, err
+}
+func Label_1_1_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Label_1_1(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Label_1_1_star(r, pos); err == nil && w > 0; w, err = Label_1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Label_1_2_capture_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_A-Za-z]", c)
+	}
+	return w, nil
+}
+func Label_1_2_capture_1_2_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+	}
+	return w, nil
+}
+func Label_1_2_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Label_1_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Label_1_2_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Label_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Label_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Label_1_2_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Label_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Label_1_2_capture_1(r, pos)
+	return w, err
+}
+func Label_1_2(r *result, pos int) (int, error) {
+	w, err := Label_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Label_1_3(r *result, pos int) (int, error) {
+	const literal = ":"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Label_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Label_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Label_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Label_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func LabelHandler(r *result, pos int) (int, error) {
+	w, err := Label_1(r, pos)
+	return w, err
+}
+func Literal_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Literal_1_2_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "\""
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_1_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'\\': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\\\]", c)
+	}
+	return w, nil
+}
+func Literal_1_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	return w, nil
+}
+func Literal_1_2_capture_1_2_star_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_1_2_capture_1_2_star_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_1_2_capture_1_2_star_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_1_2_capture_1_2_star_paren_2_1_neg(r *result, pos int) (int, error) {
+	const literal = "\""
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_1_2_capture_1_2_star_paren_2_1(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Literal_1_2_capture_1_2_star_paren_2_1_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Literal_1_2_capture_1_2_star_paren_2_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	return w, nil
+}
+func Literal_1_2_capture_1_2_star_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_1_2_capture_1_2_star_paren_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_1_2_capture_1_2_star_paren_2_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_1_2_capture_1_2_star(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Literal_1_2_capture_1_2_star_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Literal_1_2_capture_1_2_star_paren_2(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Literal_1_2_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Literal_1_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Literal_1_2_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Literal_1_2_capture_1_3(r *result, pos int) (int, error) {
+	const literal = "\""
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_1_2_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_1_2_capture_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Literal_1_2_capture_1(r, pos)
+	return w, err
+}
+func Literal_1_2(r *result, pos int) (int, error) {
+	w, err := Literal_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Literal_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_2_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Literal_2_2_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "'"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_2_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
+	const literal = "'"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_2_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Literal_2_2_capture_1_2_star_paren_1_1_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Literal_2_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	return w, nil
+}
+func Literal_2_2_capture_1_2_star_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_2_2_capture_1_2_star_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_2_2_capture_1_2_star_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_2_2_capture_1_2_star(r *result, pos int) (int, error) {
+	w, err := Literal_2_2_capture_1_2_star_paren_1(r, pos)
+	return w, err
+}
+func Literal_2_2_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Literal_2_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Literal_2_2_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Literal_2_2_capture_1_3(r *result, pos int) (int, error) {
+	const literal = "'"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_2_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_2_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_2_2_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_2_2_capture_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_2_2_capture(r *result, pos int) (int, error) {
+	w, err := Literal_2_2_capture_1(r, pos)
+	return w, err
+}
+func Literal_2_2(r *result, pos int) (int, error) {
+	w, err := Literal_2_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Literal_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_2_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_3_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func Literal_3_2_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "`"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_3_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
+	const literal = "`"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_3_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Literal_3_2_capture_1_2_star_paren_1_1_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Literal_3_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	return w, nil
+}
+func Literal_3_2_capture_1_2_star_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_3_2_capture_1_2_star_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_3_2_capture_1_2_star_paren_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_3_2_capture_1_2_star(r *result, pos int) (int, error) {
+	w, err := Literal_3_2_capture_1_2_star_paren_1(r, pos)
+	return w, err
+}
+func Literal_3_2_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Literal_3_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Literal_3_2_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Literal_3_2_capture_1_3(r *result, pos int) (int, error) {
+	const literal = "`"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Literal_3_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_3_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_3_2_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_3_2_capture_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Literal_3_2_capture(r *result, pos int) (int, error) {
+	w, err := Literal_3_2_capture_1(r, pos)
+	return w, err
+}
+func Literal_3_2(r *result, pos int) (int, error) {
+	w, err := Literal_3_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Literal_3(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Literal_3_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Literal_3_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func LiteralHandler(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Literal_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Literal_2(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Literal_3(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Indent_1_1_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Indent_1_1(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Indent_1_1_star(r, pos); err == nil && w > 0; w, err = Indent_1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Indent_1_2_capture_1_1_paren_1_1(r *result, pos int) (int, error) {
+	const literal = "INDENT"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Indent_1_2_capture_1_1_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Indent_1_2_capture_1_1_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Indent_1_2_capture_1_1_paren_2_1(r *result, pos int) (int, error) {
+	const literal = "DEDENT"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Indent_1_2_capture_1_1_paren_2(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Indent_1_2_capture_1_1_paren_2_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Indent_1_2_capture_1_1_paren_3_1(r *result, pos int) (int, error) {
+	const literal = "SAMEDENT"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func Indent_1_2_capture_1_1_paren_3(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Indent_1_2_capture_1_1_paren_3_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Indent_1_2_capture_1_1(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := Indent_1_2_capture_1_1_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Indent_1_2_capture_1_1_paren_2(r, pos)
+	}
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = Indent_1_2_capture_1_1_paren_3(r, pos)
+	}
+	r.leave(scope)
+	return w, err
+}
+func Indent_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Indent_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Indent_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Indent_1_2_capture_1(r, pos)
+	return w, err
+}
+func Indent_1_2(r *result, pos int) (int, error) {
+	w, err := Indent_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Indent_1_3_neg(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+	}
+	return w, nil
+}
+func Indent_1_3(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := Indent_1_3_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func Indent_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Indent_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Indent_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Indent_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func IndentHandler(r *result, pos int) (int, error) {
+	w, err := Indent_1(r, pos)
+	return w, err
+}
+func BackRef_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func BackRef_1_2(r *result, pos int) (int, error) {
+	const literal = "$"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func BackRef_1_3_capture_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_A-Za-z]", c)
+	}
+	return w, nil
+}
+func BackRef_1_3_capture_1_2_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+	}
+	return w, nil
+}
+func BackRef_1_3_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := BackRef_1_3_capture_1_2_star(r, pos); err == nil && w > 0; w, err = BackRef_1_3_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func BackRef_1_3_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = BackRef_1_3_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = BackRef_1_3_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func BackRef_1_3_capture(r *result, pos int) (int, error) {
+	w, err := BackRef_1_3_capture_1(r, pos)
+	return w, err
+}
+func BackRef_1_3(r *result, pos int) (int, error) {
+	w, err := BackRef_1_3_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func BackRef_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = BackRef_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = BackRef_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = BackRef_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func BackRefHandler(r *result, pos int) (int, error) {
+	w, err := BackRef_1(r, pos)
+	return w, err
+}
+func Ident_1_1_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
+	}
+	return w, nil
+}
+func Ident_1_1(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Ident_1_1_star(r, pos); err == nil && w > 0; w, err = Ident_1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Ident_1_2_capture_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_A-Za-z]", c)
+	}
+	return w, nil
+}
+func Ident_1_2_capture_1_2_star(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+	}
+	return w, nil
+}
+func Ident_1_2_capture_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := Ident_1_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Ident_1_2_capture_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func Ident_1_2_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Ident_1_2_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Ident_1_2_capture_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func Ident_1_2_capture(r *result, pos int) (int, error) {
+	w, err := Ident_1_2_capture_1(r, pos)
+	return w, err
+}
+func Ident_1_2(r *result, pos int) (int, error) {
+	w, err := Ident_1_2_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func Ident_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = Ident_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = Ident_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func IdentHandler(r *result, pos int) (int, error) {
+	w, err := Ident_1(r, pos)
+	return w, err
+}
+func CharClass_1_1(r *result, pos int) (int, error) {
+	return apply(r, pos, _Handler, 36)
+}
+func CharClass_1_2(r *result, pos int) (int, error) {
+	const literal = "["
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func CharClass_1_3_capture_1_1_star(r *result, pos int) (int, error) {
+	return apply(r, pos, ClassItemHandler, 33)
+}
+func CharClass_1_3_capture_1_1(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := CharClass_1_3_capture_1_1_star(r, pos); err == nil && w > 0; w, err = CharClass_1_3_capture_1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func CharClass_1_3_capture_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = CharClass_1_3_capture_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func CharClass_1_3_capture(r *result, pos int) (int, error) {
+	w, err := CharClass_1_3_capture_1(r, pos)
+	return w, err
+}
+func CharClass_1_3(r *result, pos int) (int, error) {
+	w, err := CharClass_1_3_capture(r, pos)
+	if err != nil {
+		return w, err
+	}
+	r.TopNode().Start = pos
+	r.TopNode().Text = r.Source[pos : pos+w]
+	return w, nil
+}
+func CharClass_1_4(r *result, pos int) (int, error) {
+	const literal = "]"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func CharClass_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = CharClass_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = CharClass_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = CharClass_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = CharClass_1_4(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func CharClassHandler(r *result, pos int) (int, error) {
+	w, err := CharClass_1(r, pos)
+	return w, err
+}
+func ClassItem_1_1(r *result, pos int) (int, error) {
+	const literal = "["
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func ClassItem_1_2_star(r *result, pos int) (int, error) {
+	return apply(r, pos, ClassItemHandler, 33)
+}
+func ClassItem_1_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := ClassItem_1_2_star(r, pos); err == nil && w > 0; w, err = ClassItem_1_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func ClassItem_1_3(r *result, pos int) (int, error) {
+	const literal = "]"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
+	}
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+	}
+	return len(literal), nil
+}
+func ClassItem_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = ClassItem_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = ClassItem_1_2(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	w, err = ClassItem_1_3(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
+}
+func ClassItem_2_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'\\': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\\\]", c)
+	}
+	return w, nil
+}
+func ClassItem_2_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
+	}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
 	}
-	r.TopNode().Start = pos
-	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func Literal_1(r *result, pos int) (int, error) {
+func ClassItem_2(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_1_1(r, pos+ww)
+	w, err = ClassItem_2_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_1_2(r, pos+ww)
+	w, err = ClassItem_2_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Literal_2_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Literal_2_2_capture_1_1(r *result, pos int) (int, error) {
-	const literal = "'"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
-	}
-	return len(literal), nil
-}
-func Literal_2_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
-	const literal = "'"
+func ClassItem_3_1_neg(r *result, pos int) (int, error) {
+	const literal = "]"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1296,9 +4324,13 @@ func Literal_2_2_capture_1_2_star_paren_1_1_neg(r *result, pos int) (int, error)
 	}
 	return len(literal), nil
 }
-func Literal_2_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
+func ClassItem_3_1(r *result, pos int) (int, error) {
 	const negative = true
-	_, err := Literal_2_2_capture_1_2_star_paren_1_1_neg(r, pos)
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := ClassItem_3_1_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
 	if negative == (err != nil) {
 		return 0, nil
 	}
@@ -1307,7 +4339,7 @@ func Literal_2_2_capture_1_2_star_paren_1_1(r *result, pos int) (int, error) {
 	}
 	return 0, err
 }
-func Literal_2_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
+func ClassItem_3_2(r *result, pos int) (int, error) {
 	if pos == len(r.Source) {
 		return 0, fmt.Errorf("expected character, got EOF")
 	}
@@ -1317,35 +4349,39 @@ func Literal_2_2_capture_1_2_star_paren_1_2(r *result, pos int) (int, error) {
 	}
 	return w, nil
 }
-func Literal_2_2_capture_1_2_star_paren_1(r *result, pos int) (int, error) {
+func ClassItem_3(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_2_2_capture_1_2_star_paren_1_1(r, pos+ww)
+	w, err = ClassItem_3_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_2_2_capture_1_2_star_paren_1_2(r, pos+ww)
+	w, err = ClassItem_3_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Literal_2_2_capture_1_2_star(r *result, pos int) (int, error) {
-	w, err := Literal_2_2_capture_1_2_star_paren_1(r, pos)
-	return w, err
-}
-func Literal_2_2_capture_1_2(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := Literal_2_2_capture_1_2_star(r, pos); err == nil && w > 0; w, err = Literal_2_2_capture_1_2_star(r, pos+ww) {
-		ww += w
+func ClassItemHandler(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := ClassItem_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = ClassItem_2(r, pos)
 	}
-	return ww, nil
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = ClassItem_3(r, pos)
+	}
+	r.leave(scope)
+	return w, err
 }
-func Literal_2_2_capture_1_3(r *result, pos int) (int, error) {
-	const literal = "'"
+func IgnoreCase_1_1_capture_1_1(r *result, pos int) (int, error) {
+	const literal = "i"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1355,33 +4391,23 @@ func Literal_2_2_capture_1_3(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Literal_2_2_capture_1(r *result, pos int) (int, error) {
+func IgnoreCase_1_1_capture_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_2_2_capture_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = Literal_2_2_capture_1_2(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = Literal_2_2_capture_1_3(r, pos+ww)
+	w, err = IgnoreCase_1_1_capture_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func Literal_2_2_capture(r *result, pos int) (int, error) {
-	w, err := Literal_2_2_capture_1(r, pos)
+func IgnoreCase_1_1_capture(r *result, pos int) (int, error) {
+	w, err := IgnoreCase_1_1_capture_1(r, pos)
 	return w, err
 }
-func Literal_2_2(r *result, pos int) (int, error) {
-	w, err := Literal_2_2_capture(r, pos)
+func IgnoreCase_1_1(r *result, pos int) (int, error) {
+	w, err := IgnoreCase_1_1_capture(r, pos)
 	if err != nil {
 		return w, err
 	}
@@ -1389,27 +4415,54 @@ func Literal_2_2(r *result, pos int) (int, error) {
 	r.TopNode().Text = r.Source[pos : pos+w]
 	return w, nil
 }
-func Literal_2(r *result, pos int) (int, error) {
+func IgnoreCase_1_2_neg(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{'_': true}
+	var rangeTable = &unicode.RangeTable{R16: []unicode.Range16{unicode.Range16{Lo: 0x30, Hi: 0x39, Stride: 1}, unicode.Range16{Lo: 0x41, Hi: 0x5a, Stride: 1}, unicode.Range16{Lo: 0x61, Hi: 0x7a, Stride: 1}}}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !(charClassMap[c] || unicode.Is(rangeTable, c)) {
+		return 0, fmt.Errorf("character %q does not match class [_0-9A-Za-z]", c)
+	}
+	return w, nil
+}
+func IgnoreCase_1_2(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := IgnoreCase_1_2_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
+}
+func IgnoreCase_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Literal_2_1(r, pos+ww)
+	w, err = IgnoreCase_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Literal_2_2(r, pos+ww)
+	w, err = IgnoreCase_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func LiteralHandler(r *result, pos int) (int, error) {
-	w, err := Literal_1(r, pos)
-	if err != nil {
-		w, err = Literal_2(r, pos)
-	}
+func IgnoreCaseHandler(r *result, pos int) (int, error) {
+	w, err := IgnoreCase_1(r, pos)
 	return w, err
 }
 func EndOfLine_1_1_star(r *result, pos int) (int, error) {
@@ -1422,15 +4475,21 @@ func EndOfLine_1_1_star(r *result, pos int) (int, error) {
 		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
 	}
 	if !charClassMap[c] {
-		return 0, fmt.Errorf("character %q does not match class [\t ]", c)
+		return 0, fmt.Errorf("character %q does not match class [\\t ]", c)
 	}
 	return w, nil
 }
 func EndOfLine_1_1(r *result, pos int) (int, error) {
 	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
 	for w, err := EndOfLine_1_1_star(r, pos); err == nil && w > 0; w, err = EndOfLine_1_1_star(r, pos+ww) {
 		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
 	}
+	r.points = r.points[:k]
+	r.restoreState(save)
 	return ww, nil
 }
 func EndOfLine_1_2_paren_1_1(r *result, pos int) (int, error) {
@@ -1500,13 +4559,18 @@ func EndOfLine_1_2_paren_3(r *result, pos int) (int, error) {
 	return ww, nil
 }
 func EndOfLine_1_2(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
 	w, err := EndOfLine_1_2_paren_1(r, pos)
-	if err != nil {
+	if err != nil && !r.cut {
+		r.restoreState(save)
 		w, err = EndOfLine_1_2_paren_2(r, pos)
 	}
-	if err != nil {
+	if err != nil && !r.cut {
+		r.restoreState(save)
 		w, err = EndOfLine_1_2_paren_3(r, pos)
 	}
+	r.leave(scope)
 	return w, err
 }
 func EndOfLine_1(r *result, pos int) (int, error) {
@@ -1529,14 +4593,33 @@ func EndOfLineHandler(r *result, pos int) (int, error) {
 	w, err := EndOfLine_1(r, pos)
 	return w, err
 }
-func RHS_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, TermsHandler, 3)
+func __1_1_star_paren_1_1(r *result, pos int) (int, error) {
+	var charClassMap = map[rune]bool{' ': true, '\t': true, '\r': true, '\n': true}
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if w == 0 {
+		return 0, fmt.Errorf("expecting char, got EOF")
+	}
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	if !charClassMap[c] {
+		return 0, fmt.Errorf("character %q does not match class [\\t\\n\\r ]", c)
+	}
+	return w, nil
 }
-func RHS_1_2_star_paren_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
+func __1_1_star_paren_1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = __1_1_star_paren_1_1(r, pos+ww)
+	ww += w
+	if err != nil {
+		return ww, err
+	}
+	return ww, nil
 }
-func RHS_1_2_star_paren_1_2(r *result, pos int) (int, error) {
-	const literal = "/"
+func __1_1_star_paren_2_1(r *result, pos int) (int, error) {
+	const literal = "#"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1546,66 +4629,77 @@ func RHS_1_2_star_paren_1_2(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func RHS_1_2_star_paren_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, TermsHandler, 3)
-}
-func RHS_1_2_star_paren_1(r *result, pos int) (int, error) {
-	ww := 0
-	var w int
-	var err error
-	w, err = RHS_1_2_star_paren_1_1(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
-	}
-	w, err = RHS_1_2_star_paren_1_2(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+func __1_1_star_paren_2_2_star_paren_1_1_neg(r *result, pos int) (int, error) {
+	const literal = "\n"
+	if len(r.Source)-pos < len(literal) {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
-	w, err = RHS_1_2_star_paren_1_3(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+	next := r.Source[pos : pos+len(literal)]
+	if next != literal {
+		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
 	}
-	return ww, nil
+	return len(literal), nil
 }
-func RHS_1_2_star(r *result, pos int) (int, error) {
-	w, err := RHS_1_2_star_paren_1(r, pos)
-	return w, err
+func __1_1_star_paren_2_2_star_paren_1_1(r *result, pos int) (int, error) {
+	const negative = true
+	r.predicates++
+	scope := r.enter(pos, false)
+	_, err := __1_1_star_paren_2_2_star_paren_1_1_neg(r, pos)
+	r.leave(scope)
+	r.predicates--
+	if negative == (err != nil) {
+		return 0, nil
+	}
+	if err == nil {
+		return 0, fmt.Errorf("negative predicate matched")
+	}
+	return 0, err
 }
-func RHS_1_2(r *result, pos int) (int, error) {
-	ww := 0
-	for w, err := RHS_1_2_star(r, pos); err == nil && w > 0; w, err = RHS_1_2_star(r, pos+ww) {
-		ww += w
+func __1_1_star_paren_2_2_star_paren_1_2(r *result, pos int) (int, error) {
+	if pos == len(r.Source) {
+		return 0, fmt.Errorf("expected character, got EOF")
 	}
-	return ww, nil
+	c, w := utf8.DecodeRuneInString(r.Source[pos:])
+	if c == utf8.RuneError {
+		return w, fmt.Errorf("invalid utf8: %q", r.Source[pos:pos+w])
+	}
+	return w, nil
 }
-func RHS_1(r *result, pos int) (int, error) {
+func __1_1_star_paren_2_2_star_paren_1(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = RHS_1_1(r, pos+ww)
+	w, err = __1_1_star_paren_2_2_star_paren_1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = RHS_1_2(r, pos+ww)
+	w, err = __1_1_star_paren_2_2_star_paren_1_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func RHSHandler(r *result, pos int) (int, error) {
-	w, err := RHS_1(r, pos)
+func __1_1_star_paren_2_2_star(r *result, pos int) (int, error) {
+	w, err := __1_1_star_paren_2_2_star_paren_1(r, pos)
 	return w, err
 }
-func Parens_1_1(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
+func __1_1_star_paren_2_2(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := __1_1_star_paren_2_2_star(r, pos); err == nil && w > 0; w, err = __1_1_star_paren_2_2_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
 }
-func Parens_1_2(r *result, pos int) (int, error) {
-	const literal = "("
+func __1_1_star_paren_2_3_question(r *result, pos int) (int, error) {
+	const literal = "\n"
 	if len(r.Source)-pos < len(literal) {
 		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
 	}
@@ -1615,60 +4709,79 @@ func Parens_1_2(r *result, pos int) (int, error) {
 	}
 	return len(literal), nil
 }
-func Parens_1_3(r *result, pos int) (int, error) {
-	return apply(r, pos, RHSHandler, 2)
-}
-func Parens_1_4(r *result, pos int) (int, error) {
-	return apply(r, pos, _Handler, 14)
-}
-func Parens_1_5(r *result, pos int) (int, error) {
-	const literal = ")"
-	if len(r.Source)-pos < len(literal) {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, r.Source[pos:])
-	}
-	next := r.Source[pos : pos+len(literal)]
-	if next != literal {
-		return 0, fmt.Errorf("expecting %q, got %q", literal, next)
+func __1_1_star_paren_2_3(r *result, pos int) (int, error) {
+	save := r.saveState()
+	k := r.push(pos)
+	w, err := __1_1_star_paren_2_3_question(r, pos)
+	r.points = r.points[:k]
+	if err != nil {
+		r.restoreState(save)
+		return 0, nil
 	}
-	return len(literal), nil
+	return w, nil
 }
-func Parens_1(r *result, pos int) (int, error) {
+func __1_1_star_paren_2(r *result, pos int) (int, error) {
 	ww := 0
 	var w int
 	var err error
-	w, err = Parens_1_1(r, pos+ww)
+	w, err = __1_1_star_paren_2_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Parens_1_2(r, pos+ww)
+	w, err = __1_1_star_paren_2_2(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Parens_1_3(r, pos+ww)
+	w, err = __1_1_star_paren_2_3(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
-	w, err = Parens_1_4(r, pos+ww)
-	ww += w
-	if err != nil {
-		return ww, err
+	return ww, nil
+}
+func __1_1_star(r *result, pos int) (int, error) {
+	scope := r.enter(pos, true)
+	save := r.saveState()
+	w, err := __1_1_star_paren_1(r, pos)
+	if err != nil && !r.cut {
+		r.restoreState(save)
+		w, err = __1_1_star_paren_2(r, pos)
 	}
-	w, err = Parens_1_5(r, pos+ww)
+	r.leave(scope)
+	return w, err
+}
+func __1_1(r *result, pos int) (int, error) {
+	ww := 0
+	save := r.saveState()
+	k := r.push(pos)
+	for w, err := __1_1_star(r, pos); err == nil && w > 0; w, err = __1_1_star(r, pos+ww) {
+		ww += w
+		save = r.saveState()
+		r.points[k] = pos + ww
+	}
+	r.points = r.points[:k]
+	r.restoreState(save)
+	return ww, nil
+}
+func __1(r *result, pos int) (int, error) {
+	ww := 0
+	var w int
+	var err error
+	w, err = __1_1(r, pos+ww)
 	ww += w
 	if err != nil {
 		return ww, err
 	}
 	return ww, nil
 }
-func ParensHandler(r *result, pos int) (int, error) {
-	w, err := Parens_1(r, pos)
+func _Handler(r *result, pos int) (int, error) {
+	w, err := __1(r, pos)
 	return w, err
 }
 
-var labels = []string{"Grammar", "Rule", "RHS", "Terms", "Term", "Special", "Parens", "NegPred", "Pred", "Capture", "Literal", "Ident", "CharClass", "EndOfLine", "_"}
+var labels = []string{"Grammar", "Import", "Skip", "Rule", "Params", "Override", "Token", "Marker", "Prec", "PrecLevel", "PrecOp", "RHS", "Terms", "Term", "Special", "Cut", "Repeat", "Recover", "Parens", "SemPred", "SemNegPred", "NegPred", "Pred", "Capture", "Call", "Arg", "Labeled", "Label", "Literal", "Indent", "BackRef", "Ident", "CharClass", "ClassItem", "IgnoreCase", "EndOfLine", "_"}
 
 func parse(source string) (*result, error) {
 	r := &result{Source: source, Memo: make(map[int]map[int]*parser.Node), NodeStack: make([]*parser.Node, 0, 10)}
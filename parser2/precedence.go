@@ -0,0 +1,293 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/salikh/peg/parser"
+)
+
+// A rule can be defined by a precedence table of operators instead of
+// a sequence of terms:
+//
+//   Expr <- %prec Operand
+//     %left Add:"+" Sub:"-"
+//     %left Mul:"*" Div:"/"
+//     %right Pow:"^"
+//     %prefix Neg:"-"
+//     %postfix Fact:"!"
+//
+// The levels are listed from the lowest to the highest precedence. The
+// operands are parsed with the rule Operand, and the operators with
+// precedence climbing, without a chain of rules per level. Every operator
+// produces one node labeled by the operator name, or by the rule name if
+// the operator has no name, with the operator text in Node.Text and the
+// operands as children, e.g. 1+2*3 is parsed as
+//
+//   (Expr (Add "+" (Operand "1") (Mul "*" (Operand "2") (Operand "3"))))
+//
+// If the grammar has the %skip directive and the rule is not lexical,
+// the skip rule is applied between the operators and operands, and the
+// nodes it produces are discarded.
+
+// Precedence is a precedence table of operators.
+type Precedence struct {
+	// Levels are the levels of operators from the lowest to the highest
+	// precedence.
+	Levels []*PrecLevel
+	// skip is the name of the skip rule applied between the operators
+	// and operands, set by insertSkip.
+	skip string
+}
+
+// PrecLevel is one level of a precedence table.
+type PrecLevel struct {
+	// Kind is one of "left" and "right" for the left- and right-associative
+	// infix operators, "prefix" or "postfix".
+	Kind string
+	Ops  []*PrecOp
+}
+
+// PrecOp is an operator of a precedence table.
+type PrecOp struct {
+	// Label is the label of the operator nodes, or empty if the nodes
+	// are labeled by the rule name.
+	Label   string
+	Literal string
+}
+
+func (p *Precedence) String() string {
+	r := []string{"(Precedence"}
+	for _, level := range p.Levels {
+		r = append(r, ` (Level :Kind(`, strconv.Quote(level.Kind), `)`)
+		for _, op := range level.Ops {
+			r = append(r, ` (Op :Literal(`, strconv.Quote(op.Literal), `)`)
+			if op.Label != "" {
+				r = append(r, ` :Label(`, strconv.Quote(op.Label), `)`)
+			}
+			r = append(r, ")")
+		}
+		r = append(r, ")")
+	}
+	r = append(r, ")")
+	return strings.Join(r, "")
+}
+
+// ShortString returns the source representation of the precedence table
+// without the operand.
+func (p *Precedence) ShortString() string {
+	var r []string
+	for _, level := range p.Levels {
+		r = append(r, "%"+level.Kind)
+		for _, op := range level.Ops {
+			if op.Label != "" {
+				r = append(r, op.Label+":"+strconv.Quote(op.Literal))
+			} else {
+				r = append(r, strconv.Quote(op.Literal))
+			}
+		}
+	}
+	return strings.Join(r, " ")
+}
+
+// check returns an error if the operators are empty or ambiguous. The same
+// literal can be both a prefix operator and an infix or postfix operator,
+// since they are matched at different positions.
+func (p *Precedence) check() error {
+	prefix := make(map[string]bool)
+	other := make(map[string]bool)
+	for _, level := range p.Levels {
+		for _, op := range level.Ops {
+			if op.Literal == "" {
+				return fmt.Errorf("empty operator in precedence table")
+			}
+			seen := other
+			if level.Kind == "prefix" {
+				seen = prefix
+			}
+			if seen[op.Literal] {
+				return fmt.Errorf("operator %q is defined more than once", op.Literal)
+			}
+			seen[op.Literal] = true
+		}
+	}
+	return nil
+}
+
+// precOp is an operator prepared for matching.
+type precOp struct {
+	literal string
+	label   string
+	// level is the precedence of the operator, starting from 1.
+	level int
+	right bool
+}
+
+// precParser parses the operators of a precedence table with precedence
+// climbing.
+type precParser struct {
+	operand handler
+	skip    handler
+	// The operators of each kind are sorted by the literal length, so that
+	// the longest operator is matched first.
+	prefix, postfix, infix []precOp
+}
+
+func (g *Grammar) makePrecedenceHandler(term *Term) (handler, error) {
+	p := &precParser{}
+	var err error
+	p.operand, err = g.makeRuleHandler(term.Ident)
+	if err != nil {
+		return nil, err
+	}
+	if term.Prec.skip != "" {
+		p.skip, err = g.makeRuleHandler(term.Prec.skip)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, level := range term.Prec.Levels {
+		for _, op := range level.Ops {
+			o := precOp{literal: op.Literal, label: op.Label, level: i + 1, right: level.Kind == "right"}
+			switch level.Kind {
+			case "prefix":
+				p.prefix = append(p.prefix, o)
+			case "postfix":
+				p.postfix = append(p.postfix, o)
+			default:
+				p.infix = append(p.infix, o)
+			}
+		}
+	}
+	for _, ops := range [][]precOp{p.prefix, p.postfix, p.infix} {
+		sort.SliceStable(ops, func(i, j int) bool { return len(ops[i].literal) > len(ops[j].literal) })
+	}
+	return func(r *Result, pos int) (int, error) {
+		// The operators and the operands are tried at several positions,
+		// so the parser can resume anywhere after pos.
+		k := r.push(pos)
+		nodes, w, err := p.parse(r, pos, 0)
+		r.points = r.points[:k]
+		if err != nil {
+			return w, err
+		}
+		top := r.TopNode()
+		top.Children = append(top.Children[:len(top.Children):len(top.Children)], nodes...)
+		return w, nil
+	}, nil
+}
+
+// parse parses an expression at pos with the operators of precedence min
+// and higher. It returns the nodes of the expression, which are the nodes
+// of the operand if there are no operators.
+func (p *precParser) parse(r *Result, pos, min int) ([]*parser.Node, int, error) {
+	var left []*parser.Node
+	w := -1
+	if op, ok := p.match(r, pos, p.prefix, 0); ok {
+		s := p.skipAt(r, pos+len(op.literal))
+		operand, ww, err := p.parse(r, pos+len(op.literal)+s, op.level)
+		if err == nil {
+			w = len(op.literal) + s + ww
+			left = []*parser.Node{op.node(r, pos, w, operand)}
+		}
+	}
+	if w < 0 {
+		var err error
+		left, w, err = collectNodes(r, p.operand, pos)
+		if err != nil {
+			return nil, w, err
+		}
+	}
+	for {
+		s := p.skipAt(r, pos+w)
+		at := pos + w + s
+		if op, ok := p.match(r, at, p.postfix, min); ok {
+			w += s + len(op.literal)
+			left = []*parser.Node{op.node(r, pos, w, left)}
+			continue
+		}
+		op, ok := p.match(r, at, p.infix, min)
+		if !ok {
+			break
+		}
+		next := op.level + 1
+		if op.right {
+			next = op.level
+		}
+		s2 := p.skipAt(r, at+len(op.literal))
+		right, ww, err := p.parse(r, at+len(op.literal)+s2, next)
+		if err != nil {
+			// The operator is not a part of the expression.
+			break
+		}
+		w += s + len(op.literal) + s2 + ww
+		left = []*parser.Node{op.node(r, pos, w, append(left[:len(left):len(left)], right...))}
+	}
+	return left, w, nil
+}
+
+// collectNodes applies the handler and returns the nodes it attached to the
+// top node, removing them from the top node.
+func collectNodes(r *Result, h handler, pos int) ([]*parser.Node, int, error) {
+	top := r.TopNode()
+	n := len(top.Children)
+	w, err := h(r, pos)
+	nodes := append([]*parser.Node(nil), top.Children[n:]...)
+	top.Children = top.Children[:n]
+	return nodes, w, err
+}
+
+// skipAt applies the skip rule, if any, and returns the length of the
+// skipped input.
+func (p *precParser) skipAt(r *Result, pos int) int {
+	if p.skip == nil {
+		return 0
+	}
+	_, w, err := collectNodes(r, p.skip, pos)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// match returns the first operator of ops with precedence min or higher
+// that matches the input at pos.
+func (p *precParser) match(r *Result, pos int, ops []precOp, min int) (precOp, bool) {
+	for _, op := range ops {
+		if op.level < min {
+			continue
+		}
+		if strings.HasPrefix(r.Source[pos:], op.literal) {
+			return op, true
+		}
+		r.expect(pos, strconv.Quote(op.literal))
+	}
+	return precOp{}, false
+}
+
+// node makes the node of the operator applied to the operands, spanning
+// the input from pos of length w. The operators without a label are
+// labeled by the rule being parsed.
+func (op precOp) node(r *Result, pos, w int, operands []*parser.Node) *parser.Node {
+	label := op.label
+	if label == "" {
+		label = r.TopNode().Label
+	}
+	return &parser.Node{Label: label, Text: op.literal, Pos: pos, Len: w, Children: operands}
+}
@@ -60,6 +60,22 @@ type CaptureTest struct {
 	Outcomes []CaptureOutcome
 }
 
+// TreeOutcome defines one test input together with the expected parse tree.
+type TreeOutcome struct {
+	// Input string.
+	Input string
+	// Tree is the expected parse tree in the serialization format of
+	// package tree. If empty, the input must trigger parser error.
+	Tree string
+}
+
+// TreeTest defines one test that checks the shape of the parse tree.
+// The parser is expected to skip empty nodes.
+type TreeTest struct {
+	Grammar  string
+	Outcomes []TreeOutcome
+}
+
 // Invalid is an array of negative tests with invalid grammars.
 var Invalid = []InvalidGrammarTest{
 	{"Ident <- abc <- xyz"},
@@ -82,6 +98,16 @@ var Invalid = []InvalidGrammarTest{
 	{"I <- [z-a]"},
 	{"I <- &"},
 	{"I <- !"},
+	{"A <- 'a'^b"},
+	{"A <- ^b 'a'\nb <- .*"},
+	{"A <- 'a'^b^b\nb <- .*"},
+	{"A <- $x"},
+	{"A <- <x: 'a'>\nB <- $x"},
+	{"%skip _\nA <- 'a' 'b'"},
+	{`A <- "\q"`},
+	{`A <- "\x4"`},
+	{`A <- "\u00e"`},
+	{"A <- `a"},
 }
 
 // Positive is an array of positive tests.
@@ -578,6 +604,39 @@ var Positive = []PositiveTest{
 			{"ab1\n", false},
 		},
 	},
+	{
+		Grammar: `Quote1 <- "\"" ( "\\\"" / !"\"" . )* "\""`,
+		Outcomes: []Outcome{
+			{`""`, true},
+			{`"abc"`, true},
+			{`"a\"b"`, true},
+			{`"a"b"`, false},
+			{`"a\"`, false},
+		},
+	},
+	{
+		Grammar: `Quote2 <- "it\'s" / "\'\""`,
+		Outcomes: []Outcome{
+			{"it's", true},
+			{`'"`, true},
+			{`it\'s`, false},
+		},
+	},
+	{
+		Grammar: `Unicode1 <- "\u00e9\U0001F600" "\x41\101"`,
+		Outcomes: []Outcome{
+			{"é😀AA", true},
+			{"e😀AA", false},
+			{`\u00e9\U0001F600\x41\101`, false},
+		},
+	},
+	{
+		Grammar: "Raw1 <- `a\\n\"'` `\\`",
+		Outcomes: []Outcome{
+			{`a\n"'\`, true},
+			{"a\n\"'\\", false},
+		},
+	},
 }
 
 // Capture is an array of capture tests.
@@ -638,3 +697,758 @@ var Capture = []CaptureTest{
 		},
 	},
 }
+
+// LeftRecursion is an array of tests for grammars with left-recursive rules.
+// The bootstrap parser does not support left recursion, so these tests
+// are kept separately from Positive.
+var LeftRecursion = []TreeTest{
+	{
+		Grammar: `Expr <- Expr Op Num / Num
+Op <- < [-+] >
+Num <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"1", `(Expr (Num "1"))`},
+			{"1-2", `(Expr (Expr (Num "1")) (Op "-") (Num "2"))`},
+			{"1-2+3", `(Expr (Expr (Expr (Num "1")) (Op "-") (Num "2")) (Op "+") (Num "3"))`},
+			{"", ""},
+			{"1-", ""},
+			{"-1", ""},
+		},
+	},
+	{
+		// Two levels of precedence, each left-associative.
+		Grammar: `Sum <- Sum < [-+] > Product / Product
+Product <- Product < [*/] > Num / Num
+Num <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"1*2", `(Sum (Product "*" (Product (Num "1")) (Num "2")))`},
+			{"1+2*3", `(Sum "+" (Sum (Product (Num "1"))) (Product "*" (Product (Num "2")) (Num "3")))`},
+			{"1*2-3", `(Sum "-" (Sum (Product "*" (Product (Num "1")) (Num "2"))) (Product (Num "3")))`},
+			{"1+*2", ""},
+		},
+	},
+	{
+		// Indirect left recursion through another rule.
+		Grammar: `Expr <- Call / Name
+Call <- Expr '(' Name? ')'
+Name <- < [a-z]+ >`,
+		Outcomes: []TreeOutcome{
+			{"f", `(Expr (Name "f"))`},
+			{"f()", `(Expr (Call (Expr (Name "f"))))`},
+			{"f(x)(y)", `(Expr (Call (Expr (Call (Expr (Name "f")) (Name "x"))) (Name "y")))`},
+			{"f(x", ""},
+			{"()", ""},
+		},
+	},
+	{
+		// Left recursion through a nullable prefix.
+		Grammar: `List <- _ List ',' Item / Item
+Item <- _ < [a-z] >
+_ <- ' '*`,
+		Outcomes: []TreeOutcome{
+			{"a", `(List (Item "a"))`},
+			{"a, b,c", `(List (List (List (Item "a")) (Item "b")) (Item "c"))`},
+			{"a,", ""},
+		},
+	},
+}
+
+// Parameterized is an array of tests for grammars with parameterized rules.
+// The instances of parameterized rules are labeled by the rule name joined
+// with the argument names, e.g. CommaList(Ident) produces CommaList_Ident
+// nodes.
+var Parameterized = []TreeTest{
+	{
+		Grammar: `Decl <- Names ':' Nums
+Names <- CommaList(Ident)
+Nums <- CommaList(Num)
+CommaList(X) <- X (_ ',' X)*
+Ident <- _ < [a-z]+ >
+Num <- _ < [0-9]+ >
+_ <- ' '*`,
+		Outcomes: []TreeOutcome{
+			{"a:1", `(Decl (Names (CommaList_Ident (Ident "a"))) (Nums (CommaList_Num (Num "1"))))`},
+			{"a, b:1, 2, 3", `(Decl
+  (Names (CommaList_Ident (Ident "a") (Ident "b")))
+  (Nums (CommaList_Num (Num "1") (Num "2") (Num "3"))))`},
+			{"a,:1", ""},
+			{"a:b", ""},
+		},
+	},
+	{
+		// Nested and recursive instantiation.
+		Grammar: `Top <- List(Pair(Key, Val))
+List(X) <- X (';' List(X))?
+Pair(K, V) <- K '=' V
+Key <- < [a-z]+ >
+Val <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"a=1", `(Top (List_Pair_Key_Val (Pair_Key_Val (Key "a") (Val "1"))))`},
+			{"a=1;b=2", `(Top (List_Pair_Key_Val
+  (Pair_Key_Val (Key "a") (Val "1"))
+  (List_Pair_Key_Val (Pair_Key_Val (Key "b") (Val "2")))))`},
+			{"a=1;", ""},
+			{"a", ""},
+		},
+	},
+}
+
+// IgnoreCase is an array of tests for grammars with case-insensitive literals
+// ("abc"i) and character classes ([a-z]i) that use Unicode simple case
+// folding.
+var IgnoreCase = []TreeTest{
+	{
+		Grammar: `Select <- Keyword _ Name
+Keyword <- < "select"i >
+Name <- < [a-z_]i+ >
+_ <- ' '+`,
+		Outcomes: []TreeOutcome{
+			{"select x", `(Select (Keyword "select") (Name "x"))`},
+			{"SELECT Xy_Z", `(Select (Keyword "SELECT") (Name "Xy_Z"))`},
+			{"SeLeCt a", `(Select (Keyword "SeLeCt") (Name "a"))`},
+			{"selec x", ""},
+			{"select 1", ""},
+		},
+	},
+	{
+		// The folded input may have a different length in bytes:
+		// U+212A KELVIN SIGN folds to k, and U+017F LATIN SMALL LETTER LONG S
+		// folds to s.
+		Grammar: `Word <- Ok S
+Ok <- < "ok"i >
+S <- < [s]i >`,
+		Outcomes: []TreeOutcome{
+			{"oks", `(Word (Ok "ok") (S "s"))`},
+			{"OKS", `(Word (Ok "OK") (S "S"))`},
+			{"oKs", `(Word (Ok "oK") (S "s"))`},
+			{"okſ", `(Word (Ok "ok") (S "ſ"))`},
+			{"ox", ""},
+		},
+	},
+	{
+		Grammar: `Greeting <- Word ' ' Mark
+Word <- < "привет"i >
+Mark <- < [^а-я]i >`,
+		Outcomes: []TreeOutcome{
+			{"Привет !", `(Greeting (Word "Привет") (Mark "!"))`},
+			{"ПРИВЕТ !", `(Greeting (Word "ПРИВЕТ") (Mark "!"))`},
+			{"привет Я", ""},
+		},
+	},
+}
+
+// Labels is an array of tests for grammars with labeled rule references
+// (name:Rule), which store the node in TreeAnnotations, and labeled
+// captures (<name: ...>), which store the text in Annotations.
+var Labels = []TreeTest{
+	{
+		Grammar: `Assign <- name:Ident _ '=' _ value:Expr
+Expr <- Num / Ident
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >
+_ <- ' '*`,
+		Outcomes: []TreeOutcome{
+			{"x = 1", `(Assign :name(Ident "x") :value(Expr (Num "1")))`},
+			{"x=y", `(Assign :name(Ident "x") :value(Expr (Ident "y")))`},
+			{"1 = x", ""},
+		},
+	},
+	{
+		Grammar: `Pair <- <key: [a-z]+> '=' < value:[0-9]* >`,
+		Outcomes: []TreeOutcome{
+			{"a=1", `(Pair :key("a") :value("1"))`},
+			{"abc=", `(Pair :key("abc") :value(""))`},
+			{"=1", ""},
+		},
+	},
+	{
+		// The labels of failed alternatives are discarded.
+		Grammar: `A <- x:B '!' / y:B '?' / B
+B <- < [a-z] >`,
+		Outcomes: []TreeOutcome{
+			{"b!", `(A :x(B "b"))`},
+			{"b?", `(A :y(B "b"))`},
+			{"b", `(A (B "b"))`},
+		},
+	},
+	{
+		// Labeled and unlabeled children, and labels inside of repetitions.
+		Grammar: `Call <- fn:Ident '(' ( Arg ( ',' Arg )* )? ')' ( '.' last:Ident )*
+Arg <- <text: [a-z0-9]+ >
+Ident <- < [a-z]+ >`,
+		Outcomes: []TreeOutcome{
+			{"f()", `(Call :fn(Ident "f"))`},
+			{"f(a,1)", `(Call :fn(Ident "f") (Arg :text("a")) (Arg :text("1")))`},
+			{"f(a).b.c", `(Call :fn(Ident "f") :last(Ident "c") (Arg :text("a")))`},
+		},
+	},
+	{
+		// Labeled invocation of a parameterized rule.
+		Grammar: `Top <- names:List(Ident) ';' nums:List(Num)
+List(X) <- X (',' X)*
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"a,b;1", `(Top :names(List_Ident (Ident "a") (Ident "b")) :nums(List_Num (Num "1")))`},
+		},
+	},
+}
+
+// Markers is an array of tests for grammars with the tree shape markers
+// inline, drop and keep.
+var Markers = []TreeTest{
+	{
+		Grammar: `Sum <- Term ( _ Op _ Term )*
+inline Term <- Num / Paren
+Paren <- '(' _ Sum _ ')'
+Op <- < [-+] >
+Num <- < [0-9]+ >
+drop _ <- ( [ \t\n] / Comment )*
+Comment <- < '#' [a-z]* >`,
+		Outcomes: []TreeOutcome{
+			{"1", `(Sum (Num "1"))`},
+			{"1 + 2", `(Sum (Num "1") (Op "+") (Num "2"))`},
+			{"1 #c\n+ (2 - 3)", `(Sum (Num "1") (Op "+")
+  (Paren (Sum (Num "2") (Op "-") (Num "3"))))`},
+			{"1 +", ""},
+		},
+	},
+	{
+		Grammar: `List <- '[' Items ']'
+keep Items <- Item*
+Item <- < [a-z] >`,
+		Outcomes: []TreeOutcome{
+			{"[]", `(List (Items))`},
+			{"[ab]", `(List (Items (Item "a") (Item "b")))`},
+		},
+	},
+	{
+		// The annotations of an inlined node are merged into the parent.
+		Grammar: `Assign <- Target '=' value:Num
+inline Target <- name:Ident <kind: '*'?>
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"x=1", `(Assign :kind("") :name(Ident "x") :value(Num "1"))`},
+			{"p*=2", `(Assign :kind("*") :name(Ident "p") :value(Num "2"))`},
+		},
+	},
+	{
+		Grammar: `Top <- List(Num) ';' List(Ident)
+inline List(X) <- X (',' X)*
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"1,2;a", `(Top (Num "1") (Num "2") (Ident "a"))`},
+		},
+	},
+	{
+		// Markers are only recognized before a rule definition.
+		Grammar: `A <- keep drop
+keep <- < 'k' >
+drop <- < 'd' >`,
+		Outcomes: []TreeOutcome{
+			{"kd", `(A (keep "k") (drop "d"))`},
+		},
+	},
+}
+
+// Cut is an array of tests for grammars with the cut operator ~.
+var Cut = []TreeTest{
+	{
+		Grammar: `Stmt <- Ident '(' ~ Args ')' / Word
+Args <- Ident ( ',' Ident )*
+Word <- < [a-z()]+ >
+Ident <- < [a-z]+ >`,
+		Outcomes: []TreeOutcome{
+			{"f(x)", `(Stmt (Ident "f") (Args (Ident "x")))`},
+			{"fx", `(Stmt (Word "fx"))`},
+			// After the cut, Word is not tried.
+			{"f(x", ""},
+			{"f()", ""},
+		},
+	},
+	{
+		// The cuts inside of a rule only commit the choices of the rule.
+		Grammar: `Stmt <- Call / Word
+Call <- Ident '(' ~ Ident ')'
+Word <- < [a-z()]+ >
+Ident <- < [a-z]+ >`,
+		Outcomes: []TreeOutcome{
+			{"f(x)", `(Stmt (Call (Ident "f") (Ident "x")))`},
+			{"f(x", `(Stmt (Word "f(x"))`},
+		},
+	},
+	{
+		Grammar: `S <- ( A / B ) 'c' / 'xyd'
+A <- 'x' ~ 'y'
+B <- 'x'`,
+		Outcomes: []TreeOutcome{
+			{"xyc", `(S)`},
+			{"xyd", `(S)`},
+			{"xyz", ""},
+		},
+	},
+	{
+		// A failure after a cut ends a repetition like any other failure.
+		Grammar: `List <- Item* Rest?
+Item <- < [a-z] > ~ ';'
+Rest <- < [a-z0-9]+ >`,
+		Outcomes: []TreeOutcome{
+			{"a;b;", `(List (Item "a") (Item "b"))`},
+			{"a;1", `(List (Item "a") (Rest "1"))`},
+			{"a;b", `(List (Item "a") (Rest "b"))`},
+		},
+	},
+	{
+		Grammar: `List <- ( Item ~ ';' )* Rest
+Item <- < [a-z] >
+Rest <- < [a-z]+ '.' >`,
+		Outcomes: []TreeOutcome{
+			{"a;b;c.", `(List (Item "a") (Item "b") (Rest "c."))`},
+			{"a;bc.", `(List (Item "a") (Rest "bc."))`},
+		},
+	},
+	{
+		Grammar: `A <- ( 'a' ~ 'b' ){2,3} / 'a' 'b' 'c'`,
+		Outcomes: []TreeOutcome{
+			{"abab", `(A)`},
+			{"ababab", `(A)`},
+			// The cut passed in the first iteration commits the choice,
+			// so the second alternative is not tried.
+			{"abc", ""},
+		},
+	},
+	{
+		Grammar: `A <- ( '-' ~ Num )? Num
+Num <- < [0-9] >`,
+		Outcomes: []TreeOutcome{
+			{"-12", `(A (Num "1") (Num "2"))`},
+			{"1", `(A (Num "1"))`},
+			{"-", ""},
+		},
+	},
+	{
+		// The cuts inside of a predicate only commit the choices inside
+		// of the predicate.
+		Grammar: `A <- !( 'x' ~ 'y' / 'x' ) B / C
+B <- < [a-z]+ >
+C <- < 'x' [a-z]* >`,
+		Outcomes: []TreeOutcome{
+			{"ab", `(A (B "ab"))`},
+			{"xz", `(A (B "xz"))`},
+			{"xyz", `(A (C "xyz"))`},
+		},
+	},
+	{
+		// The cut commits only the innermost choice, so the enclosing
+		// choice still tries the next alternative.
+		Grammar: `A <- ( 'a' ( 'b' ~ 'c' / 'b' ) ) / 'a' 'b' 'd'`,
+		Outcomes: []TreeOutcome{
+			{"abc", `(A)`},
+			{"abd", `(A)`},
+			{"abe", ""},
+		},
+	},
+	{
+		// A cut in a group without alternatives commits the enclosing
+		// choice.
+		Grammar: `A <- 'a' ( 'b' ~ ) 'c' / 'a' 'b' 'd'`,
+		Outcomes: []TreeOutcome{
+			{"abc", `(A)`},
+			{"abd", ""},
+		},
+	},
+}
+
+// Repeat is an array of tests for grammars with the bounded repetition
+// {n}, {n,} and {n,m}.
+var Repeat = []TreeTest{
+	{
+		Grammar: `Code <- 'U+' Hex
+Hex <- < [0-9A-F]{4} >`,
+		Outcomes: []TreeOutcome{
+			{"U+00E9", `(Code (Hex "00E9"))`},
+			{"U+0E9", ""},
+			{"U+00E9F", ""},
+		},
+	},
+	{
+		Grammar: `Date <- Year '-' Num{1,2} '-' Num{1,2}
+Year <- < [0-9]{4} >
+Num <- < [0-9] >`,
+		Outcomes: []TreeOutcome{
+			{"2024-1-15", `(Date (Year "2024") (Num "1") (Num "1") (Num "5"))`},
+			{"2024-10-5", `(Date (Year "2024") (Num "1") (Num "0") (Num "5"))`},
+			{"2024-100-5", ""},
+			{"24-1-5", ""},
+		},
+	},
+	{
+		Grammar: `A <- Item{2,} Rest?
+Item <- < 'a' >
+Rest <- < 'b'{0,1} 'c' >`,
+		Outcomes: []TreeOutcome{
+			{"a", ""},
+			{"aa", `(A (Item "a") (Item "a"))`},
+			{"aaaac", `(A (Item "a") (Item "a") (Item "a") (Item "a") (Rest "c"))`},
+			{"aabc", `(A (Item "a") (Item "a") (Rest "bc"))`},
+			{"aabbc", ""},
+		},
+	},
+	{
+		// The repetition of an expression that can match empty input stops
+		// after the first empty match.
+		Grammar: `A <- ( 'x'? ){3} < 'y' >`,
+		Outcomes: []TreeOutcome{
+			{"y", `(A "y")`},
+			{"xxy", `(A "y")`},
+			{"xxxy", `(A "y")`},
+			{"xxxxy", ""},
+		},
+	},
+}
+
+// CharClass tests the character classes that mix special classes, nested
+// classes and set operations.
+var CharClass = []TreeTest{
+	{
+		Grammar: `Ident <- < [[:alpha:]_][[:alpha:]_0-9]* >`,
+		Outcomes: []TreeOutcome{
+			{"x_1", `(Ident "x_1")`},
+			{"имя2", `(Ident "имя2")`},
+			{"_", `(Ident "_")`},
+			{"1x", ""},
+		},
+	},
+	{
+		Grammar: `Word <- Consonant+
+Consonant <- < [[a-z]--[aeiou]] >`,
+		Outcomes: []TreeOutcome{
+			{"bcd", `(Word (Consonant "b") (Consonant "c") (Consonant "d"))`},
+			{"bad", ""},
+		},
+	},
+	{
+		Grammar: `A <- Head Tail?
+Head <- < [[a-z]&&[^m-z]]+ >
+Tail <- < [^[a-z][0-9]]+ >`,
+		Outcomes: []TreeOutcome{
+			{"abc", `(A (Head "abc"))`},
+			{"abcXY", `(A (Head "abc") (Tail "XY"))`},
+			{"abcm", ""},
+			{"abc1", ""},
+		},
+	},
+	{
+		Grammar: `Ident <- < [\p{XID_Start}_][\p{XID_Continue}]* >`,
+		Outcomes: []TreeOutcome{
+			{"x_1", `(Ident "x_1")`},
+			{"_ℵ2", `(Ident "_ℵ2")`},
+			{"имя", `(Ident "имя")`},
+			{"1x", ""},
+			{"x-1", ""},
+		},
+	},
+	{
+		Grammar: `Text <- Greek / Upper / Other
+Greek <- < [\p{Greek}]+ >
+Upper <- < [\p{Lu}]+ >
+Other <- < [\P{L}]+ >`,
+		Outcomes: []TreeOutcome{
+			{"αβγ", `(Text (Greek "αβγ"))`},
+			{"ABC", `(Text (Upper "ABC"))`},
+			{"123", `(Text (Other "123"))`},
+			{"abc", ""},
+		},
+	},
+	{
+		Grammar: `Brackets <- < [\[\]]+ >`,
+		Outcomes: []TreeOutcome{
+			{"[[]]", `(Brackets "[[]]")`},
+			{"[a]", ""},
+		},
+	},
+}
+
+// Recover tests the labeled failures term^label with recovery rules.
+var Recover = []TreeTest{
+	{
+		Grammar: `Stmts <- Stmt*
+Stmt <- Ident _ '=' _ Num ';'^semi _
+Ident <- < [a-z]+ >
+Num <- < [0-9]+ >
+semi <- ( ![\n] . )*
+_ <- [ \n]*`,
+		Outcomes: []TreeOutcome{
+			{"a=1;b=2;", `(Stmts (Stmt (Ident "a") (Num "1")) (Stmt (Ident "b") (Num "2")))`},
+			{"a=1\nb=2;", `(Stmts (Stmt (Ident "a") (Num "1") (Error :label("semi")))
+				(Stmt (Ident "b") (Num "2")))`},
+			{"a=1 x\nb=2;", `(Stmts (Stmt (Ident "a") (Num "1") (Error " x" :label("semi")))
+				(Stmt (Ident "b") (Num "2")))`},
+			{"a=\n", ""},
+		},
+	},
+	{
+		// The original failure is reported if the recovery rule fails.
+		Grammar: `List <- '[' Item ( ',' Item )* ']'^close
+Item <- < [a-z]+ >
+close <- ';'`,
+		Outcomes: []TreeOutcome{
+			{"[a,b]", `(List (Item "a") (Item "b"))`},
+			{"[a,b;", `(List (Item "a") (Item "b") (Error ";" :label("close")))`},
+			{"[a,b", ""},
+		},
+	},
+	{
+		// The errors recovered inside of dropped nodes are kept.
+		Grammar: `A <- Item+
+drop Item <- < [a-z] > ';'^semi
+semi <- ( ![a-z] . )*`,
+		Outcomes: []TreeOutcome{
+			{"a;b;", `(A)`},
+			{"a!b;", `(A (Error "!" :label("semi")))`},
+		},
+	},
+	{
+		// The recovered errors are memoized with the rule.
+		Grammar: `A <- B 'x' / B 'y'
+B <- < 'b' > ';'^semi
+semi <- ( !'x' !'y' . )*`,
+		Outcomes: []TreeOutcome{
+			{"b;y", `(A (B "b"))`},
+			{"b!y", `(A (B "b" (Error "!" :label("semi"))))`},
+		},
+	},
+}
+
+// Indent tests the indentation terms INDENT, DEDENT and SAMEDENT, which are
+// only supported by parser2.
+var Indent = []TreeTest{
+	{
+		Grammar: `File <- Stmt*
+inline Stmt <- SAMEDENT Body
+inline Body <- If / Simple
+If <- 'if ' Name ':' NL Block
+Block <- INDENT Body Stmt* DEDENT
+Simple <- Name NL
+Name <- < [a-z]+ >
+NL <- "\n"+ / !.`,
+		Outcomes: []TreeOutcome{
+			{"a\nif b:\n  c\n  d\ne\n", `(File (Simple (Name "a"))
+				(If (Name "b") (Block (Simple (Name "c")) (Simple (Name "d"))))
+				(Simple (Name "e")))`},
+			{"if a:\n  if b:\n    c\n  d\ne", `(File
+				(If (Name "a") (Block
+					(If (Name "b") (Block (Simple (Name "c"))))
+					(Simple (Name "d"))))
+				(Simple (Name "e")))`},
+			{"if a:\n  if b:\n\tc\n\nd\n", `(File
+				(If (Name "a") (Block (If (Name "b") (Block (Simple (Name "c"))))))
+				(Simple (Name "d")))`},
+			{"if a:\n    b\n  c\n", ""},
+			{"if a:\nb\n", ""},
+			{"  a\n", ""},
+		},
+	},
+	{
+		// The memoized rules restore the indentation stack they leave.
+		Grammar: `Doc <- Name NL Nested
+Nested <- Open < 'x' > NL DEDENT / Open < 'y' > NL DEDENT
+Open <- INDENT
+Name <- < [a-z]+ >
+NL <- "\n"`,
+		Outcomes: []TreeOutcome{
+			{"a\n  x\n", `(Doc (Name "a") (Nested "x"))`},
+			{"a\n  y\n", `(Doc (Name "a") (Nested "y"))`},
+			{"a\ny\n", ""},
+		},
+	},
+	{
+		// The predicates do not change the indentation stack.
+		Grammar: `A <- &INDENT INDENT < 'a' > DEDENT`,
+		Outcomes: []TreeOutcome{
+			{"  a", `(A "a")`},
+			{"a", ""},
+		},
+	},
+}
+
+// BackRef tests the back-references $name to the labeled captures
+// <name: ...> of the same rule.
+var BackRef = []TreeTest{
+	{
+		Grammar: `Heredoc <- "<<" <tag: [A-Z]+> "\n" < (!("\n" $tag) .)* > "\n" $tag`,
+		Outcomes: []TreeOutcome{
+			{"<<EOF\nab\nEOF", `(Heredoc "ab" :tag("EOF"))`},
+			{"<<A\nA\nA", `(Heredoc "A" :tag("A"))`},
+			{"<<EOF\nab\nEOX", ""},
+			{"<<EOF\nab\nEO", ""},
+		},
+	},
+	{
+		Grammar: `Raw <- "r" <hashes: "#"*> '"' < (!('"' $hashes) .)* > '"' $hashes`,
+		Outcomes: []TreeOutcome{
+			{`r"ab"`, `(Raw "ab" :hashes(""))`},
+			{`r#"a"b"#`, `(Raw "a\"b" :hashes("#"))`},
+			{`r##"a"#"##`, `(Raw "a\"#" :hashes("##"))`},
+			{`r##"a"#`, ""},
+		},
+	},
+	{
+		// Each element has its own tag.
+		Grammar: `Elem <- "<" <tag: [a-z]+> ">" Elem* "</" $tag ">" / "<" <tag: [a-z]+> "/>"`,
+		Outcomes: []TreeOutcome{
+			{"<a><b/><c></c></a>", `(Elem :tag("a") (Elem :tag("b")) (Elem :tag("c")))`},
+			{"<a><a></a></a>", `(Elem :tag("a") (Elem :tag("a")))`},
+			{"<a><b></a></b>", ""},
+			{"<a></b>", ""},
+		},
+	},
+	{
+		// The captures of the failed alternatives are discarded.
+		Grammar: `A <- <x: [a-z]> (<x: [a-z]> "!")? $x`,
+		Outcomes: []TreeOutcome{
+			{"aa", `(A :x("a"))`},
+			{"ab!b", `(A :x("b"))`},
+			{"ab", ""},
+		},
+	},
+}
+
+// Skip tests the directive %skip, which inserts the skip rule between the
+// terms of the syntactic rules.
+var Skip = []TreeTest{
+	{
+		Grammar: `%skip _
+Program <- _ Stmt+ _ !.
+Stmt <- NAME "=" Expr ";"
+Expr <- Term ("+" Term)*
+Term <- NAME / Number
+NAME <- < [a-z]+ >
+%token Number <- < [0-9]+ >
+_ <- ( [ \t\n] / "#" (!"\n" .)* )*`,
+		Outcomes: []TreeOutcome{
+			{"a=1;", `(Program (Stmt (NAME "a") (Expr (Term (Number "1")))))`},
+			{"a = 1 + b;\n  c=d ;", `(Program
+				(Stmt (NAME "a") (Expr (Term (Number "1")) (Term (NAME "b"))))
+				(Stmt (NAME "c") (Expr (Term (NAME "d")))))`},
+			{" a = 12 # comment\n + b ; ", `(Program
+				(Stmt (NAME "a") (Expr (Term (Number "12")) (Term (NAME "b")))))`},
+			{"a = 1 2;", ""},
+			{"a b = 1;", ""},
+		},
+	},
+	{
+		Grammar: `%skip _
+Triple <- D{3} ";" / D{1,2} "!"
+%token D <- < [0-9] >
+_ <- " "*`,
+		Outcomes: []TreeOutcome{
+			{"1 2 3;", `(Triple (D "1") (D "2") (D "3"))`},
+			{"12 3 ;", `(Triple (D "1") (D "2") (D "3"))`},
+			{"1 2 !", `(Triple (D "1") (D "2"))`},
+			{"1 2;", ""},
+			{"1 2 3 !", ""},
+		},
+	},
+	{
+		// The skip rule is inserted into the captures of syntactic rules.
+		Grammar: `%skip _
+Words <- < "a"+ >
+_ <- " "*`,
+		Outcomes: []TreeOutcome{
+			{"a a  a", `(Words "a a  a")`},
+			{"a a ", ""},
+		},
+	},
+}
+
+var Precedence = []TreeTest{
+	{
+		Grammar: `Calc <- Expr !.
+Expr <- %prec Operand
+  %left Add:"+" Sub:"-"
+  %left Mul:"*" Div:"/"
+  %prefix Neg:"-"
+  %right Pow:"^"
+  %postfix Fact:"!"
+Operand <- < [0-9]+ > / "(" Expr ")"`,
+		Outcomes: []TreeOutcome{
+			{"1", `(Calc (Expr (Operand "1")))`},
+			{"1+2*3", `(Calc (Expr (Add "+" (Operand "1") (Mul "*" (Operand "2") (Operand "3")))))`},
+			{"1*2+3", `(Calc (Expr (Add "+" (Mul "*" (Operand "1") (Operand "2")) (Operand "3"))))`},
+			{"1-2-3", `(Calc (Expr (Sub "-" (Sub "-" (Operand "1") (Operand "2")) (Operand "3"))))`},
+			{"2^3^2", `(Calc (Expr (Pow "^" (Operand "2") (Pow "^" (Operand "3") (Operand "2")))))`},
+			{"-2^2", `(Calc (Expr (Neg "-" (Pow "^" (Operand "2") (Operand "2")))))`},
+			{"2*-3", `(Calc (Expr (Mul "*" (Operand "2") (Neg "-" (Operand "3")))))`},
+			{"3!+1", `(Calc (Expr (Add "+" (Fact "!" (Operand "3")) (Operand "1"))))`},
+			{"(1+2)*3", `(Calc (Expr (Mul "*"
+				(Operand (Expr (Add "+" (Operand "1") (Operand "2"))))
+				(Operand "3"))))`},
+			{"1+", ""},
+			{"1++2", ""},
+			{"*1", ""},
+		},
+	},
+	{
+		// The operators without names are labeled by the rule name.
+		Grammar: `%skip _
+Sum <- %prec Num
+  %left "+" "-"
+  %left "*"
+%token Num <- < [0-9]+ >
+_ <- " "*`,
+		Outcomes: []TreeOutcome{
+			{"1 + 2 * 3", `(Sum (Sum "+" (Num "1") (Sum "*" (Num "2") (Num "3"))))`},
+			{"1 -2", `(Sum (Sum "-" (Num "1") (Num "2")))`},
+		},
+	},
+	{
+		// The longest operator is matched first.
+		Grammar: `Cmp <- %prec Var
+  %left Or:"||" BitOr:"|"
+  %left Shl:"<<" Lt:"<"
+Var <- < [a-z] >`,
+		Outcomes: []TreeOutcome{
+			{"a||b|c", `(Cmp (BitOr "|" (Or "||" (Var "a") (Var "b")) (Var "c")))`},
+			{"a<<b<c", `(Cmp (Lt "<" (Shl "<<" (Var "a") (Var "b")) (Var "c")))`},
+		},
+	},
+}
+
+// ByteMode tests are parsed with the dot and the char classes matching
+// single bytes.
+var ByteMode = []TreeTest{
+	{
+		Grammar: `Header <- Magic Version Flags? !.
+Magic <- "\x89PNG"
+Version <- < [\x00-\x7f] >
+Flags <- < [\x80-\xff]+ >`,
+		Outcomes: []TreeOutcome{
+			{"\x89PNG\x01", `(Header (Version "\x01"))`},
+			{"\x89PNG\x00\xff\x80", `(Header (Version "\x00") (Flags "\xff\x80"))`},
+			{"\x89PNG\x80", ""},
+			{"\x89PNG", ""},
+		},
+	},
+	{
+		// The dot matches a single byte of a multibyte UTF-8 rune.
+		Grammar: `Pair <- Byte Byte !.
+Byte <- < . >`,
+		Outcomes: []TreeOutcome{
+			{"é", `(Pair (Byte "\xc3") (Byte "\xa9"))`},
+			{"ab", `(Pair (Byte "a") (Byte "b"))`},
+			{"\xff\xfe", `(Pair (Byte "\xff") (Byte "\xfe"))`},
+			{"€", ""},
+		},
+	},
+	{
+		Grammar: `Word <- < [^\x00]* > "\x00"`,
+		Outcomes: []TreeOutcome{
+			{"héllo\x00", `(Word "héllo")`},
+			{"\x00", `(Word)`},
+			{"abc", ""},
+		},
+	},
+}